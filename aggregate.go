@@ -19,3 +19,49 @@ type Aggregate interface {
 	// Version returns the current aggregate version (for optimistic locking).
 	Version() int64
 }
+
+// Validator is implemented by aggregates that can check their own invariants
+// (e.g. "balance is never negative"). Repository calls Validate after rebuilding
+// an aggregate, so a corrupted event history fails loudly at load time instead of
+// silently producing wrong state.
+type Validator interface {
+	Validate() error
+}
+
+// ApplyErrorReporter is implemented by aggregates that record errors while applying
+// events, such as Base under FailOnUnknownEvents. Repository checks ApplyErr after
+// rebuilding an aggregate, before Validate.
+type ApplyErrorReporter interface {
+	ApplyErr() error
+}
+
+// SnapshotSchemaVersioner is implemented by aggregates that version the shape of
+// their snapshot state. Bump the version whenever the state changes shape: snapshots
+// written under another version are then ignored on load, as cache misses, and the
+// aggregate is rebuilt from its events instead of from a state that no longer fits.
+// A version of 0 disables the check. See also WithSnapshotSchemaVersion.
+type SnapshotSchemaVersioner interface {
+	SnapshotSchemaVersion() int
+}
+
+// Resetter is implemented by aggregates that can be reused for another stream,
+// which lets a Repository created with WithAggregatePool recycle them.
+type Resetter interface {
+	// Reset returns the aggregate to the state the Repository's factory gives it
+	// for streamID. Aggregates embedding Base clear their own fields and call
+	// Base.Reset.
+	Reset(streamID string)
+}
+
+// Snapshotter is implemented by aggregates that can capture and restore their own
+// state, which lets a Repository snapshot them without per-aggregate glue.
+// Base implements it when configured with WithSnapshotter.
+type Snapshotter interface {
+	// Snapshot returns the state to persist. A nil result means there is nothing to snapshot.
+	Snapshot() any
+
+	// ApplySnapshot restores state previously captured by Snapshot. Depending on the
+	// store, state may come back in a generic shape (e.g. map[string]any); DecodeState
+	// converts it into a concrete type.
+	ApplySnapshot(state any) error
+}
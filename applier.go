@@ -0,0 +1,79 @@
+package ges
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// UnknownEventPolicy decides what Base.Apply does with an event that neither a
+// typed applier registered with OnEvent nor the applier passed to Init handles.
+// SkippedEvent and UnknownEvent payloads, which stores deliver for events they could
+// not decode, are always ignored.
+type UnknownEventPolicy int
+
+const (
+	// IgnoreUnknownEvents only advances the version, e.g. for events the aggregate
+	// no longer cares about. It is the default.
+	IgnoreUnknownEvents UnknownEventPolicy = iota
+
+	// FailOnUnknownEvents records an error wrapping ErrUnknownEvent, reported by
+	// ApplyErr, which makes Repository.Load fail instead of returning an aggregate
+	// that silently skipped part of its history.
+	FailOnUnknownEvents
+)
+
+// WithUnknownEvents sets the policy for events without an applier.
+func WithUnknownEvents(policy UnknownEventPolicy) InitOption {
+	return func(b *Base) { b.unknown = policy }
+}
+
+// OnEvent registers apply as the applier of events of type E, so that Apply
+// dispatches to it instead of to the applier passed to Init. Non-nil *E events are
+// dispatched to it too, dereferenced, unless *E has an applier of its own. It
+// replaces a type switch over every event of the aggregate:
+//
+//	a.Init(streamID, nil, ges.WithUnknownEvents(ges.FailOnUnknownEvents))
+//	ges.OnEvent(&a.Base, func(e MoneyDeposited) { a.balance += e.Amount })
+func OnEvent[E Event](b *Base, apply func(E)) {
+	if b.appliers == nil {
+		b.appliers = map[reflect.Type]func(Event){}
+	}
+	b.appliers[reflect.TypeFor[E]()] = func(e Event) { apply(e.(E)) }
+}
+
+// ApplyErr returns the error recorded for the first unknown event applied under
+// FailOnUnknownEvents, or nil.
+func (b *Base) ApplyErr() error { return b.applyErr }
+
+// apply dispatches e to its typed applier, the applier passed to Init, or the
+// unknown event policy, in that order. A *E event without an applier of its own is
+// dispatched to the applier of E, as T and *T are the same event type (see
+// RegisterEventType).
+func (b *Base) apply(e Event) {
+	if fn, ok := b.appliers[reflect.TypeOf(e)]; ok {
+		fn(e)
+		return
+	}
+	if v := reflect.ValueOf(e); v.Kind() == reflect.Pointer {
+		if fn, ok := b.appliers[indirectType(v.Type())]; ok {
+			for v.Kind() == reflect.Pointer && !v.IsNil() {
+				v = v.Elem()
+			}
+			if v.Kind() != reflect.Pointer {
+				fn(v.Interface())
+				return
+			}
+		}
+	}
+	if b.applier != nil {
+		b.applier(e)
+		return
+	}
+	switch e.(type) {
+	case SkippedEvent, UnknownEvent:
+		return
+	}
+	if b.unknown == FailOnUnknownEvents && b.applyErr == nil {
+		b.applyErr = fmt.Errorf("%w: %T at version %d of %s", ErrUnknownEvent, e, b.version+1, b.id)
+	}
+}
@@ -0,0 +1,99 @@
+package ges_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/mickamy/go-event-sourcing"
+)
+
+type Withdrawn struct{ Amount int }
+
+func newTypedWallet(streamID string, policy ges.UnknownEventPolicy) *wallet {
+	var w wallet
+	w.Init(streamID, nil, ges.WithUnknownEvents(policy))
+	ges.OnEvent(&w.Base, func(e Deposited) { w.balance += e.Amount })
+	return &w
+}
+
+func TestOnEvent(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	store := newSpyStore()
+	store.events["Wallet:1"] = []ges.Event{Deposited{Amount: 5}, Withdrawn{Amount: 2}, Deposited{Amount: 1}}
+
+	lenient := ges.NewRepository(store, func(streamID string) *wallet {
+		return newTypedWallet(streamID, ges.IgnoreUnknownEvents)
+	})
+	w, err := lenient.Load(ctx, "Wallet:1")
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	if w.balance != 6 || w.Version() != 3 {
+		t.Fatalf("expected 6 at version 3 with Withdrawn ignored, got %d at %d", w.balance, w.Version())
+	}
+
+	strict := ges.NewRepository(store, func(streamID string) *wallet {
+		return newTypedWallet(streamID, ges.FailOnUnknownEvents)
+	})
+	if _, err := strict.Load(ctx, "Wallet:1"); !errors.Is(err, ges.ErrUnknownEvent) {
+		t.Fatalf("expected ErrUnknownEvent, got %v", err)
+	}
+
+	// Events the store could not decode are not the aggregate's concern.
+	store.events["Wallet:2"] = []ges.Event{Deposited{Amount: 5}, ges.UnknownEvent{Type: "Legacy"}}
+	if w, err := strict.Load(ctx, "Wallet:2"); err != nil || w.balance != 5 || w.Version() != 2 {
+		t.Fatalf("expected the UnknownEvent to be ignored, got %v", err)
+	}
+
+	// A typed applier for the event resolves it.
+	w = newTypedWallet("Wallet:1", ges.FailOnUnknownEvents)
+	ges.OnEvent(&w.Base, func(e Withdrawn) { w.balance -= e.Amount })
+	if err := ges.Rehydrate(ctx, store, "Wallet:1", w); err != nil || w.ApplyErr() != nil {
+		t.Fatalf("rehydrate failed: %v, %v", err, w.ApplyErr())
+	}
+	if w.balance != 4 {
+		t.Fatalf("expected 4, got %d", w.balance)
+	}
+}
+
+func TestOnEvent_SaveRejectsUnknownEvent(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	store := newSpyStore()
+	repo := ges.NewRepository(store, func(streamID string) *wallet {
+		return newTypedWallet(streamID, ges.FailOnUnknownEvents)
+	})
+
+	w, err := repo.Load(ctx, "Wallet:1")
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	w.Raise(Deposited{Amount: 5})
+	w.Raise(Withdrawn{Amount: 2})
+	if err := repo.Save(ctx, w, nil); !errors.Is(err, ges.ErrUnknownEvent) {
+		t.Fatalf("expected ErrUnknownEvent, got %v", err)
+	}
+	if n := len(store.events["Wallet:1"]); n != 0 {
+		t.Fatalf("expected nothing to be appended, got %d events", n)
+	}
+}
+
+func TestOnEvent_PointerEvent(t *testing.T) {
+	t.Parallel()
+
+	w := newTypedWallet("Wallet:1", ges.FailOnUnknownEvents)
+	w.Raise(&Deposited{Amount: 3})
+	w.Raise(Deposited{Amount: 2})
+	if w.balance != 5 || w.Version() != 2 || w.ApplyErr() != nil {
+		t.Fatalf("expected 5 at version 2 from both events, got %d at %d, %v", w.balance, w.Version(), w.ApplyErr())
+	}
+
+	// There is nothing to dereference in a nil pointer.
+	w.Raise((*Deposited)(nil))
+	if w.balance != 5 || !errors.Is(w.ApplyErr(), ges.ErrUnknownEvent) {
+		t.Fatalf("expected the nil event to be unknown, got %d, %v", w.balance, w.ApplyErr())
+	}
+}
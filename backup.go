@@ -0,0 +1,223 @@
+package ges
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// backupFormat and backupVersion identify the archives written by Backup. Restore
+// rejects any other format, and versions it does not know.
+const (
+	backupFormat  = "ges-backup"
+	backupVersion = 1
+)
+
+// backupHeader is the first NDJSON line of a backup.
+type backupHeader struct {
+	Format  string `json:"format"`
+	Version int    `json:"version"`
+}
+
+// backupRecord is any later NDJSON line of a backup: an event, with the position it
+// had in the backed up store, or the latest snapshot of a stream.
+type backupRecord struct {
+	Event    *exportedEvent  `json:"event,omitempty"`
+	Position int64           `json:"position,omitempty"`
+	Snapshot *backupSnapshot `json:"snapshot,omitempty"`
+}
+
+type backupSnapshot struct {
+	StreamID string          `json:"stream_id"`
+	Version  int64           `json:"version"`
+	State    json.RawMessage `json:"state"`
+	Metadata Metadata        `json:"metadata,omitempty"`
+	At       time.Time       `json:"at"`
+}
+
+// Backup writes every event of store to w in global position order, followed by the
+// latest snapshot of every stream, as a versioned NDJSON archive for Restore. Events
+// are read page by page like Sync and written as they are read, so only a page and
+// the IDs of the streams seen are kept in memory. Payloads keep their stored
+// encoding; snapshot states are encoded as JSON. The snapshot of a stream truncated
+// with TruncateBefore is written before its events instead, since Restore needs it
+// to start the stream after its first version.
+//
+// store must implement GlobalReader and RawLoader, and should implement
+// RawRangeLoader (see Sync). Events appended while Backup runs may or may not be
+// included.
+func Backup(ctx context.Context, store EventStore, w io.Writer) error {
+	reader, ok := store.(GlobalReader)
+	if !ok {
+		return fmt.Errorf("ges: backup source %T cannot read across streams", store)
+	}
+	loader, ok := store.(RawLoader)
+	if !ok {
+		return ErrRawUnsupported
+	}
+
+	enc := json.NewEncoder(w)
+	if err := enc.Encode(backupHeader{Format: backupFormat, Version: backupVersion}); err != nil {
+		return fmt.Errorf("ges: backup: %w", err)
+	}
+
+	var streams []string // streams whose snapshot is written last
+	seen := map[string]bool{}
+	_, err := readRaw(ctx, reader, loader, 0, func(streamID string, batch []StoredEvent) error {
+		if !seen[streamID] {
+			seen[streamID] = true
+			if batch[0].Version > 1 {
+				// Truncated: restoring its events needs the snapshot first.
+				if err := writeSnapshotRecord(ctx, store, enc, streamID); err != nil {
+					return err
+				}
+			} else {
+				streams = append(streams, streamID)
+			}
+		}
+		for _, ev := range batch {
+			line, err := exportEvent(ev)
+			if err != nil {
+				return err
+			}
+			if err := enc.Encode(backupRecord{Event: &line, Position: ev.Position}); err != nil {
+				return fmt.Errorf("ges: backup %s at version %d: %w", streamID, ev.Version, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, streamID := range streams {
+		if err := writeSnapshotRecord(ctx, store, enc, streamID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeSnapshotRecord writes the latest snapshot of streamID to enc, if there is one.
+func writeSnapshotRecord(ctx context.Context, store EventStore, enc *json.Encoder, streamID string) error {
+	snap, err := store.LoadSnapshot(ctx, streamID)
+	if err != nil {
+		return err
+	}
+	if !snap.Found {
+		return nil
+	}
+	state, err := json.Marshal(snap.State)
+	if err != nil {
+		return fmt.Errorf("ges: backup snapshot of %s: %w", streamID, err)
+	}
+	record := backupRecord{Snapshot: &backupSnapshot{
+		StreamID: streamID,
+		Version:  snap.Version,
+		State:    state,
+		Metadata: snap.Metadata,
+		At:       snap.At,
+	}}
+	if err := enc.Encode(record); err != nil {
+		return fmt.Errorf("ges: backup snapshot of %s: %w", streamID, err)
+	}
+	return nil
+}
+
+// Restore reads an archive written by Backup from r and writes its events and
+// snapshots to store, which must implement RawAppender. Versions, payload encodings,
+// metadata and times are preserved, and events are appended in their original global
+// order, so positions keep their relative order; the store assigns the positions
+// themselves, and those of an empty store match the backup's unless it had gaps.
+//
+// Restore is idempotent: events the store already has are skipped, and a snapshot is
+// only saved if the store has none for its stream at that version or later, so an
+// interrupted restore can simply be run again. A stream truncated with TruncateBefore
+// before the backup is restored from its first kept version, after its snapshot (see
+// RawAppender); any other stream that lacks versions before the first one restored
+// fails the restore. Snapshot states are restored in their JSON form (see
+// DecodeState) and timestamped by the store.
+func Restore(ctx context.Context, store EventStore, r io.Reader) error {
+	appender, ok := store.(RawAppender)
+	if !ok {
+		return ErrRawUnsupported
+	}
+
+	dec := json.NewDecoder(r)
+	var header backupHeader
+	if err := dec.Decode(&header); err != nil {
+		return fmt.Errorf("ges: restore: could not decode header: %w", err)
+	}
+	if header.Format != backupFormat || header.Version < 1 || header.Version > backupVersion {
+		return fmt.Errorf("ges: restore: unsupported archive %q version %d", header.Format, header.Version)
+	}
+
+	var batch []StoredEvent
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		err := syncStream(ctx, appender, batch[0].StreamID, batch)
+		batch = nil
+		return err
+	}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		var record backupRecord
+		err := dec.Decode(&record)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("ges: restore: could not decode record: %w", err)
+		}
+
+		switch {
+		case record.Event != nil:
+			// Consecutive versions of the same stream are appended together.
+			if n := len(batch); n > 0 {
+				last := batch[n-1]
+				if record.Event.StreamID != last.StreamID || record.Event.Version != last.Version+1 || n == importBatchSize {
+					if err := flush(); err != nil {
+						return err
+					}
+				}
+			}
+			batch = append(batch, record.Event.stored())
+		case record.Snapshot != nil:
+			if err := flush(); err != nil {
+				return err
+			}
+			if err := restoreSnapshot(ctx, store, record.Snapshot); err != nil {
+				return err
+			}
+		}
+	}
+	return flush()
+}
+
+// restoreSnapshot saves snap unless store already has a snapshot of its stream at
+// that version or later.
+func restoreSnapshot(ctx context.Context, store EventStore, snap *backupSnapshot) error {
+	current, err := store.LoadSnapshot(ctx, snap.StreamID)
+	if err != nil {
+		return err
+	}
+	if current.Found && current.Version >= snap.Version {
+		return nil
+	}
+	var state any
+	if err := json.Unmarshal(snap.State, &state); err != nil {
+		return fmt.Errorf("ges: restore snapshot of %s: %w", snap.StreamID, err)
+	}
+	if saver, ok := store.(SnapshotMetadataSaver); ok {
+		return saver.SaveSnapshotWithMeta(ctx, snap.StreamID, snap.Version, state, snap.Metadata)
+	}
+	return store.SaveSnapshot(ctx, snap.StreamID, snap.Version, state)
+}
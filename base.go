@@ -1,5 +1,7 @@
 package ges
 
+import "reflect"
+
 // Base is an embeddable helper to implement Aggregate boilerplate.
 // Semantics:
 //   - Apply(e): mutate state via applier and bump version by 1. Does NOT enqueue.
@@ -7,17 +9,43 @@ package ges
 //   - Version(): current version INCLUDING pending.
 //   - Flush(): returns pending and clears it; also returns
 //     expectedVersion = currentVersion - len(pending_before).
+//   - PendingCount(), ExpectedVersion(): what Flush would return, without clearing.
 type Base struct {
-	id      string
-	version int64
-	pending []Event
-	applier func(Event)
+	id       string
+	version  int64
+	pending  []Event
+	applier  func(Event)
+	snapshot func() any
+	restore  func(state any) error
+	handlers map[reflect.Type]reflect.Value // see On
+	receiver reflect.Value                  // see WithCommandMethods
+	appliers map[reflect.Type]func(Event)   // see OnEvent
+	unknown  UnknownEventPolicy
+	applyErr error
+}
+
+// InitOption configures optional Base capabilities in Init.
+type InitOption func(*Base)
+
+// WithSnapshotter makes the aggregate snapshot-capable: snapshot captures the
+// current state to persist, and restore rebuilds state from a persisted snapshot.
+// With both set, Base implements Snapshotter and a Repository can snapshot the
+// aggregate without per-aggregate glue.
+func WithSnapshotter(snapshot func() any, restore func(state any) error) InitOption {
+	return func(b *Base) {
+		b.snapshot = snapshot
+		b.restore = restore
+	}
 }
 
-// Init sets the stream ID and the state mutation function (applier).
-func (b *Base) Init(streamID string, applier func(Event)) {
+// Init sets the stream ID and the state mutation function (applier). applier may be
+// nil when every event has a typed applier registered with OnEvent.
+func (b *Base) Init(streamID string, applier func(Event), opts ...InitOption) {
 	b.id = streamID
 	b.applier = applier
+	for _, opt := range opts {
+		opt(b)
+	}
 }
 
 // StreamID returns the unique identifier for this aggregate’s event stream.
@@ -36,9 +64,7 @@ func (b *Base) SetVersion(v int64) { b.version = v }
 // Apply mutates state by a single event and advances the version by 1.
 // Typically used for event replay (rehydration) or confirming committed events.
 func (b *Base) Apply(e Event) {
-	if b.applier != nil {
-		b.applier(e)
-	}
+	b.apply(e)
 	b.version++
 }
 
@@ -58,5 +84,42 @@ func (b *Base) Flush() (events []Event, expectedVersion int64) {
 	return
 }
 
+// PendingCount returns the number of events raised since the last Flush.
+func (b *Base) PendingCount() int { return len(b.pending) }
+
+// ExpectedVersion returns the expected version Flush would return, without clearing
+// the pending events: the version the stream must be at for them to be appended.
+func (b *Base) ExpectedVersion() int64 { return b.version - int64(len(b.pending)) }
+
+// Reset sets the stream ID to streamID and drops the version and pending events,
+// keeping the appliers, snapshotter and command handlers set up by Init, OnEvent and On.
+func (b *Base) Reset(streamID string) {
+	b.id = streamID
+	b.version = 0
+	b.pending = nil
+	b.applyErr = nil
+}
+
 // Version returns the current aggregate version INCLUDING pending events.
 func (b *Base) Version() int64 { return b.version }
+
+// Snapshot returns the state captured by the snapshot function set via WithSnapshotter,
+// or nil if none is configured.
+func (b *Base) Snapshot() any {
+	if b.snapshot == nil {
+		return nil
+	}
+	return b.snapshot()
+}
+
+// ApplySnapshot restores state via the restore function set via WithSnapshotter.
+// It does not touch the version; callers restore it with SetVersion.
+// It returns ErrSnapshotUnsupported if no restore function is configured.
+func (b *Base) ApplySnapshot(state any) error {
+	if b.restore == nil {
+		return ErrSnapshotUnsupported
+	}
+	return b.restore(state)
+}
+
+var _ Snapshotter = (*Base)(nil)
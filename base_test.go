@@ -0,0 +1,31 @@
+package ges_test
+
+import (
+	"testing"
+
+	"github.com/mickamy/go-event-sourcing"
+)
+
+func TestBase_PendingCount(t *testing.T) {
+	t.Parallel()
+
+	w := newTypedWallet("Wallet:1", ges.IgnoreUnknownEvents)
+	w.Apply(Deposited{Amount: 1})
+	w.Raise(Deposited{Amount: 2})
+	w.Raise(Deposited{Amount: 3})
+
+	if got := w.PendingCount(); got != 2 {
+		t.Fatalf("expected 2 pending events, got %d", got)
+	}
+	if got := w.ExpectedVersion(); got != 1 {
+		t.Fatalf("expected version 1, got %d", got)
+	}
+	// Neither accessor clears the pending events.
+	events, expected := w.Flush()
+	if len(events) != 2 || expected != 1 {
+		t.Fatalf("expected Flush to return 2 events at 1, got %d at %d", len(events), expected)
+	}
+	if w.PendingCount() != 0 || w.ExpectedVersion() != 3 {
+		t.Fatalf("expected nothing pending at 3 after Flush, got %d at %d", w.PendingCount(), w.ExpectedVersion())
+	}
+}
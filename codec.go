@@ -3,6 +3,7 @@ package ges
 import (
 	"encoding/json"
 	"fmt"
+	"strings"
 )
 
 // EventCodec defines how events are encoded/decoded for persistence.
@@ -12,13 +13,76 @@ type EventCodec interface {
 	Decode(b []byte) (any, error)
 }
 
+// ContentTyper is implemented by codecs that report the media type they encode to
+// (e.g. "application/json"). Stores record it with each payload, so rows written by
+// an earlier codec can still be decoded after a type switches to a new one.
+type ContentTyper interface {
+	ContentType() string
+}
+
+// ContentTypeOf returns the content type reported by codec, or "" if it reports none.
+func ContentTypeOf(codec EventCodec) string {
+	if ct, ok := codec.(ContentTyper); ok {
+		return ct.ContentType()
+	}
+	return ""
+}
+
+// WithContentType returns codec reporting contentType, for codecs that do not
+// implement ContentTyper themselves.
+func WithContentType(codec EventCodec, contentType string) EventCodec {
+	return contentTypedCodec{EventCodec: codec, contentType: contentType}
+}
+
+type contentTypedCodec struct {
+	EventCodec
+	contentType string
+}
+
+func (c contentTypedCodec) ContentType() string { return c.contentType }
+
+// CodecKey identifies the codec for one encoding of an event type.
+type CodecKey struct {
+	EventType   string
+	ContentType string
+}
+
+// DecoderFor returns the codec for a payload of eventType stored with contentType:
+// the one registered in byContentType under (eventType, contentType) if any,
+// otherwise byType[eventType]. Rows without a content type always use byType.
+// A "*pkg.T" type, as recorded for pointer events before EventType dereferenced
+// them, falls back to the codecs of "pkg.T". It returns nil when neither registry
+// has a codec.
+func DecoderFor(
+	byType map[string]EventCodec,
+	byContentType map[CodecKey]EventCodec,
+	eventType, contentType string,
+) EventCodec {
+	if contentType != "" {
+		if codec := byContentType[CodecKey{EventType: eventType, ContentType: contentType}]; codec != nil {
+			return codec
+		}
+	}
+	if codec := byType[eventType]; codec != nil {
+		return codec
+	}
+	if name, ok := strings.CutPrefix(eventType, "*"); ok {
+		return DecoderFor(byType, byContentType, name, contentType)
+	}
+	return nil
+}
+
 // JSONCodec is a generic implementation of EventCodec for JSON-based encoding.
+// Its content type is "application/json". See JSONCodecWith for stricter decoding
+// and custom formatting.
 func JSONCodec[T any]() EventCodec {
 	return jsonCodec[T]{}
 }
 
 type jsonCodec[T any] struct{}
 
+func (jsonCodec[T]) ContentType() string { return "application/json" }
+
 func (jsonCodec[T]) Encode(v any) ([]byte, error) {
 	return json.Marshal(v)
 }
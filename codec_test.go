@@ -0,0 +1,43 @@
+package ges_test
+
+import (
+	"testing"
+
+	"github.com/mickamy/go-event-sourcing"
+)
+
+func TestDecoderFor(t *testing.T) {
+	t.Parallel()
+
+	current := ges.JSONCodec[int]()
+	legacy := ges.WithContentType(ges.JSONCodec[string](), "text/legacy")
+	byType := map[string]ges.EventCodec{"Added": current}
+	byContentType := map[ges.CodecKey]ges.EventCodec{
+		{EventType: "Added", ContentType: "text/legacy"}: legacy,
+	}
+
+	if ct := ges.ContentTypeOf(current); ct != "application/json" {
+		t.Fatalf("expected application/json, got %q", ct)
+	}
+	if ct := ges.ContentTypeOf(legacy); ct != "text/legacy" {
+		t.Fatalf("expected text/legacy, got %q", ct)
+	}
+
+	for _, tc := range []struct {
+		eventType, contentType string
+		want                   ges.EventCodec
+	}{
+		{"Added", "text/legacy", legacy},
+		{"Added", "application/json", current},
+		{"Added", "", current},
+		{"Removed", "text/legacy", nil},
+		// Pointer events were once recorded under a "*" name.
+		{"*Added", "text/legacy", legacy},
+		{"*Added", "", current},
+		{"*Removed", "", nil},
+	} {
+		if got := ges.DecoderFor(byType, byContentType, tc.eventType, tc.contentType); got != tc.want {
+			t.Fatalf("%s/%q: expected %v, got %v", tc.eventType, tc.contentType, tc.want, got)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package cbor
+
+import (
+	"fmt"
+
+	"github.com/fxamacker/cbor/v2"
+
+	"github.com/mickamy/go-event-sourcing"
+)
+
+// encMode uses the Core Deterministic Encoding rules (RFC 8949 §4.2), so equal
+// values always encode to identical bytes (e.g., map keys are sorted).
+var encMode = func() cbor.EncMode {
+	em, err := cbor.CoreDetEncOptions().EncMode()
+	if err != nil {
+		panic(err)
+	}
+	return em
+}()
+
+// Codec returns an EventCodec that encodes events of type T as deterministic CBOR.
+// Decode returns a value of type T. Its content type is "application/cbor".
+func Codec[T any]() ges.EventCodec {
+	return codec[T]{}
+}
+
+type codec[T any] struct{}
+
+func (codec[T]) ContentType() string { return "application/cbor" }
+
+func (codec[T]) Encode(v any) ([]byte, error) {
+	b, err := encMode.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("ges-cbor: failed to encode cbor: %w", err)
+	}
+	return b, nil
+}
+
+func (codec[T]) Decode(b []byte) (any, error) {
+	var v T
+	if err := cbor.Unmarshal(b, &v); err != nil {
+		return nil, fmt.Errorf("ges-cbor: failed to decode cbor: %w", err)
+	}
+	return v, nil
+}
@@ -0,0 +1,88 @@
+package cbor_test
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/mickamy/go-event-sourcing"
+	"github.com/mickamy/go-event-sourcing/codecs/cbor"
+	"github.com/mickamy/go-event-sourcing/stores/mem"
+)
+
+type Deposited struct {
+	Amount int64             `cbor:"amount"`
+	Tags   map[string]string `cbor:"tags"`
+}
+
+func TestCodec_RoundTrip(t *testing.T) {
+	t.Parallel()
+
+	c := cbor.Codec[Deposited]()
+	in := Deposited{Amount: 9007199254740993, Tags: map[string]string{"b": "2", "a": "1"}}
+
+	b, err := c.Encode(in)
+	if err != nil {
+		t.Fatalf("encode failed: %v", err)
+	}
+	out, err := c.Decode(b)
+	if err != nil {
+		t.Fatalf("decode failed: %v", err)
+	}
+	if !reflect.DeepEqual(out, in) {
+		t.Fatalf("expected %+v, got %+v", in, out)
+	}
+}
+
+func TestCodec_Deterministic(t *testing.T) {
+	t.Parallel()
+
+	c := cbor.Codec[Deposited]()
+	first, err := c.Encode(Deposited{Tags: map[string]string{"x": "1", "y": "2", "z": "3"}})
+	if err != nil {
+		t.Fatalf("encode failed: %v", err)
+	}
+	for range 20 {
+		b, err := c.Encode(Deposited{Tags: map[string]string{"z": "3", "y": "2", "x": "1"}})
+		if err != nil {
+			t.Fatalf("encode failed: %v", err)
+		}
+		if !bytes.Equal(first, b) {
+			t.Fatalf("expected identical encodings, got %x and %x", first, b)
+		}
+	}
+}
+
+func TestCodec_DecodeError(t *testing.T) {
+	t.Parallel()
+
+	if _, err := cbor.Codec[Deposited]().Decode([]byte{0xff}); err == nil {
+		t.Fatalf("expected decode error")
+	}
+}
+
+func TestCodec_Store(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	in := Deposited{Amount: 9007199254740993, Tags: map[string]string{"a": "1"}}
+	s := mem.New(mem.WithTypeRegistry(map[string]ges.EventCodec{ges.EventType(in): cbor.Codec[Deposited]()}))
+	if _, err := s.Append(ctx, "Account:1", 0, []ges.Event{in}, nil); err != nil {
+		t.Fatalf("append failed: %v", err)
+	}
+
+	raw, err := s.LoadRaw(ctx, "Account:1", 0)
+	if err != nil || len(raw) != 1 {
+		t.Fatalf("expected one raw event, got %v, %v", raw, err)
+	}
+	if raw[0].ContentType != "application/cbor" {
+		t.Fatalf("expected a CBOR payload, got %q", raw[0].ContentType)
+	}
+	if _, err := s.AppendRaw(ctx, "Account:2", 0, raw); err != nil {
+		t.Fatalf("append raw failed: %v", err)
+	}
+	events, _, err := s.Load(ctx, "Account:2", 0)
+	if err != nil || len(events) != 1 || !reflect.DeepEqual(events[0], in) {
+		t.Fatalf("expected %+v back, got %v, %v", in, events, err)
+	}
+}
@@ -0,0 +1,15 @@
+module github.com/mickamy/go-event-sourcing/codecs/cbor
+
+go 1.24.0
+
+replace github.com/mickamy/go-event-sourcing => ../..
+
+replace github.com/mickamy/go-event-sourcing/stores/mem => ../../stores/mem
+
+require (
+	github.com/fxamacker/cbor/v2 v2.9.0
+	github.com/mickamy/go-event-sourcing v0.0.0
+	github.com/mickamy/go-event-sourcing/stores/mem v0.0.0
+)
+
+require github.com/x448/float16 v0.8.4 // indirect
@@ -0,0 +1,45 @@
+package gob
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+
+	"github.com/mickamy/go-event-sourcing"
+)
+
+// Codec returns an EventCodec that encodes events of type T with encoding/gob.
+// Decode returns a value of type T. Its content type is "application/x-gob".
+//
+// gob is faster than JSON and needs no schema, but it is Go-only, and the stored
+// bytes are tied to T's field names and types: renaming or retyping a field breaks
+// decoding of events written before the change. Prefer JSON or protobuf for events
+// consumed outside Go or expected to evolve.
+//
+// Every payload carries its own type description so events can be decoded one at a
+// time, which makes gob payloads larger than a streaming gob encoder would.
+func Codec[T any]() ges.EventCodec {
+	var zero T
+	gob.Register(zero)
+	return codec[T]{}
+}
+
+type codec[T any] struct{}
+
+func (codec[T]) ContentType() string { return "application/x-gob" }
+
+func (codec[T]) Encode(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+		return nil, fmt.Errorf("ges-gob: failed to encode gob: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func (codec[T]) Decode(b []byte) (any, error) {
+	var v T
+	if err := gob.NewDecoder(bytes.NewReader(b)).Decode(&v); err != nil {
+		return nil, fmt.Errorf("ges-gob: failed to decode gob: %w", err)
+	}
+	return v, nil
+}
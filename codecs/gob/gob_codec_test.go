@@ -0,0 +1,44 @@
+package gob_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/mickamy/go-event-sourcing/codecs/gob"
+)
+
+type Deposited struct {
+	Amount int64
+	At     time.Time
+	Tags   []string
+}
+
+func TestCodec_RoundTrip(t *testing.T) {
+	t.Parallel()
+
+	c := gob.Codec[Deposited]()
+	in := Deposited{Amount: 9007199254740993, At: time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC), Tags: []string{"a"}}
+
+	for _, v := range []any{in, &in} {
+		b, err := c.Encode(v)
+		if err != nil {
+			t.Fatalf("encode failed: %v", err)
+		}
+		out, err := c.Decode(b)
+		if err != nil {
+			t.Fatalf("decode failed: %v", err)
+		}
+		if !reflect.DeepEqual(out, in) {
+			t.Fatalf("expected %+v, got %+v", in, out)
+		}
+	}
+}
+
+func TestCodec_DecodeError(t *testing.T) {
+	t.Parallel()
+
+	if _, err := gob.Codec[Deposited]().Decode([]byte("not gob")); err == nil {
+		t.Fatalf("expected decode error")
+	}
+}
@@ -0,0 +1,82 @@
+package ges
+
+import (
+	"fmt"
+	"reflect"
+)
+
+var errorType = reflect.TypeFor[error]()
+
+// WithCommandMethods lets HandleCommand dispatch to methods of receiver named
+// Handle<CommandType>, e.g. HandleDeposit(cmd Deposit) error. receiver is usually
+// the aggregate embedding Base.
+func WithCommandMethods(receiver any) InitOption {
+	return func(b *Base) { b.receiver = reflect.ValueOf(receiver) }
+}
+
+// On registers handler for commands of the same type as cmd. handler must be a
+// func(C) error where C is that type; On panics otherwise, as registration happens
+// while wiring an aggregate. Handlers registered with On take precedence over
+// methods found via WithCommandMethods.
+//
+//	acc.On(OpenAccount{}, func(c OpenAccount) error {
+//		acc.Raise(AccountOpened{ID: c.ID})
+//		return nil
+//	})
+func (b *Base) On(cmd any, handler any) {
+	t := reflect.TypeOf(cmd)
+	fn := reflect.ValueOf(handler)
+	if t == nil || !fn.IsValid() || !isCommandHandler(fn.Type(), t) {
+		panic(fmt.Sprintf("ges: handler for %v must be a func(%v) error, got %T", t, t, handler))
+	}
+	if b.handlers == nil {
+		b.handlers = map[reflect.Type]reflect.Value{}
+	}
+	b.handlers[t] = fn
+}
+
+// HandleCommand runs the handler for cmd: one registered with On, or else a
+// Handle<CommandType> method of the receiver set with WithCommandMethods. Handlers
+// record their outcome with Raise. It returns an error wrapping ErrUnknownCommand
+// if no handler matches.
+func (b *Base) HandleCommand(cmd any) error {
+	if cmd == nil {
+		return fmt.Errorf("%w: nil", ErrUnknownCommand)
+	}
+	t := reflect.TypeOf(cmd)
+	fn, ok := b.handlers[t]
+	if !ok {
+		fn, ok = b.commandMethod(t)
+	}
+	if !ok {
+		return fmt.Errorf("%w: %v", ErrUnknownCommand, t)
+	}
+	err, _ := fn.Call([]reflect.Value{reflect.ValueOf(cmd)})[0].Interface().(error)
+	return err
+}
+
+// commandMethod finds the receiver's Handle<CommandType> method for commands of type t.
+func (b *Base) commandMethod(t reflect.Type) (reflect.Value, bool) {
+	if !b.receiver.IsValid() {
+		return reflect.Value{}, false
+	}
+	name := t.Name()
+	if t.Kind() == reflect.Pointer {
+		name = t.Elem().Name()
+	}
+	if name == "" {
+		return reflect.Value{}, false
+	}
+	m := b.receiver.MethodByName("Handle" + name)
+	if !m.IsValid() || !isCommandHandler(m.Type(), t) {
+		return reflect.Value{}, false
+	}
+	return m, true
+}
+
+// isCommandHandler reports whether fn is a func(cmd) error.
+func isCommandHandler(fn, cmd reflect.Type) bool {
+	return fn.Kind() == reflect.Func &&
+		fn.NumIn() == 1 && cmd.AssignableTo(fn.In(0)) &&
+		fn.NumOut() == 1 && fn.Out(0) == errorType
+}
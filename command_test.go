@@ -0,0 +1,83 @@
+package ges_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/mickamy/go-event-sourcing"
+)
+
+type Deposit struct{ Amount int }
+
+type Withdraw struct{ Amount int }
+
+type Deposited struct{ Amount int }
+
+type wallet struct {
+	ges.Base
+	balance int
+}
+
+func (w *wallet) HandleDeposit(c Deposit) error {
+	w.Raise(Deposited{Amount: c.Amount})
+	return nil
+}
+
+func newWallet() *wallet {
+	var w wallet
+	w.Init("Wallet:1", func(e ges.Event) {
+		if ev, ok := e.(Deposited); ok {
+			w.balance += ev.Amount
+		}
+	}, ges.WithCommandMethods(&w))
+	return &w
+}
+
+func TestBase_HandleCommand(t *testing.T) {
+	t.Parallel()
+
+	w := newWallet()
+	if err := w.HandleCommand(Deposit{Amount: 5}); err != nil {
+		t.Fatalf("Deposit failed: %v", err)
+	}
+	if w.balance != 5 || w.Version() != 1 {
+		t.Fatalf("expected balance 5 at version 1, got %d at %d", w.balance, w.Version())
+	}
+
+	if err := w.HandleCommand(Withdraw{Amount: 1}); !errors.Is(err, ges.ErrUnknownCommand) {
+		t.Fatalf("expected ErrUnknownCommand, got %v", err)
+	}
+
+	errInsufficient := errors.New("insufficient funds")
+	w.On(Withdraw{}, func(c Withdraw) error {
+		if c.Amount > w.balance {
+			return errInsufficient
+		}
+		w.Raise(Deposited{Amount: -c.Amount})
+		return nil
+	})
+	if err := w.HandleCommand(Withdraw{Amount: 10}); !errors.Is(err, errInsufficient) {
+		t.Fatalf("expected the handler's error, got %v", err)
+	}
+	if err := w.HandleCommand(Withdraw{Amount: 2}); err != nil || w.balance != 3 {
+		t.Fatalf("expected balance 3, got %d (%v)", w.balance, err)
+	}
+
+	// Registered handlers win over methods.
+	w.On(Deposit{}, func(Deposit) error { return errInsufficient })
+	if err := w.HandleCommand(Deposit{Amount: 1}); !errors.Is(err, errInsufficient) {
+		t.Fatalf("expected the registered handler to run, got %v", err)
+	}
+}
+
+func TestBase_OnPanicsOnMismatchedHandler(t *testing.T) {
+	t.Parallel()
+
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("expected On to panic")
+		}
+	}()
+	var b ges.Base
+	b.On(Deposit{}, func(Withdraw) error { return nil })
+}
@@ -0,0 +1,69 @@
+package ges
+
+import (
+	"context"
+	"fmt"
+)
+
+// Cursor reads a single stream incrementally across process restarts. It keeps the
+// version of the last event it returned in a Checkpointer under its name, so a new
+// Cursor with the same name resumes where the previous one stopped. It is the
+// per-stream counterpart of a Subscription, for tooling that is driven by its
+// caller rather than by polling.
+//
+// A Cursor is not safe for concurrent use.
+type Cursor struct {
+	name     string
+	streamID string
+	reader   StreamIterator
+	cp       Checkpointer
+	version  int64
+	loaded   bool
+}
+
+// NewCursor creates a cursor over streamID that records its progress in cp under name.
+func NewCursor(name string, reader StreamIterator, streamID string, cp Checkpointer) *Cursor {
+	return &Cursor{name: name, streamID: streamID, reader: reader, cp: cp}
+}
+
+// Next returns up to batch events following the last one returned, and advances the
+// cursor past them: their version is checkpointed before Next returns, so a batch is
+// handed out once even if processing it later fails. An empty result means the
+// cursor is at the end of the stream for now.
+func (c *Cursor) Next(ctx context.Context, batch int) ([]StoredEvent, error) {
+	if !c.loaded {
+		v, err := c.cp.LoadCheckpoint(ctx, c.name)
+		if err != nil {
+			return nil, fmt.Errorf("ges: cursor %s: could not load checkpoint: %w", c.name, err)
+		}
+		c.version, c.loaded = v, true
+	}
+	if batch < 1 {
+		return nil, nil
+	}
+
+	var events []StoredEvent
+	for ev, err := range c.reader.LoadIter(ctx, c.streamID, c.version) {
+		if err != nil {
+			return nil, fmt.Errorf("ges: cursor %s: could not read %s: %w", c.name, c.streamID, err)
+		}
+		events = append(events, ev)
+		if len(events) == batch {
+			break
+		}
+	}
+	if len(events) == 0 {
+		return nil, nil
+	}
+
+	last := events[len(events)-1].Version
+	if err := c.cp.SaveCheckpoint(ctx, c.name, last); err != nil {
+		return nil, fmt.Errorf("ges: cursor %s: could not save checkpoint: %w", c.name, err)
+	}
+	c.version = last
+	return events, nil
+}
+
+// Version returns the version of the last event the cursor returned, or of the
+// checkpoint it resumed from. It is 0 before the first call to Next.
+func (c *Cursor) Version() int64 { return c.version }
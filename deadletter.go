@@ -0,0 +1,100 @@
+package ges
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// DeadLetter records an event that a subscription gave up on after repeated failures.
+type DeadLetter struct {
+	Subscription string
+	Position     int64
+	StreamID     string
+	Version      int64
+	Type         string
+	Error        string // Last handler error
+	Attempts     int
+	At           time.Time
+}
+
+// DeadLetterStore persists dead-lettered events per subscription.
+type DeadLetterStore interface {
+	// SaveDeadLetter records dl, replacing any entry for the same subscription and position.
+	SaveDeadLetter(ctx context.Context, dl DeadLetter) error
+
+	// ListDeadLetters returns the dead letters of a subscription ordered by position.
+	ListDeadLetters(ctx context.Context, subscription string) ([]DeadLetter, error)
+
+	// DeleteDeadLetter removes the entry for subscription and position, if any.
+	DeleteDeadLetter(ctx context.Context, subscription string, position int64) error
+}
+
+// WithDeadLetter makes the subscription give up on an event after maxAttempts
+// consecutive handler failures: the event is recorded in store and skipped, so a
+// single poison event no longer blocks the checkpoint. Use Redrive to re-process
+// dead-lettered events once the cause is fixed.
+func WithDeadLetter(store DeadLetterStore, maxAttempts int) SubscriptionOption {
+	return func(s *Subscription) {
+		s.deadLetters = store
+		s.maxAttempts = max(maxAttempts, 1)
+	}
+}
+
+// deadLetter records ev as dead-lettered after attempts failures.
+func (s *Subscription) deadLetter(ctx context.Context, ev StoredEvent, attempts int, cause error) error {
+	if err := s.deadLetters.SaveDeadLetter(ctx, DeadLetter{
+		Subscription: s.name,
+		Position:     ev.Position,
+		StreamID:     ev.StreamID,
+		Version:      ev.Version,
+		Type:         ev.Type,
+		Error:        cause.Error(),
+		Attempts:     attempts,
+		At:           time.Now(),
+	}); err != nil {
+		return fmt.Errorf("ges: subscription %s: could not save dead letter: %w", s.name, err)
+	}
+	return nil
+}
+
+// Redrive re-delivers the subscription's dead-lettered events to its handler in
+// position order, removing each one that is handled successfully. It returns the
+// number of events redriven; failures stay dead-lettered with the new error.
+func (s *Subscription) Redrive(ctx context.Context) (int, error) {
+	if s.deadLetters == nil {
+		return 0, fmt.Errorf("ges: subscription %s has no dead letter store", s.name)
+	}
+
+	letters, err := s.deadLetters.ListDeadLetters(ctx, s.name)
+	if err != nil {
+		return 0, fmt.Errorf("ges: subscription %s: could not list dead letters: %w", s.name, err)
+	}
+
+	redriven := 0
+	for _, dl := range letters {
+		events, err := s.reader.ReadAll(ctx, dl.Position-1, 1)
+		if err != nil {
+			return redriven, fmt.Errorf("ges: subscription %s: could not read event: %w", s.name, err)
+		}
+		if len(events) == 0 || events[0].Position != dl.Position {
+			// The event no longer exists; nothing left to re-process.
+			if err := s.deadLetters.DeleteDeadLetter(ctx, s.name, dl.Position); err != nil {
+				return redriven, err
+			}
+			continue
+		}
+
+		if err := s.handler(ctx, events[0]); err != nil {
+			if dlErr := s.deadLetter(ctx, events[0], dl.Attempts+1, err); dlErr != nil {
+				return redriven, dlErr
+			}
+			continue
+		}
+		if err := s.deadLetters.DeleteDeadLetter(ctx, s.name, dl.Position); err != nil {
+			return redriven, fmt.Errorf("ges: subscription %s: could not delete dead letter: %w", s.name, err)
+		}
+		redriven++
+	}
+	return redriven, nil
+}
@@ -0,0 +1,64 @@
+package ges
+
+import (
+	"context"
+	"fmt"
+)
+
+// Change describes how a stream evolved between two versions, as computed by Diff.
+type Change[S any] struct {
+	FromVersion int64
+	ToVersion   int64
+	// Before and After are the states folded up to FromVersion and ToVersion.
+	Before S
+	After  S
+	// Events are the events with FromVersion < Version <= ToVersion, in order.
+	Events []StoredEvent
+}
+
+// Diff computes what changed in streamID between version from and version to, e.g.
+// for an audit view showing "version 5→6: balance 1000→1500". It folds the events up
+// to from into the Before state, starting from the zero S, then the events up to to
+// into the After state. fold must not modify the state it receives in place if S
+// shares memory (maps, slices, pointers), or Before will reflect later events too.
+//
+// It fails if from is negative, from > to, or the stream has fewer than to events.
+// The fold always starts at version 1, so a stream truncated with Truncater cannot
+// be diffed, even between versions it still has; the result then wraps
+// ErrStreamTruncated.
+func Diff[S any](ctx context.Context, store RangeLoader, streamID string, from, to int64, fold func(state S, ev StoredEvent) S) (Change[S], error) {
+	if from < 0 || from > to {
+		return Change[S]{}, fmt.Errorf("ges: invalid version range %d..%d for %s", from, to, streamID)
+	}
+
+	before, err := store.LoadRange(ctx, streamID, 0, from)
+	if err != nil {
+		return Change[S]{}, err
+	}
+	events, err := store.LoadRange(ctx, streamID, from, to)
+	if err != nil {
+		return Change[S]{}, err
+	}
+	loaded := before
+	if len(loaded) == 0 {
+		loaded = events
+	}
+	if len(loaded) > 0 && loaded[0].Version > 1 {
+		return Change[S]{}, fmt.Errorf("%w: %s starts at version %d, so it cannot be diffed",
+			ErrStreamTruncated, streamID, loaded[0].Version)
+	}
+	if reached := from + int64(len(events)); int64(len(before)) != from || reached != to {
+		return Change[S]{}, fmt.Errorf("ges: could not diff %s at versions %d..%d: stream has %d events",
+			streamID, from, to, int64(len(before))+int64(len(events)))
+	}
+
+	change := Change[S]{FromVersion: from, ToVersion: to, Events: events}
+	for _, ev := range before {
+		change.Before = fold(change.Before, ev)
+	}
+	change.After = change.Before
+	for _, ev := range events {
+		change.After = fold(change.After, ev)
+	}
+	return change, nil
+}
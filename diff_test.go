@@ -0,0 +1,57 @@
+package ges_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/mickamy/go-event-sourcing"
+)
+
+// rangeStore serves LoadRange from a fixed list of events of one stream.
+type rangeStore []ges.StoredEvent
+
+func (s rangeStore) LoadRange(_ context.Context, _ string, from, to int64) ([]ges.StoredEvent, error) {
+	var out []ges.StoredEvent
+	for _, ev := range s {
+		if ev.Version > from && ev.Version <= to {
+			out = append(out, ev)
+		}
+	}
+	return out, nil
+}
+
+func TestDiff(t *testing.T) {
+	t.Parallel()
+
+	store := rangeStore{
+		{Version: 1, Payload: Deposited{Amount: 1000}},
+		{Version: 2, Payload: Deposited{Amount: 500}},
+		{Version: 3, Payload: Deposited{Amount: -200}},
+	}
+	balance := func(total int, ev ges.StoredEvent) int { return total + ev.Payload.(Deposited).Amount }
+
+	change, err := ges.Diff(t.Context(), store, "Account:1", 1, 2, balance)
+	if err != nil {
+		t.Fatalf("diff failed: %v", err)
+	}
+	if change.Before != 1000 || change.After != 1500 || len(change.Events) != 1 || change.Events[0].Version != 2 {
+		t.Fatalf("expected 1000→1500 through version 2, got %+v", change)
+	}
+
+	if _, err := ges.Diff(t.Context(), store, "Account:1", 2, 5, balance); err == nil {
+		t.Fatalf("expected an error for versions past the end of the stream")
+	}
+	if _, err := ges.Diff(t.Context(), store, "Account:1", 2, 1, balance); err == nil {
+		t.Fatalf("expected an error for a reversed range")
+	}
+
+	// Version 1 is gone, so neither state can be folded from the start.
+	truncated := store[1:]
+	if _, err := ges.Diff(t.Context(), truncated, "Account:1", 2, 3, balance); !errors.Is(err, ges.ErrStreamTruncated) {
+		t.Fatalf("expected ErrStreamTruncated, got %v", err)
+	}
+	if _, err := ges.Diff(t.Context(), truncated, "Account:1", 0, 3, balance); !errors.Is(err, ges.ErrStreamTruncated) {
+		t.Fatalf("expected ErrStreamTruncated, got %v", err)
+	}
+}
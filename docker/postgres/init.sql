@@ -4,21 +4,71 @@ CREATE DATABASE ges;
 
 CREATE TABLE IF NOT EXISTS events
 (
-    stream_id  TEXT        NOT NULL,
-    version    BIGINT      NOT NULL,
-    event_id   UUID                 DEFAULT gen_random_uuid(),
-    event_type TEXT        NOT NULL,
-    payload    JSONB       NOT NULL,
-    metadata   JSONB       NOT NULL DEFAULT '{}'::jsonb,
-    at         TIMESTAMPTZ NOT NULL DEFAULT now(),
+    position     BIGSERIAL   NOT NULL,
+    stream_id    TEXT        NOT NULL,
+    version      BIGINT      NOT NULL,
+    event_id     UUID                 DEFAULT gen_random_uuid(),
+    event_type   TEXT        NOT NULL,
+    content_type TEXT        NOT NULL DEFAULT '',
+    payload      JSONB       NOT NULL,
+    metadata     JSONB       NOT NULL DEFAULT '{}'::jsonb,
+    headers      JSONB       NOT NULL DEFAULT '{}'::jsonb,
+    occurred_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+    at           TIMESTAMPTZ NOT NULL DEFAULT now(),
+    dedup_key    TEXT,
+    md_tenant_id TEXT,
+    md_user_id   TEXT,
     PRIMARY KEY (stream_id, version),
-    UNIQUE (event_id)
+    UNIQUE (event_id),
+    UNIQUE (position)
 );
 
+CREATE UNIQUE INDEX IF NOT EXISTS events_dedup_key ON events (stream_id, dedup_key) WHERE dedup_key IS NOT NULL;
+CREATE INDEX IF NOT EXISTS events_md_tenant_id ON events (md_tenant_id, position);
+CREATE INDEX IF NOT EXISTS events_md_user_id ON events (md_user_id, position);
+
 CREATE TABLE IF NOT EXISTS snapshots
 (
     stream_id TEXT PRIMARY KEY,
     version   BIGINT      NOT NULL,
     state     JSONB       NOT NULL,
+    metadata  JSONB       NOT NULL DEFAULT '{}'::jsonb,
     at        TIMESTAMPTZ NOT NULL DEFAULT now()
 );
+
+CREATE TABLE IF NOT EXISTS snapshot_history
+(
+    stream_id TEXT        NOT NULL,
+    version   BIGINT      NOT NULL,
+    state     JSONB       NOT NULL,
+    metadata  JSONB       NOT NULL DEFAULT '{}'::jsonb,
+    at        TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (stream_id, version)
+);
+
+CREATE TABLE IF NOT EXISTS stream_headers
+(
+    stream_id  TEXT PRIMARY KEY,
+    metadata   JSONB       NOT NULL DEFAULT '{}'::jsonb,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS checkpoints
+(
+    name       TEXT PRIMARY KEY,
+    position   BIGINT      NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS dead_letters
+(
+    subscription TEXT        NOT NULL,
+    position     BIGINT      NOT NULL,
+    stream_id    TEXT        NOT NULL,
+    version      BIGINT      NOT NULL,
+    event_type   TEXT        NOT NULL,
+    error        TEXT        NOT NULL,
+    attempts     INT         NOT NULL,
+    at           TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (subscription, position)
+);
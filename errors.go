@@ -8,6 +8,77 @@ var (
 	// ErrVersionConflict indicates that the expectedVersion did not match
 	// the current version in the store, typically due to concurrent writes.
 	ErrVersionConflict = fmt.Errorf("eventstore: version conflict")
+
+	// ErrSnapshotUnsupported indicates that an aggregate cannot restore itself from a snapshot.
+	ErrSnapshotUnsupported = fmt.Errorf("ges: snapshot not supported")
+
+	// ErrRawUnsupported indicates that a store cannot load or append events in their
+	// encoded form (see RawLoader and RawAppender).
+	ErrRawUnsupported = fmt.Errorf("ges: raw event access not supported")
+
+	// ErrInvalidExpectedVersion indicates a negative expectedVersion. Versions start
+	// at 0 for an empty stream, so a negative value is always a caller bug and is
+	// rejected rather than treated as a conflict.
+	ErrInvalidExpectedVersion = fmt.Errorf("ges: invalid expected version")
+
+	// ErrStreamNotFound indicates that a write expected an existing stream but the
+	// stream has no events. A *VersionConflictError with ActualVersion 0 and a
+	// positive ExpectedVersion matches both it and ErrVersionConflict.
+	ErrStreamNotFound = fmt.Errorf("ges: stream not found")
+	// ErrTruncateUnsafe indicates that TruncateBefore would delete events that no
+	// snapshot covers.
+	ErrTruncateUnsafe = fmt.Errorf("ges: truncation past the latest snapshot")
+
+	// ErrInvalidAggregate indicates that an aggregate rebuilt by a Repository failed
+	// its Validator check. The error also wraps the one returned by Validate.
+	ErrInvalidAggregate = fmt.Errorf("ges: aggregate invariants violated")
+
+	// ErrVersionLimit indicates that an append would move a stream past the highest
+	// version its store allows. Without a configured limit that is math.MaxInt64,
+	// so version arithmetic never overflows.
+	ErrVersionLimit = fmt.Errorf("ges: stream version limit exceeded")
+
+	// ErrDuplicateKey indicates an event whose dedup key (see Deduplicated) is
+	// already in the stream with a different payload.
+	ErrDuplicateKey = fmt.Errorf("ges: dedup key already used with a different payload")
+
+	// ErrPositionNotReached indicates that WaitForPosition gave up before the
+	// reader caught up with the requested position.
+	ErrPositionNotReached = fmt.Errorf("ges: position not reached")
+
+	// ErrInvalidMetadata indicates metadata rejected by Metadata.Validate.
+	ErrInvalidMetadata = fmt.Errorf("ges: invalid metadata")
+
+	// ErrInvalidLockToken indicates a LockToken that is malformed or belongs to a
+	// different stream than the aggregate it is used with.
+	ErrInvalidLockToken = fmt.Errorf("ges: invalid lock token")
+
+	// ErrTenantMismatch indicates a write through a TenantStore whose metadata
+	// names a different tenant.
+	ErrTenantMismatch = fmt.Errorf("ges: tenant mismatch")
+
+	// ErrUnknownCommand indicates that HandleCommand found no handler for a command.
+	ErrUnknownCommand = fmt.Errorf("ges: unknown command")
+
+	// ErrUnknownEvent indicates that an aggregate applied an event it has no applier
+	// for, under FailOnUnknownEvents.
+	ErrUnknownEvent = fmt.Errorf("ges: unknown event")
+
+	// ErrSchemaViolation indicates that an event payload does not conform to the
+	// schema registered for its type (see ValidatePayload).
+	ErrSchemaViolation = fmt.Errorf("ges: payload violates schema")
+
+	// ErrNilEvent indicates a nil entry in a batch of events to append.
+	ErrNilEvent = fmt.Errorf("ges: nil event")
+
+	// ErrSnapshotNotVerified indicates that VerifySnapshot could not compare a
+	// snapshot with the stream's events, because the aggregate does not take the
+	// snapshot or the stream cannot be replayed from its start.
+	ErrSnapshotNotVerified = fmt.Errorf("ges: snapshot not verified")
+
+	// ErrStreamTruncated indicates that an operation needs the events of a stream
+	// from its start, but the stream was truncated (see Truncater).
+	ErrStreamTruncated = fmt.Errorf("ges: stream truncated")
 )
 
 // VersionConflictError provides structured information about version mismatch.
@@ -15,18 +86,73 @@ type VersionConflictError struct {
 	StreamID        string
 	ExpectedVersion int64
 	ActualVersion   int64
+
+	// ConcurrentEvents are the events with a version after ExpectedVersion that made
+	// the append fail, for conflict resolution such as re-applying commutative events.
+	// Stores only fill it when configured to, since it costs an extra read.
+	ConcurrentEvents []StoredEvent
 }
 
 func (e *VersionConflictError) Error() string {
 	return fmt.Sprintf("version conflict on stream %s: expected=%d actual=%d", e.StreamID, e.ExpectedVersion, e.ActualVersion)
 }
 
-// Is allows errors.Is(err, ErrVersionConflict) to match this type.
+// IsStreamNotFound reports whether the conflict is caused by the stream not existing
+// yet ("create it first") rather than by another writer advancing it.
+func (e *VersionConflictError) IsStreamNotFound() bool {
+	return e.ActualVersion == 0 && e.ExpectedVersion > 0
+}
+
+// Is allows errors.Is(err, ErrVersionConflict) to match this type, and
+// errors.Is(err, ErrStreamNotFound) when IsStreamNotFound reports true.
 func (e *VersionConflictError) Is(target error) bool {
-	return target == ErrVersionConflict
+	return target == ErrVersionConflict || (target == ErrStreamNotFound && e.IsStreamNotFound())
 }
 
 // Unwrap returns the underlying sentinel error ErrVersionConflict,
 func (e *VersionConflictError) Unwrap() error {
 	return ErrVersionConflict
 }
+
+// DecodeError identifies a stored event that could not be decoded, e.g. because its
+// payload is corrupt or no codec is registered for its type.
+type DecodeError struct {
+	StreamID  string
+	Version   int64
+	EventType string
+	Cause     error
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("ges: could not decode %s at version %d of stream %s: %v", e.EventType, e.Version, e.StreamID, e.Cause)
+}
+
+// Unwrap returns the codec error.
+func (e *DecodeError) Unwrap() error {
+	return e.Cause
+}
+
+// VersionGapError reports that a stream read skipped versions, e.g. after a botched
+// migration or a manual delete, so an aggregate rebuilt from it would be wrong.
+type VersionGapError struct {
+	StreamID        string
+	ExpectedVersion int64 // the version that should have come next
+	ActualVersion   int64 // the version that came instead
+}
+
+func (e *VersionGapError) Error() string {
+	return fmt.Sprintf("ges: gap in stream %s: expected version %d, found %d", e.StreamID, e.ExpectedVersion, e.ActualVersion)
+}
+
+// SnapshotMismatchError reports a snapshot that does not rebuild the same aggregate
+// as the stream's events (see VerifySnapshot).
+type SnapshotMismatchError struct {
+	StreamID        string
+	SnapshotVersion int64
+	Replayed        any // Snapshot() of the aggregate replayed from version 0
+	Restored        any // Snapshot() of the aggregate restored from the snapshot
+}
+
+func (e *SnapshotMismatchError) Error() string {
+	return fmt.Sprintf("ges: snapshot of %s at version %d does not match its events", e.StreamID, e.SnapshotVersion)
+}
@@ -0,0 +1,40 @@
+// Package estest provides assertions for tests of code built on ges, such as store
+// implementations and services that append events.
+package estest
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/mickamy/go-event-sourcing"
+)
+
+// AssertConflict fails the test unless err is, or wraps, a *ges.VersionConflictError
+// for streamID with the given expected and actual versions. It returns the conflict
+// for further checks, e.g. of ConcurrentEvents.
+func AssertConflict(t testing.TB, err error, streamID string, expected, actual int64) *ges.VersionConflictError {
+	t.Helper()
+	var vc *ges.VersionConflictError
+	if !errors.As(err, &vc) {
+		t.Fatalf("expected a version conflict on %s, got %v", streamID, err)
+		return nil
+	}
+	if vc.StreamID != streamID || vc.ExpectedVersion != expected || vc.ActualVersion != actual {
+		t.Fatalf("expected a conflict on %s with expected=%d actual=%d, got %s with expected=%d actual=%d",
+			streamID, expected, actual, vc.StreamID, vc.ExpectedVersion, vc.ActualVersion)
+	}
+	return vc
+}
+
+// AssertNoConflict fails the test if err is not nil, saying so plainly when it is
+// a version conflict.
+func AssertNoConflict(t testing.TB, err error) {
+	t.Helper()
+	var vc *ges.VersionConflictError
+	switch {
+	case errors.As(err, &vc):
+		t.Fatalf("unexpected version conflict on %s: expected=%d actual=%d", vc.StreamID, vc.ExpectedVersion, vc.ActualVersion)
+	case err != nil:
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
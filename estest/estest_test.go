@@ -0,0 +1,62 @@
+package estest_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/mickamy/go-event-sourcing"
+	"github.com/mickamy/go-event-sourcing/estest"
+)
+
+// recorder captures failures instead of stopping the test.
+type recorder struct {
+	testing.TB
+	failures []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Fatalf(format string, args ...any) {
+	r.failures = append(r.failures, fmt.Sprintf(format, args...))
+}
+
+func TestAssertConflict(t *testing.T) {
+	t.Parallel()
+
+	conflict := fmt.Errorf("save: %w", &ges.VersionConflictError{StreamID: "Account:1", ExpectedVersion: 2, ActualVersion: 3})
+
+	var ok recorder
+	if vc := estest.AssertConflict(&ok, conflict, "Account:1", 2, 3); vc == nil || len(ok.failures) != 0 {
+		t.Fatalf("expected a matching conflict to pass, got %v", ok.failures)
+	}
+
+	var mismatch recorder
+	estest.AssertConflict(&mismatch, conflict, "Account:1", 2, 4)
+	if len(mismatch.failures) != 1 || !strings.Contains(mismatch.failures[0], "actual=4") {
+		t.Fatalf("expected a field mismatch to fail, got %v", mismatch.failures)
+	}
+
+	var other recorder
+	estest.AssertConflict(&other, errors.New("boom"), "Account:1", 2, 3)
+	if len(other.failures) != 1 {
+		t.Fatalf("expected a non-conflict to fail, got %v", other.failures)
+	}
+}
+
+func TestAssertNoConflict(t *testing.T) {
+	t.Parallel()
+
+	var ok recorder
+	estest.AssertNoConflict(&ok, nil)
+	if len(ok.failures) != 0 {
+		t.Fatalf("expected nil to pass, got %v", ok.failures)
+	}
+
+	var conflict recorder
+	estest.AssertNoConflict(&conflict, &ges.VersionConflictError{StreamID: "Account:1", ExpectedVersion: 1, ActualVersion: 2})
+	if len(conflict.failures) != 1 || !strings.Contains(conflict.failures[0], "version conflict on Account:1") {
+		t.Fatalf("expected a conflict to fail, got %v", conflict.failures)
+	}
+}
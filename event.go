@@ -2,6 +2,7 @@ package ges
 
 import (
 	"fmt"
+	"reflect"
 	"time"
 )
 
@@ -16,15 +17,132 @@ type StoredEvent struct {
 	Metadata Metadata
 	StreamID string
 	Version  int64
-	At       time.Time
+	Position int64             // Global, store-wide position; increases with every appended event
+	Headers  map[string]string // Per-event headers recorded via Envelope (nil if none)
+
+	// ContentType is the media type of the encoded payload, as reported by the codec
+	// that wrote it (see ContentTyper). It is empty for events written by codecs that
+	// report none, or before the store recorded content types.
+	ContentType string
+
+	// OccurredAt is the business time of the event: Envelope.OccurredAt when given,
+	// otherwise the time it was recorded. It may precede RecordedAt for events that
+	// arrive late.
+	OccurredAt time.Time
+	// RecordedAt is when the store persisted the event.
+	RecordedAt time.Time
+
+	// Deprecated: At is RecordedAt, kept for backward compatibility.
+	At time.Time
 }
 
-// EventType returns the canonical name for a given event.
-// If the event implements `EventType() string`, that value is used.
-// Otherwise, it falls back to the Go type name (e.g., "account.AccountOpened").
+// Envelope wraps an event with data that belongs to that event alone, as opposed
+// to the Metadata shared by every event of an Append batch.
+type Envelope struct {
+	Event      Event
+	Headers    map[string]string // e.g. a per-event schema version or content type
+	OccurredAt time.Time         // When the event happened; zero means "when appended"
+}
+
+// EventType returns the canonical name for a given event, the name stores persist.
+// A name registered with RegisterEventType takes precedence. Otherwise, if the event
+// implements `EventType() string`, that value is used, and as a last resort the Go
+// type name (e.g., "account.AccountOpened"), which changes if the type moves package.
+//
+// Pointers are dereferenced, so raising &AccountOpened{} and AccountOpened{} records
+// the same name and finds the same codec. Note that codecs decode to whatever type
+// they were built for, so an event appended as a pointer may be loaded as a value.
+// Events recorded as "*account.AccountOpened" by earlier versions still decode with
+// the codec of "account.AccountOpened" (see DecoderFor), but type filters such as
+// WithEventTypes must list both names until the stored names are rewritten.
 func EventType(e Event) string {
+	if name, ok := registeredName(e); ok {
+		return name
+	}
 	if named, ok := e.(interface{ EventType() string }); ok {
 		return named.EventType()
 	}
+	if t := reflect.TypeOf(e); t != nil {
+		return indirectType(t).String()
+	}
 	return fmt.Sprintf("%T", e)
 }
+
+// TypeNamer derives the type name a store persists for an event, in place of
+// EventType, e.g. to follow a namespaced and versioned convention such as
+// "account.AccountOpened.v2". Stores accept one with their WithTypeNamer option;
+// codec registries and type filters must then use the names it produces.
+type TypeNamer func(e Event) string
+
+// Of returns the name events of type T are persisted under, the same as EventType
+// returns for a T or *T value. It spells registry keys without a sample value, and
+// keeps them in sync with the codec's type:
+//
+//	registry := map[string]ges.EventCodec{
+//		ges.Of[AccountOpened](): ges.JSONCodec[AccountOpened](),
+//	}
+func Of[T any]() string {
+	t := reflect.TypeFor[T]()
+	if t.Kind() == reflect.Interface {
+		return t.String()
+	}
+	return EventType(reflect.New(indirectType(t)).Interface())
+}
+
+// indirectType returns the type t points to, through any number of pointers.
+func indirectType(t reflect.Type) reflect.Type {
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t
+}
+
+// Deduplicated is implemented by events that must be recorded at most once per
+// stream, such as "payment received" keyed by the payment provider's reference.
+// Stores that honor it (it is opt-in per store) drop an appended event whose key is
+// already in the stream with an equal payload, and fail the append with
+// ErrDuplicateKey if the payload differs. An empty key opts the event out.
+type Deduplicated interface {
+	DedupKey() string
+}
+
+// DedupKey returns the dedup key of e, or "" if it does not implement Deduplicated.
+func DedupKey(e Event) string {
+	if d, ok := e.(Deduplicated); ok {
+		return d.DedupKey()
+	}
+	return ""
+}
+
+// DecodeAction tells a store what to do with an event it cannot decode.
+type DecodeAction int
+
+const (
+	// DecodeFail aborts the read with the *DecodeError (the default).
+	DecodeFail DecodeAction = iota
+	// DecodeSkip delivers the event with a SkippedEvent payload and carries on.
+	DecodeSkip
+)
+
+// SkippedEvent is the payload of an event that could not be decoded and was skipped
+// (see DecodeSkip). Aggregates ignore it like any event type they do not handle, but
+// still advance their version, so a stream with a poisoned event stays loadable and
+// writable while a fix is prepared. Raw holds the payload as stored, which stores
+// keep, so the event can still be exported and decoded once the fix is in.
+type SkippedEvent struct {
+	Err DecodeError
+	Raw []byte
+}
+
+// UnknownEvent is the payload of an event whose type has no codec, delivered by
+// stores configured to fall back to it (e.g. WithUnknownEventFallback of the pgx
+// store) instead of failing the read. Raw holds the payload as stored, so tools and
+// relays can still read and forward streams that contain types the running binary
+// does not know. Aggregates ignore it, but still advance their version.
+type UnknownEvent struct {
+	Type string
+	Raw  []byte
+}
+
+// EventType returns the type the event was stored under.
+func (e UnknownEvent) EventType() string { return e.Type }
@@ -0,0 +1,66 @@
+package ges
+
+import (
+	"reflect"
+	"sync"
+)
+
+// eventTypes maps registered event names to Go types and back.
+var eventTypes = struct {
+	sync.RWMutex
+	byName map[string]reflect.Type
+	byType map[reflect.Type]string
+}{
+	byName: map[string]reflect.Type{},
+	byType: map[reflect.Type]string{},
+}
+
+// RegisterEventType binds name to the Go type of sample, so events of that type are
+// stored as name (see EventType) and NewByName can construct them. This decouples
+// persisted names from Go package paths: after moving a type, register it under its
+// old name and historical events still resolve.
+//
+// Registering a name or type again replaces the earlier binding. It is typically
+// called from init functions. A pointer sample registers the type it points to, so
+// both T and *T events are stored as name.
+func RegisterEventType(name string, sample Event) {
+	t := indirectType(reflect.TypeOf(sample))
+
+	eventTypes.Lock()
+	defer eventTypes.Unlock()
+	if old, ok := eventTypes.byName[name]; ok {
+		delete(eventTypes.byType, old)
+	}
+	if old, ok := eventTypes.byType[t]; ok {
+		delete(eventTypes.byName, old)
+	}
+	eventTypes.byName[name] = t
+	eventTypes.byType[t] = name
+}
+
+// TypeName returns the name e is persisted under. It is the same as EventType.
+func TypeName(e Event) string {
+	return EventType(e)
+}
+
+// NewByName returns a pointer to a new zero value of the type registered under name,
+// ready to be decoded into, and false if name is not registered.
+func NewByName(name string) (any, bool) {
+	eventTypes.RLock()
+	t, ok := eventTypes.byName[name]
+	eventTypes.RUnlock()
+	if !ok {
+		return nil, false
+	}
+	return reflect.New(t).Interface(), true
+}
+
+func registeredName(e Event) (string, bool) {
+	eventTypes.RLock()
+	defer eventTypes.RUnlock()
+	if len(eventTypes.byType) == 0 {
+		return "", false
+	}
+	name, ok := eventTypes.byType[indirectType(reflect.TypeOf(e))]
+	return name, ok
+}
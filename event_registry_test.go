@@ -0,0 +1,76 @@
+package ges_test
+
+import (
+	"testing"
+
+	"github.com/mickamy/go-event-sourcing"
+)
+
+type movedEvent struct{ N int }
+
+type namedEvent struct{}
+
+func (namedEvent) EventType() string { return "Named" }
+
+func TestRegisterEventType(t *testing.T) {
+	t.Parallel()
+
+	if got := ges.TypeName(movedEvent{}); got != "ges_test.movedEvent" {
+		t.Fatalf("expected the Go type name before registration, got %q", got)
+	}
+
+	ges.RegisterEventType("legacy.Moved", movedEvent{})
+	if got := ges.TypeName(movedEvent{N: 1}); got != "legacy.Moved" {
+		t.Fatalf("expected the registered name, got %q", got)
+	}
+	v, ok := ges.NewByName("legacy.Moved")
+	if !ok {
+		t.Fatalf("expected legacy.Moved to be registered")
+	}
+	if _, ok := v.(*movedEvent); !ok {
+		t.Fatalf("expected *movedEvent, got %T", v)
+	}
+	if _, ok := ges.NewByName("unknown"); ok {
+		t.Fatalf("expected unknown names to be reported")
+	}
+
+	// Registration takes precedence over an EventType method.
+	ges.RegisterEventType("Renamed", namedEvent{})
+	if got := ges.EventType(namedEvent{}); got != "Renamed" {
+		t.Fatalf("expected the registered name to win, got %q", got)
+	}
+}
+
+type pointerEvent struct{ N int }
+
+type pointerNamed struct{}
+
+func (*pointerNamed) EventType() string { return "PointerNamed" }
+
+func TestEventType_Pointers(t *testing.T) {
+	t.Parallel()
+
+	// Raising &e instead of e must not change the persisted name, or the codec
+	// registered for the value type would not be found.
+	if got, want := ges.EventType(&pointerEvent{N: 1}), ges.EventType(pointerEvent{N: 1}); got != want {
+		t.Fatalf("expected *T and T to share a name, got %q and %q", got, want)
+	}
+	if got := ges.EventType(&pointerEvent{}); got != "ges_test.pointerEvent" {
+		t.Fatalf("expected the dereferenced Go type name, got %q", got)
+	}
+	if got := ges.Of[pointerEvent](); got != "ges_test.pointerEvent" {
+		t.Fatalf("expected Of to match EventType, got %q", got)
+	}
+	if got := ges.Of[*pointerEvent](); got != "ges_test.pointerEvent" {
+		t.Fatalf("expected Of of a pointer type to match, got %q", got)
+	}
+	if got := ges.Of[namedEvent](); got != "Named" {
+		t.Fatalf("expected Of to use the EventType method, got %q", got)
+	}
+	if got := ges.EventType(&pointerNamed{}); got != "PointerNamed" {
+		t.Fatalf("expected the pointer method, got %q", got)
+	}
+	if got := ges.Of[pointerNamed](); got != "PointerNamed" {
+		t.Fatalf("expected Of to find a pointer-receiver EventType method, got %q", got)
+	}
+}
@@ -31,6 +31,7 @@ func main() {
 			"AccountOpened":  ges.JSONCodec[AccountOpened](),
 			"MoneyDeposited": ges.JSONCodec[MoneyDeposited](),
 		}),
+		pgx.WithStreamIDValidator(ges.ValidateStreamID),
 	)
 
 	svc := NewAccountService(store)
@@ -62,7 +63,11 @@ func main() {
 	fmt.Println()
 
 	// 3) Load and show balance (rehydrate)
-	acc, err := NewAccountRepository(store).Load(ctx, id)
+	streamID, err := accountStreamID(id)
+	if err != nil {
+		log.Fatal(err)
+	}
+	acc, err := NewAccountRepository(store).Load(ctx, streamID)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -1,67 +1,35 @@
 package main
 
 import (
-	"context"
-	"fmt"
+	"time"
 
 	"github.com/mickamy/go-event-sourcing"
 )
 
-// AccountRepository loads and saves Account aggregates using an EventStore.
-type AccountRepository struct {
-	store ges.EventStore
+// accountReplayTarget is how long rehydrating an account may take before a snapshot is saved.
+const accountReplayTarget = 5 * time.Millisecond
+
+// NewAccountRepository creates a repository for Account aggregates backed by the given store.
+// Accounts restore from the latest snapshot first, then replay the delta events, and are
+// snapshotted once replaying them gets expensive. Snapshots are only a cache, so an
+// account whose snapshot cannot be read is replayed in full instead.
+func NewAccountRepository(store ges.EventStore) *ges.Repository[*Account] {
+	return ges.NewRepository(store, newAccount,
+		ges.WithSnapshotPolicy(ges.CostBasedPolicy(accountReplayTarget)),
+		ges.WithStrictSnapshots(false),
+	)
 }
 
-// NewAccountRepository creates a repository backed by the given store.
-func NewAccountRepository(store ges.EventStore) *AccountRepository {
-	return &AccountRepository{store: store}
-}
-
-// Load fetches and rehydrates an Account by its ID.
-// It tries a snapshot first, then loads the delta events.
-func (r *AccountRepository) Load(ctx context.Context, id string) (*Account, error) {
-	streamID := "Account:" + id
+// newAccount returns an empty Account wired to its appliers, snapshot functions and
+// command handlers. Replaying an event the account has no applier for fails the load.
+func newAccount(streamID string) *Account {
 	var a Account
-
-	// Initialize Base with stream ID and applier before any replay/snapshot.
-	a.Init(streamID, a.when)
-
-	// 1) Try snapshot
-	snap, err := r.store.LoadSnapshot(ctx, streamID)
-	if err != nil {
-		return nil, err
-	}
-	if s, ok, err := decodeSnapshot(snap); err != nil {
-		return nil, err
-	} else if ok {
-		// restore basic fields
-		a.SetStreamID("Account:" + s.ID)
-		a.owner = s.Owner
-		a.balance = s.Balance
-		a.opened = s.ID != ""
-		a.SetVersion(s.Version)
-	}
-
-	// 2) Apply delta events
-	evs, last, err := r.store.Load(ctx, streamID, a.Version())
-	if err != nil {
-		return nil, err
-	}
-	a.Restore(evs)
-	if last != a.Version() {
-		return nil, fmt.Errorf("version mismatch after Restore: aggregate=%d, store=%d",
-			a.Version(), last)
-	}
-	return &a, nil
-}
-
-// Save persists the aggregate's pending events with optimistic locking.
-// On success, it clears pending events.
-func (r *AccountRepository) Save(ctx context.Context, a *Account, md ges.Metadata) error {
-	evs, expected := a.Flush()
-	if len(evs) == 0 {
-		return nil
-	}
-	_, err := r.store.Append(ctx, a.StreamID(), expected, evs, md)
-	return err
+	a.Init(streamID, nil,
+		ges.WithSnapshotter(a.snapshot, a.restore),
+		ges.WithCommandMethods(&a),
+		ges.WithUnknownEvents(ges.FailOnUnknownEvents),
+	)
+	ges.OnEvent(&a.Base, a.onOpened)
+	ges.OnEvent(&a.Base, a.onDeposited)
+	return &a
 }
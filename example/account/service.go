@@ -8,7 +8,7 @@ import (
 
 // AccountService orchestrates command handling using repository + store.
 type AccountService struct {
-	repo  *AccountRepository
+	repo  *ges.Repository[*Account]
 	store ges.EventStore
 }
 
@@ -20,22 +20,29 @@ func NewAccountService(store ges.EventStore) *AccountService {
 	}
 }
 
-// Handle executes a command end-to-end: load → Handle → append.
+// Handle executes a command end-to-end: load → HandleCommand → append.
+// On a version conflict the whole cycle is re-run against fresh state.
 func (s *AccountService) Handle(ctx context.Context, cmd any, md ges.Metadata) error {
-	// Determine target aggregate ID from the command.
-	id := extractAccountID(cmd)
-	acc, err := s.repo.Load(ctx, id)
+	// Determine target aggregate stream from the command.
+	streamID, err := accountStreamID(extractAccountID(cmd))
 	if err != nil {
 		return err
 	}
 
-	// Route to domain logic.
-	if err := acc.Handle(cmd); err != nil {
-		return err
-	}
+	return ges.RunWithRetry(ctx, func(ctx context.Context) error {
+		acc, err := s.repo.Load(ctx, streamID)
+		if err != nil {
+			return err
+		}
+
+		// Route to domain logic.
+		if err := acc.HandleCommand(cmd); err != nil {
+			return err
+		}
 
-	// Persist resulting events.
-	return s.repo.Save(ctx, acc, md)
+		// Persist resulting events.
+		return s.repo.Save(ctx, acc, md)
+	}, ges.WithRetryAttempts(5))
 }
 
 // extractAccountID is a tiny helper for this sample.
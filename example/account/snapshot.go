@@ -1,17 +1,24 @@
 package main
 
 import (
-	"encoding/json"
-	"strings"
-
 	"github.com/mickamy/go-event-sourcing"
 )
 
-const accountPrefix = "Account:"
+const accountCategory = "Account"
+
+// accountStreamID returns the stream of the account id, or an error wrapping
+// ges.ErrInvalidStreamID for an empty id, e.g. from a malformed command.
+func accountStreamID(id string) (string, error) {
+	s, err := ges.NewStreamID(accountCategory, id)
+	return s.String(), err
+}
 
+// accountIDFromStreamID returns the account ID of an "Account:<id>" stream. Any
+// other stream ID is returned unchanged, as before streams were built with
+// ges.StreamID, so snapshots keep the IDs they were taken with.
 func accountIDFromStreamID(s string) string {
-	if strings.HasPrefix(s, accountPrefix) {
-		return strings.TrimPrefix(s, accountPrefix)
+	if id := ges.StreamID(s); id.Category() == accountCategory {
+		return id.ID()
 	}
 	return s
 }
@@ -21,31 +28,25 @@ type AccountSnapshot struct {
 	ID      string `json:"id"`
 	Owner   string `json:"owner"`
 	Balance int64  `json:"balance"`
-	Version int64  `json:"version"`
 }
 
-// serializeState converts the in-memory aggregate into a persistable snapshot.
-func serializeState(a *Account) any {
+// snapshot converts the in-memory aggregate into a persistable snapshot.
+func (a *Account) snapshot() any {
 	return AccountSnapshot{
 		ID:      accountIDFromStreamID(a.StreamID()),
 		Owner:   a.owner,
 		Balance: a.balance,
-		Version: a.Version(),
 	}
 }
 
-func decodeSnapshot(snap ges.Snapshot) (AccountSnapshot, bool, error) {
-	if !snap.Found || snap.State == nil {
-		return AccountSnapshot{}, false, nil
-	}
-	// State(map[string]any) → JSON → AccountSnapshot
-	raw, err := json.Marshal(snap.State)
+// restore rebuilds the aggregate state from a persisted snapshot.
+func (a *Account) restore(state any) error {
+	s, err := ges.DecodeState[AccountSnapshot](state)
 	if err != nil {
-		return AccountSnapshot{}, false, err
-	}
-	var out AccountSnapshot
-	if err := json.Unmarshal(raw, &out); err != nil {
-		return AccountSnapshot{}, false, err
+		return err
 	}
-	return out, true, nil
+	a.owner = s.Owner
+	a.balance = s.Balance
+	a.opened = s.ID != ""
+	return nil
 }
@@ -0,0 +1,152 @@
+package ges
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// importBatchSize bounds how many consecutive events of a stream ImportStream
+// appends at once.
+const importBatchSize = 500
+
+// exportedEvent is one NDJSON line written by ExportStream.
+//
+// Payloads produced by JSON codecs are embedded as-is; any other encoding is
+// carried base64-encoded in payload_bytes so the line stays valid JSON.
+type exportedEvent struct {
+	StreamID     string            `json:"stream_id"`
+	Version      int64             `json:"version"`
+	Type         string            `json:"type"`
+	ContentType  string            `json:"content_type,omitempty"`
+	Payload      json.RawMessage   `json:"payload,omitempty"`
+	PayloadBytes []byte            `json:"payload_bytes,omitempty"`
+	Metadata     Metadata          `json:"metadata,omitempty"`
+	Headers      map[string]string `json:"headers,omitempty"`
+	OccurredAt   time.Time         `json:"occurred_at"`
+	At           time.Time         `json:"at"` // when the event was recorded
+}
+
+// ExportStream writes every event of streamID to w as newline-delimited JSON, one
+// event per line in version order. The store must implement RawLoader; payloads are
+// exported in their stored encoding and are not decoded.
+func ExportStream(ctx context.Context, store EventStore, streamID string, w io.Writer) error {
+	loader, ok := store.(RawLoader)
+	if !ok {
+		return ErrRawUnsupported
+	}
+	events, err := loader.LoadRaw(ctx, streamID, 0)
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(w)
+	for _, ev := range events {
+		line, err := exportEvent(ev)
+		if err != nil {
+			return err
+		}
+		if err := enc.Encode(line); err != nil {
+			return fmt.Errorf("ges: export %s at version %d: %w", streamID, ev.Version, err)
+		}
+	}
+	return nil
+}
+
+// exportEvent converts a raw event into its exported form.
+func exportEvent(ev StoredEvent) (exportedEvent, error) {
+	payload, ok := ev.Payload.([]byte)
+	if !ok {
+		return exportedEvent{}, fmt.Errorf("ges: export %s at version %d: payload is %T, not []byte", ev.StreamID, ev.Version, ev.Payload)
+	}
+	line := exportedEvent{
+		StreamID: ev.StreamID,
+		Version:  ev.Version,
+		Type:     ev.Type,
+		Metadata: ev.Metadata,
+		Headers:  ev.Headers,
+
+		ContentType: ev.ContentType,
+		OccurredAt:  ev.OccurredAt,
+		At:          ev.RecordedAt,
+	}
+	if json.Valid(payload) {
+		line.Payload = payload
+	} else {
+		line.PayloadBytes = payload
+	}
+	return line, nil
+}
+
+// stored converts an exported event back into a raw event.
+func (line exportedEvent) stored() StoredEvent {
+	payload := []byte(line.Payload)
+	if line.PayloadBytes != nil {
+		payload = line.PayloadBytes
+	}
+	return StoredEvent{
+		Type:     line.Type,
+		Payload:  payload,
+		Metadata: line.Metadata,
+		StreamID: line.StreamID,
+		Version:  line.Version,
+		Headers:  line.Headers,
+
+		ContentType: line.ContentType,
+		OccurredAt:  line.OccurredAt,
+		RecordedAt:  line.At,
+	}
+}
+
+// ImportStream reads events written by ExportStream from r and appends them to store,
+// which must implement RawAppender. Versions are preserved exactly: each event is
+// appended with expectedVersion set to its version minus one, so importing into a
+// stream that already has events fails with a version conflict instead of
+// renumbering them.
+func ImportStream(ctx context.Context, store EventStore, r io.Reader) error {
+	appender, ok := store.(RawAppender)
+	if !ok {
+		return ErrRawUnsupported
+	}
+
+	var batch []StoredEvent
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		first := batch[0]
+		if _, err := appender.AppendRaw(ctx, first.StreamID, first.Version-1, batch); err != nil {
+			return fmt.Errorf("ges: import %s at version %d: %w", first.StreamID, first.Version, err)
+		}
+		batch = nil
+		return nil
+	}
+
+	dec := json.NewDecoder(r)
+	for {
+		var line exportedEvent
+		err := dec.Decode(&line)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("ges: import: could not decode event: %w", err)
+		}
+
+		// Consecutive versions of the same stream are appended together.
+		if n := len(batch); n > 0 {
+			last := batch[n-1]
+			if line.StreamID != last.StreamID || line.Version != last.Version+1 || n == importBatchSize {
+				if err := flush(); err != nil {
+					return err
+				}
+			}
+		}
+
+		batch = append(batch, line.stored())
+	}
+	return flush()
+}
@@ -0,0 +1,142 @@
+// Package grpc provides gRPC interceptors that carry request metadata into events.
+//
+// The server interceptors read tenant, user and correlation IDs from incoming gRPC
+// metadata and attach them to the context with ges.WithMetadata, so a store
+// configured with ges.ContextMetadata as its extractor records them on every
+// Append made while handling the call. The client interceptors propagate the
+// correlation ID to downstream services.
+package grpc
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+
+	"github.com/mickamy/go-event-sourcing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// Incoming gRPC metadata keys read by default, and the ges.Metadata keys they map to.
+const (
+	TenantIDHeader      = "x-tenant-id"
+	UserIDHeader        = "x-user-id"
+	CorrelationIDHeader = "x-correlation-id"
+
+	TenantIDKey      = ges.MetadataTenantID
+	UserIDKey        = ges.MetadataUserID
+	CorrelationIDKey = ges.MetadataCorrelationID
+)
+
+// Option configures the server interceptors.
+type Option func(*config)
+
+type config struct {
+	headers map[string]string // gRPC metadata key → ges.Metadata key
+	newID   func() string
+}
+
+// WithHeader additionally copies the incoming gRPC metadata header into ges.Metadata
+// under key. Header names are case-insensitive.
+func WithHeader(header, key string) Option {
+	return func(c *config) { c.headers[header] = key }
+}
+
+// WithCorrelationIDGenerator sets how a correlation ID is generated for calls that
+// arrive without one. The default is a random 128-bit hex string; a generator
+// returning "" leaves such calls without a correlation ID.
+func WithCorrelationIDGenerator(newID func() string) Option {
+	return func(c *config) { c.newID = newID }
+}
+
+func newConfig(opts []Option) *config {
+	c := &config{
+		headers: map[string]string{
+			TenantIDHeader:      TenantIDKey,
+			UserIDHeader:        UserIDKey,
+			CorrelationIDHeader: CorrelationIDKey,
+		},
+		newID: randomID,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// UnaryServerInterceptor attaches metadata from the incoming call to the handler's context.
+func UnaryServerInterceptor(opts ...Option) grpc.UnaryServerInterceptor {
+	c := newConfig(opts)
+	return func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		return handler(c.attach(ctx), req)
+	}
+}
+
+// StreamServerInterceptor attaches metadata from the incoming call to the stream's context.
+func StreamServerInterceptor(opts ...Option) grpc.StreamServerInterceptor {
+	c := newConfig(opts)
+	return func(srv any, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return handler(srv, &serverStream{ServerStream: ss, ctx: c.attach(ss.Context())})
+	}
+}
+
+// UnaryClientInterceptor forwards the correlation ID found in the context's
+// ges.Metadata as the x-correlation-id header of outgoing calls.
+func UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		return invoker(propagate(ctx), method, req, reply, cc, opts...)
+	}
+}
+
+// StreamClientInterceptor is the streaming counterpart of UnaryClientInterceptor.
+func StreamClientInterceptor() grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		return streamer(propagate(ctx), desc, cc, method, opts...)
+	}
+}
+
+// attach returns ctx with the configured headers of the incoming call stashed as ges.Metadata.
+func (c *config) attach(ctx context.Context) context.Context {
+	incoming, _ := metadata.FromIncomingContext(ctx)
+	md := ges.Metadata{}
+	for header, key := range c.headers {
+		if values := incoming.Get(header); len(values) > 0 && values[0] != "" {
+			md[key] = values[0]
+		}
+	}
+	if _, ok := md[CorrelationIDKey]; !ok {
+		if id := c.newID(); id != "" {
+			md[CorrelationIDKey] = id
+		}
+	}
+	return ges.WithMetadata(ctx, md)
+}
+
+// propagate copies the correlation ID from ctx's ges.Metadata to outgoing gRPC metadata.
+func propagate(ctx context.Context) context.Context {
+	id, ok := ges.ContextMetadata(ctx)[CorrelationIDKey].(string)
+	if !ok || id == "" {
+		return ctx
+	}
+	if outgoing, ok := metadata.FromOutgoingContext(ctx); ok && len(outgoing.Get(CorrelationIDHeader)) > 0 {
+		return ctx // set explicitly by the caller
+	}
+	return metadata.AppendToOutgoingContext(ctx, CorrelationIDHeader, id)
+}
+
+// serverStream overrides the context of a grpc.ServerStream.
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+func randomID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
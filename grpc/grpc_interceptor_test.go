@@ -0,0 +1,72 @@
+package grpc_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mickamy/go-event-sourcing"
+	gesgrpc "github.com/mickamy/go-event-sourcing/grpc"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	t.Parallel()
+
+	ctx := metadata.NewIncomingContext(t.Context(), metadata.Pairs(
+		"x-tenant-id", "t1",
+		"x-correlation-id", "c1",
+		"x-region", "eu",
+	))
+	intercept := gesgrpc.UnaryServerInterceptor(gesgrpc.WithHeader("X-Region", "region"))
+
+	var got ges.Metadata
+	_, err := intercept(ctx, nil, &grpc.UnaryServerInfo{}, func(ctx context.Context, _ any) (any, error) {
+		got = ges.ContextMetadata(ctx)
+		return nil, nil
+	})
+	if err != nil {
+		t.Fatalf("interceptor failed: %v", err)
+	}
+	if got["tenant_id"] != "t1" || got["correlation_id"] != "c1" || got["region"] != "eu" {
+		t.Fatalf("unexpected metadata %v", got)
+	}
+	if _, ok := got["user_id"]; ok {
+		t.Fatalf("expected no user_id, got %v", got)
+	}
+}
+
+func TestUnaryServerInterceptor_GeneratesCorrelationID(t *testing.T) {
+	t.Parallel()
+
+	intercept := gesgrpc.UnaryServerInterceptor(gesgrpc.WithCorrelationIDGenerator(func() string { return "generated" }))
+	var got ges.Metadata
+	_, _ = intercept(t.Context(), nil, &grpc.UnaryServerInfo{}, func(ctx context.Context, _ any) (any, error) {
+		got = ges.ContextMetadata(ctx)
+		return nil, nil
+	})
+	if got["correlation_id"] != "generated" {
+		t.Fatalf("expected a generated correlation ID, got %v", got)
+	}
+}
+
+func TestUnaryClientInterceptor(t *testing.T) {
+	t.Parallel()
+
+	ctx := ges.WithMetadata(t.Context(), ges.Metadata{"correlation_id": "c1"})
+	intercept := gesgrpc.UnaryClientInterceptor()
+
+	var got []string
+	err := intercept(ctx, "/svc/Method", nil, nil, nil, func(ctx context.Context, _ string, _, _ any, _ *grpc.ClientConn, _ ...grpc.CallOption) error {
+		md, _ := metadata.FromOutgoingContext(ctx)
+		got = md.Get("x-correlation-id")
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("interceptor failed: %v", err)
+	}
+	if len(got) != 1 || got[0] != "c1" {
+		t.Fatalf("expected the correlation ID to be propagated, got %v", got)
+	}
+}
@@ -0,0 +1,281 @@
+// Package httpfeed serves events from an event store as paginated JSON over
+// net/http, so other services can consume them without database access.
+//
+// A Handler answers GET requests in two shapes:
+//
+//	GET /feed?category=Account&token=...&limit=50   events of a category (or of the whole store without category)
+//	GET /feed?stream=Account:42&from=10&limit=50     events of one stream after version from
+//
+// Responses carry RFC 8288 Link headers to continue paging and an ETag derived
+// from the last position served, so pollers can use If-None-Match and receive
+// 304 Not Modified until new events arrive.
+package httpfeed
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mickamy/go-event-sourcing"
+)
+
+const (
+	defaultLimit = 50
+	maxLimit     = 500
+)
+
+// Serializer turns an event payload into the JSON embedded in a feed entry.
+type Serializer func(ev ges.StoredEvent) (json.RawMessage, error)
+
+// JSONSerializer marshals the decoded payload with encoding/json.
+func JSONSerializer(ev ges.StoredEvent) (json.RawMessage, error) {
+	return json.Marshal(ev.Payload)
+}
+
+// CodecSerializer encodes payloads with the codec registered for their type, so the
+// feed carries the same representation as the store. Payloads that are not JSON
+// (e.g. gob or CBOR) are embedded as base64 strings. Types without a codec fall
+// back to JSONSerializer.
+func CodecSerializer(registry map[string]ges.EventCodec) Serializer {
+	return func(ev ges.StoredEvent) (json.RawMessage, error) {
+		codec := registry[ev.Type]
+		if codec == nil {
+			return JSONSerializer(ev)
+		}
+		b, err := codec.Encode(ev.Payload)
+		if err != nil {
+			return nil, err
+		}
+		if json.Valid(b) {
+			return b, nil
+		}
+		return json.Marshal(b)
+	}
+}
+
+// Option configures a Handler.
+type Option func(*Handler)
+
+// WithSerializer sets how payloads are written. The default is JSONSerializer.
+func WithSerializer(s Serializer) Option {
+	return func(h *Handler) { h.serialize = s }
+}
+
+// WithDefaultLimit sets the page size used when a request has no limit parameter.
+// Requested limits are always capped at 500.
+func WithDefaultLimit(n int) Option {
+	return func(h *Handler) { h.defaultLimit = min(max(n, 1), maxLimit) }
+}
+
+// Handler is an http.Handler serving event feeds. Category and store-wide feeds
+// need a ges.GlobalReader; stream feeds need a ges.StreamIterator.
+type Handler struct {
+	store        ges.EventStore
+	serialize    Serializer
+	defaultLimit int
+}
+
+// NewHandler creates a Handler reading from store.
+func NewHandler(store ges.EventStore, opts ...Option) *Handler {
+	h := &Handler{
+		store:        store,
+		serialize:    JSONSerializer,
+		defaultLimit: defaultLimit,
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+// Entry is one event in a feed response.
+type Entry struct {
+	ID         string            `json:"id,omitempty"`
+	StreamID   string            `json:"stream_id"`
+	Version    int64             `json:"version"`
+	Position   int64             `json:"position,omitempty"`
+	Type       string            `json:"type"`
+	Data       json.RawMessage   `json:"data"`
+	Metadata   ges.Metadata      `json:"metadata,omitempty"`
+	Headers    map[string]string `json:"headers,omitempty"`
+	OccurredAt time.Time         `json:"occurred_at"`
+	RecordedAt time.Time         `json:"recorded_at"`
+}
+
+// Feed is the body of a feed response.
+type Feed struct {
+	Events []Entry `json:"events"`
+	// NextToken continues a category or store-wide feed; empty for stream feeds.
+	NextToken string `json:"next_token,omitempty"`
+}
+
+// ServeHTTP implements http.Handler.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	q := r.URL.Query()
+	limit := h.defaultLimit
+	if s := q.Get("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 {
+			http.Error(w, "invalid limit", http.StatusBadRequest)
+			return
+		}
+		limit = min(n, maxLimit)
+	}
+
+	if streamID := q.Get("stream"); streamID != "" {
+		h.serveStream(w, r, streamID, limit)
+		return
+	}
+	h.serveCategory(w, r, q.Get("category"), limit)
+}
+
+// serveCategory serves a page of a category, or of the whole store when category is empty.
+func (h *Handler) serveCategory(w http.ResponseWriter, r *http.Request, category string, limit int) {
+	reader, ok := h.store.(ges.GlobalReader)
+	if !ok {
+		http.Error(w, "store cannot read across streams", http.StatusNotImplemented)
+		return
+	}
+
+	var opts []ges.ReadOption
+	if category != "" {
+		opts = append(opts, ges.WithCategory(category))
+	}
+	page, err := ges.ReadPage(r.Context(), reader, r.URL.Query().Get("token"), limit, opts...)
+	if errors.Is(err, ges.ErrInvalidToken) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, "could not read events", http.StatusInternalServerError)
+		return
+	}
+
+	// Positions only go forward, so a category feed links to its start instead of
+	// to a previous page.
+	next := withQuery(r.URL, "token", page.NextToken)
+	first := withQuery(r.URL, "token", "")
+	links := []string{link(next, "next"), link(first, "first")}
+	etag := fmt.Sprintf(`"%s.%d"`, page.NextToken, limit)
+	h.write(w, r, page.Events, page.NextToken, etag, links)
+}
+
+// serveStream serves the events of streamID after the "from" version.
+func (h *Handler) serveStream(w http.ResponseWriter, r *http.Request, streamID string, limit int) {
+	it, ok := h.store.(ges.StreamIterator)
+	if !ok {
+		http.Error(w, "store cannot iterate streams", http.StatusNotImplemented)
+		return
+	}
+
+	var from int64
+	if s := r.URL.Query().Get("from"); s != "" {
+		v, err := strconv.ParseInt(s, 10, 64)
+		if err != nil || v < 0 {
+			http.Error(w, "invalid from", http.StatusBadRequest)
+			return
+		}
+		from = v
+	}
+
+	var events []ges.StoredEvent
+	for ev, err := range it.LoadIter(r.Context(), streamID, from) {
+		if err != nil {
+			http.Error(w, "could not read events", http.StatusInternalServerError)
+			return
+		}
+		events = append(events, ev)
+		if len(events) == limit {
+			break
+		}
+	}
+
+	last := from
+	if n := len(events); n > 0 {
+		last = events[n-1].Version
+	}
+	links := []string{link(withQuery(r.URL, "from", strconv.FormatInt(last, 10)), "next")}
+	if from > 0 {
+		prev := max(from-int64(limit), 0)
+		links = append(links, link(withQuery(r.URL, "from", strconv.FormatInt(prev, 10)), "prev"))
+	}
+	etag := fmt.Sprintf(`"%d.%d.%d"`, from, last, limit)
+	h.write(w, r, events, "", etag, links)
+}
+
+// write sends events as a Feed unless the client already has the current ETag.
+func (h *Handler) write(w http.ResponseWriter, r *http.Request, events []ges.StoredEvent, nextToken, etag string, links []string) {
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Link", strings.Join(links, ", "))
+	w.Header().Set("Cache-Control", "no-cache")
+	if match := r.Header.Get("If-None-Match"); match != "" && matchesETag(match, etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	feed := Feed{Events: make([]Entry, 0, len(events)), NextToken: nextToken}
+	for _, ev := range events {
+		data, err := h.serialize(ev)
+		if err != nil {
+			http.Error(w, "could not serialize event", http.StatusInternalServerError)
+			return
+		}
+		feed.Events = append(feed.Events, Entry{
+			ID:         ev.ID,
+			StreamID:   ev.StreamID,
+			Version:    ev.Version,
+			Position:   ev.Position,
+			Type:       ev.Type,
+			Data:       data,
+			Metadata:   ev.Metadata,
+			Headers:    ev.Headers,
+			OccurredAt: ev.OccurredAt,
+			RecordedAt: ev.RecordedAt,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if r.Method == http.MethodHead {
+		return
+	}
+	_ = json.NewEncoder(w).Encode(feed)
+}
+
+// withQuery returns u with key set to value, or removed if value is empty.
+func withQuery(u *url.URL, key, value string) string {
+	q := u.Query()
+	if value == "" {
+		q.Del(key)
+	} else {
+		q.Set(key, value)
+	}
+	out := *u
+	out.RawQuery = q.Encode()
+	return out.RequestURI()
+}
+
+func link(target, rel string) string {
+	return fmt.Sprintf(`<%s>; rel="%s"`, target, rel)
+}
+
+// matchesETag reports whether an If-None-Match header value matches etag.
+func matchesETag(header, etag string) bool {
+	for candidate := range strings.SplitSeq(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,138 @@
+package httpfeed_test
+
+import (
+	"context"
+	"encoding/json"
+	"iter"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mickamy/go-event-sourcing"
+	"github.com/mickamy/go-event-sourcing/httpfeed"
+)
+
+// sliceStore is a read-only store over a fixed list of events in position order.
+type sliceStore struct {
+	ges.EventStore
+	events []ges.StoredEvent
+}
+
+func (s *sliceStore) ReadAll(_ context.Context, from int64, limit int, opts ...ges.ReadOption) ([]ges.StoredEvent, error) {
+	filter := ges.NewReadFilter(opts...)
+	var out []ges.StoredEvent
+	for _, ev := range s.events {
+		if ev.Position > from && filter.Match(ev) && len(out) < limit {
+			out = append(out, ev)
+		}
+	}
+	return out, nil
+}
+
+func (s *sliceStore) LoadIter(_ context.Context, streamID string, from int64) iter.Seq2[ges.StoredEvent, error] {
+	return func(yield func(ges.StoredEvent, error) bool) {
+		for _, ev := range s.events {
+			if ev.StreamID == streamID && ev.Version > from && !yield(ev, nil) {
+				return
+			}
+		}
+	}
+}
+
+func newStore() *sliceStore {
+	return &sliceStore{events: []ges.StoredEvent{
+		{StreamID: "Account:1", Version: 1, Position: 1, Type: "Opened", Payload: map[string]any{"owner": "a"}},
+		{StreamID: "Order:1", Version: 1, Position: 2, Type: "Placed", Payload: map[string]any{}},
+		{StreamID: "Account:1", Version: 2, Position: 3, Type: "Deposited", Payload: map[string]any{"amount": 5}},
+		{StreamID: "Account:2", Version: 1, Position: 4, Type: "Opened", Payload: map[string]any{"owner": "b"}},
+	}}
+}
+
+func get(t *testing.T, h http.Handler, target string, header http.Header) (*httptest.ResponseRecorder, httpfeed.Feed) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	var feed httpfeed.Feed
+	if rec.Code == http.StatusOK {
+		if err := json.NewDecoder(rec.Body).Decode(&feed); err != nil {
+			t.Fatalf("decode feed: %v", err)
+		}
+	}
+	return rec, feed
+}
+
+// nextLink extracts the rel="next" target from a Link header.
+func nextLink(t *testing.T, header string) string {
+	t.Helper()
+	for part := range strings.SplitSeq(header, ",") {
+		target, rel, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if strings.TrimSpace(rel) == `rel="next"` {
+			return strings.Trim(target, "<>")
+		}
+	}
+	t.Fatalf("no next link in %q", header)
+	return ""
+}
+
+func TestHandler_CategoryPaging(t *testing.T) {
+	t.Parallel()
+	h := httpfeed.NewHandler(newStore())
+
+	var positions []int64
+	target := "/feed?category=Account&limit=2"
+	for range 3 {
+		rec, feed := get(t, h, target, nil)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body)
+		}
+		for _, e := range feed.Events {
+			if !strings.HasPrefix(e.StreamID, "Account:") {
+				t.Fatalf("unexpected stream %s in category feed", e.StreamID)
+			}
+			positions = append(positions, e.Position)
+		}
+		target = nextLink(t, rec.Header().Get("Link"))
+	}
+	if len(positions) != 3 || positions[0] != 1 || positions[1] != 3 || positions[2] != 4 {
+		t.Fatalf("expected positions [1 3 4], got %v", positions)
+	}
+}
+
+func TestHandler_StreamAndETag(t *testing.T) {
+	t.Parallel()
+	h := httpfeed.NewHandler(newStore())
+
+	rec, feed := get(t, h, "/feed?stream=Account:1&from=1", nil)
+	if rec.Code != http.StatusOK || len(feed.Events) != 1 || feed.Events[0].Type != "Deposited" {
+		t.Fatalf("unexpected response %d: %+v", rec.Code, feed)
+	}
+	if string(feed.Events[0].Data) != `{"amount":5}` {
+		t.Fatalf("unexpected data %s", feed.Events[0].Data)
+	}
+	if !strings.Contains(rec.Header().Get("Link"), `rel="prev"`) {
+		t.Fatalf("expected a prev link, got %q", rec.Header().Get("Link"))
+	}
+
+	etag := rec.Header().Get("ETag")
+	rec, _ = get(t, h, "/feed?stream=Account:1&from=1", http.Header{"If-None-Match": {etag}})
+	if rec.Code != http.StatusNotModified {
+		t.Fatalf("expected 304 for a matching ETag, got %d", rec.Code)
+	}
+}
+
+func TestHandler_BadRequests(t *testing.T) {
+	t.Parallel()
+	h := httpfeed.NewHandler(newStore())
+
+	for _, target := range []string{"/feed?token=bogus", "/feed?limit=0", "/feed?stream=Account:1&from=-1"} {
+		if rec, _ := get(t, h, target, nil); rec.Code != http.StatusBadRequest {
+			t.Fatalf("%s: expected 400, got %d", target, rec.Code)
+		}
+	}
+}
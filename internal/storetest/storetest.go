@@ -1,10 +1,18 @@
 package storetest
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"slices"
+	"sync"
 	"testing"
+	"time"
 
 	ges "github.com/mickamy/go-event-sourcing"
+	"github.com/mickamy/go-event-sourcing/estest"
 )
 
 type Opened struct{ ID string }
@@ -15,20 +23,161 @@ type Added struct{ N int }
 
 func (Added) EventType() string { return "Added" }
 
+type Closed struct{ Reason string }
+
+func (Closed) EventType() string { return "Closed" }
+
+// Paid is recorded at most once per payment reference.
+type Paid struct {
+	Ref    string
+	Amount int
+}
+
+func (Paid) EventType() string { return "Paid" }
+
+func (p Paid) DedupKey() string { return p.Ref }
+
+// Counter is a minimal snapshot-capable aggregate used by repository tests.
+type Counter struct {
+	ges.Base
+	Total int
+}
+
+type counterState struct {
+	Total int `json:"total"`
+}
+
+// NewCounter returns an initialized Counter for the given stream.
+func NewCounter(streamID string) *Counter {
+	var c Counter
+	c.Init(streamID, c.when, ges.WithSnapshotter(
+		func() any { return counterState{Total: c.Total} },
+		func(state any) error {
+			s, err := ges.DecodeState[counterState](state)
+			if err != nil {
+				return err
+			}
+			c.Total = s.Total
+			return nil
+		},
+	))
+	return &c
+}
+
+func (c *Counter) when(e ges.Event) {
+	if added, ok := e.(Added); ok {
+		c.Total += added.N
+	}
+}
+
+// plainCounter implements ges.Aggregate directly, without embedding Base.
+type plainCounter struct {
+	id      string
+	version int64
+	total   int
+}
+
+func (c *plainCounter) StreamID() string { return c.id }
+func (c *plainCounter) Version() int64   { return c.version }
+
+func (c *plainCounter) Flush() ([]ges.Event, int64) { return nil, c.version }
+
+func (c *plainCounter) Apply(e ges.Event) {
+	if added, ok := e.(Added); ok {
+		c.total += added.N
+	}
+	c.version++
+}
+
+// ApplySnapshot restores the total and, lacking SetVersion, the version as well.
+func (c *plainCounter) ApplySnapshot(state any) error {
+	s, err := ges.DecodeState[struct {
+		Total   int   `json:"total"`
+		Version int64 `json:"version"`
+	}](state)
+	if err != nil {
+		return err
+	}
+	c.total, c.version = s.Total, s.Version
+	return nil
+}
+
 // Factory creates a new EventStore instance for testing.
 // Each test should receive a fresh, isolated instance.
 // Use t.Cleanup for teardown logic if necessary.
 type Factory func(t *testing.T) ges.EventStore
 
+// SnapshotFactory creates a fresh SnapshotStore for RunSnapshots.
+type SnapshotFactory func(t *testing.T) ges.SnapshotStore
+
 // Registry provides a minimal codec registry used for tests.
 // It avoids dependency on domain-specific event definitions.
 func Registry() map[string]ges.EventCodec {
 	return map[string]ges.EventCodec{
 		"Opened": ges.JSONCodec[Opened](),
 		"Added":  ges.JSONCodec[Added](),
+		"Closed": ges.JSONCodec[Closed](),
+		"Paid":   ges.JSONCodec[Paid](),
+	}
+}
+
+// SchemaFunc adapts a function to ges.PayloadSchema.
+type SchemaFunc func(v any) error
+
+func (f SchemaFunc) Validate(v any) error { return f(v) }
+
+// ErrNegative is the schema violation of an Added event with a negative N.
+var ErrNegative = errors.New("N must not be negative")
+
+// Schemas provides the payload schemas RunSchemaRegistry expects: Added must not
+// have a negative N.
+func Schemas() map[string]ges.PayloadSchema {
+	return map[string]ges.PayloadSchema{
+		"Added": SchemaFunc(func(v any) error {
+			if n, _ := v.(map[string]any)["N"].(json.Number).Int64(); n < 0 {
+				return ErrNegative
+			}
+			return nil
+		}),
 	}
 }
 
+// LegacyContentType is the content type of Added events written by legacyAdded.
+const LegacyContentType = "application/vnd.storetest.added-v1+json"
+
+// ContentTypeRegistry provides the codecs for older encodings of the events in
+// Registry: Added used to be written as {"amount": n}.
+func ContentTypeRegistry() map[ges.CodecKey]ges.EventCodec {
+	return map[ges.CodecKey]ges.EventCodec{
+		{EventType: "Added", ContentType: LegacyContentType}: legacyAdded{},
+	}
+}
+
+type legacyAdded struct{}
+
+func (legacyAdded) Encode(v any) ([]byte, error) {
+	return json.Marshal(map[string]int{"amount": v.(Added).N})
+}
+
+func (legacyAdded) Decode(b []byte) (any, error) {
+	var v struct{ Amount int }
+	if err := json.Unmarshal(b, &v); err != nil {
+		return nil, err
+	}
+	return Added{N: v.Amount}, nil
+}
+
+// limitRecorder is a GlobalReader recording the largest limit it was asked for.
+type limitRecorder struct {
+	ges.GlobalReader
+	largest int
+}
+
+func (r *limitRecorder) ReadAll(ctx context.Context, from int64, limit int, opts ...ges.ReadOption) ([]ges.StoredEvent, error) {
+	r.largest = max(r.largest, limit)
+	return r.GlobalReader.ReadAll(ctx, from, limit, opts...)
+}
+
 // Run executes a suite of compliance tests that verify an EventStore
 // implementation adheres to the expected semantics.
 // Each subtest runs in parallel, so stores must be concurrency-safe.
@@ -75,6 +224,56 @@ func Run(t *testing.T, newStore Factory) {
 		}
 	})
 
+	t.Run("batch order", func(t *testing.T) {
+		t.Parallel()
+		ctx := t.Context()
+		s := newStore(t)
+		streamID := "Stream:batch-order"
+
+		batch := []ges.Event{Opened{ID: "batch"}, Added{N: 7}, Closed{Reason: "done"}}
+		v, err := s.Append(ctx, streamID, 0, batch, nil)
+		if err != nil {
+			t.Fatalf("append failed: %v", err)
+		}
+		if v != 3 {
+			t.Fatalf("expected version 3, got %d", v)
+		}
+
+		evs, last, err := s.Load(ctx, streamID, 0)
+		if err != nil {
+			t.Fatalf("load failed: %v", err)
+		}
+		if last != 3 {
+			t.Fatalf("expected last version 3, got %d", last)
+		}
+		if len(evs) != len(batch) {
+			t.Fatalf("expected %d events, got %d", len(batch), len(evs))
+		}
+		for i, want := range batch {
+			if evs[i] != want {
+				t.Fatalf("event %d: expected %#v, got %#v", i, want, evs[i])
+			}
+		}
+
+		it, ok := s.(ges.StreamIterator)
+		if !ok {
+			return
+		}
+		want := int64(1)
+		for ev, err := range it.LoadIter(ctx, streamID, 0) {
+			if err != nil {
+				t.Fatalf("load iter failed: %v", err)
+			}
+			if ev.Version != want {
+				t.Fatalf("expected version %d, got %d", want, ev.Version)
+			}
+			want++
+		}
+		if want != 4 {
+			t.Fatalf("expected 3 events from LoadIter, got %d", want-1)
+		}
+	})
+
 	t.Run("version conflict", func(t *testing.T) {
 		t.Parallel()
 		ctx := t.Context()
@@ -93,9 +292,1598 @@ func Run(t *testing.T, newStore Factory) {
 			Added{N: 1},
 		}, nil)
 
+		vc := estest.AssertConflict(t, err, streamID, 0, 1)
+		if vc.IsStreamNotFound() || errors.Is(err, ges.ErrStreamNotFound) {
+			t.Fatalf("expected a conflict on an existing stream, got %v", err)
+		}
+
+		// Expecting events on a stream that has none is reported as not found.
+		_, err = s.Append(ctx, "Stream:2-missing", 3, []ges.Event{Added{N: 1}}, nil)
+		if !errors.Is(err, ges.ErrStreamNotFound) || !errors.Is(err, ges.ErrVersionConflict) {
+			t.Fatalf("expected ErrStreamNotFound and ErrVersionConflict, got %v", err)
+		}
+	})
+
+	t.Run("append envelopes", func(t *testing.T) {
+		t.Parallel()
+		ctx := t.Context()
+		s := newStore(t)
+		appender, ok := s.(ges.EnvelopeAppender)
+		if !ok {
+			t.Skip("store does not implement EnvelopeAppender")
+		}
+		it, ok := s.(ges.StreamIterator)
+		if !ok {
+			t.Skip("store does not implement StreamIterator")
+		}
+		streamID := "Stream:envelopes"
+		occurred := time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+
+		v, err := appender.AppendEnvelopes(ctx, streamID, 0, []ges.Envelope{
+			{Event: Opened{ID: "env"}, Headers: map[string]string{"schema_version": "2"}, OccurredAt: occurred},
+			{Event: Added{N: 1}},
+		}, ges.Metadata{"user_id": "u1"})
+		if err != nil {
+			t.Fatalf("append envelopes failed: %v", err)
+		}
+		if v != 2 {
+			t.Fatalf("expected version 2, got %d", v)
+		}
+
+		var got []ges.StoredEvent
+		for ev, err := range it.LoadIter(ctx, streamID, 0) {
+			if err != nil {
+				t.Fatalf("load iter failed: %v", err)
+			}
+			got = append(got, ev)
+		}
+		if len(got) != 2 {
+			t.Fatalf("expected 2 events, got %d", len(got))
+		}
+		if got[0].Headers["schema_version"] != "2" || !got[0].OccurredAt.Equal(occurred) {
+			t.Fatalf("expected headers and occurrence time on first event, got %+v", got[0])
+		}
+		if got[0].RecordedAt.Before(got[0].OccurredAt) || !got[0].At.Equal(got[0].RecordedAt) {
+			t.Fatalf("expected RecordedAt (and At) to be the write time, got %+v", got[0])
+		}
+		if !got[1].OccurredAt.Equal(got[1].RecordedAt) {
+			t.Fatalf("expected OccurredAt to default to RecordedAt, got %+v", got[1])
+		}
+		if len(got[1].Headers) != 0 {
+			t.Fatalf("expected no headers on second event, got %v", got[1].Headers)
+		}
+		for _, ev := range got {
+			if ev.Metadata["user_id"] != "u1" {
+				t.Fatalf("expected batch metadata on every event, got %v", ev.Metadata)
+			}
+		}
+	})
+
+	t.Run("append auto", func(t *testing.T) {
+		t.Parallel()
+		ctx := t.Context()
+		s := newStore(t)
+		auto, ok := s.(ges.AutoAppender)
+		if !ok {
+			t.Skip("store does not implement AutoAppender")
+		}
+		streamID := "Stream:append-auto"
+
+		if v, err := auto.AppendAuto(ctx, streamID, nil, nil); err != nil || v != 0 {
+			t.Fatalf("expected version 0 for an empty append to a new stream, got %d, %v", v, err)
+		}
+		if v, err := auto.AppendAuto(ctx, streamID, []ges.Event{Opened{ID: "a"}, Added{N: 1}}, nil); err != nil || v != 2 {
+			t.Fatalf("expected version 2, got %d, %v", v, err)
+		}
+		if _, err := s.Append(ctx, streamID, 2, []ges.Event{Added{N: 2}}, nil); err != nil {
+			t.Fatalf("append failed: %v", err)
+		}
+		if v, err := auto.AppendAuto(ctx, streamID, []ges.Event{Added{N: 3}}, nil); err != nil || v != 4 {
+			t.Fatalf("expected version 4 after another writer, got %d, %v", v, err)
+		}
+		if v, err := auto.AppendAuto(ctx, streamID, nil, nil); err != nil || v != 4 {
+			t.Fatalf("expected an empty append to report version 4, got %d, %v", v, err)
+		}
+	})
+
+	t.Run("dedup keys are opt-in", func(t *testing.T) {
+		t.Parallel()
+		ctx := t.Context()
+		s := newStore(t)
+		streamID := "Stream:dedup-opt-in"
+
+		for version := range int64(2) {
+			if _, err := s.Append(ctx, streamID, version, []ges.Event{Paid{Ref: "p1", Amount: 5}}, nil); err != nil {
+				t.Fatalf("append failed: %v", err)
+			}
+		}
+		if events, _, err := s.Load(ctx, streamID, 0); err != nil || len(events) != 2 {
+			t.Fatalf("expected both events without dedup keys, got %v, %v", events, err)
+		}
+	})
+
+	t.Run("ensure version", func(t *testing.T) {
+		t.Parallel()
+		ctx := t.Context()
+		s := newStore(t)
+		checker, ok := s.(ges.VersionChecker)
+		if !ok {
+			t.Skip("store does not implement VersionChecker")
+		}
+		streamID := "Stream:ensure-version"
+
+		if err := checker.EnsureVersion(ctx, streamID, 0); err != nil {
+			t.Fatalf("expected empty stream at version 0, got %v", err)
+		}
+		if err := checker.EnsureVersion(ctx, streamID, 1); !errors.Is(err, ges.ErrVersionConflict) {
+			t.Fatalf("expected version conflict, got %v", err)
+		}
+
+		if _, err := s.Append(ctx, streamID, 0, []ges.Event{Opened{ID: "ensure"}}, nil); err != nil {
+			t.Fatalf("append failed: %v", err)
+		}
+		if err := checker.EnsureVersion(ctx, streamID, 1); err != nil {
+			t.Fatalf("expected version 1, got %v", err)
+		}
+
 		var vc *ges.VersionConflictError
-		if !errors.As(err, &vc) {
-			t.Fatalf("expected VersionConflictError, got %v", err)
+		if err := checker.EnsureVersion(ctx, streamID, 0); !errors.As(err, &vc) || vc.ActualVersion != 1 {
+			t.Fatalf("expected conflict with actual version 1, got %v", err)
+		}
+	})
+
+	t.Run("missing stream", func(t *testing.T) {
+		t.Parallel()
+		ctx := t.Context()
+		s := newStore(t)
+		streamID := "Stream:missing"
+
+		evs, last, err := s.Load(ctx, streamID, 0)
+		if err != nil || evs != nil || last != 0 {
+			t.Fatalf("expected nil events at version 0, got %v at %d, %v", evs, last, err)
+		}
+
+		checker, ok := s.(ges.ExistenceChecker)
+		if !ok {
+			t.Skip("store does not implement ExistenceChecker")
+		}
+		if exists, err := checker.Exists(ctx, streamID); err != nil || exists {
+			t.Fatalf("expected a missing stream not to exist, got %v, %v", exists, err)
+		}
+		if _, err := s.Append(ctx, streamID, 0, []ges.Event{Opened{ID: "missing"}}, nil); err != nil {
+			t.Fatalf("append failed: %v", err)
+		}
+		if exists, err := checker.Exists(ctx, streamID); err != nil || !exists {
+			t.Fatalf("expected the stream to exist after append, got %v, %v", exists, err)
+		}
+
+		// Loading past the end still reports the stream's version.
+		evs, last, err = s.Load(ctx, streamID, 1)
+		if err != nil || len(evs) != 0 || last != 1 {
+			t.Fatalf("expected no events at version 1, got %d at %d, %v", len(evs), last, err)
+		}
+	})
+
+	t.Run("empty append is a no-op", func(t *testing.T) {
+		t.Parallel()
+		ctx := t.Context()
+		s := newStore(t)
+		streamID := "Stream:empty-append"
+
+		v, err := s.Append(ctx, streamID, 7, nil, nil)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if v != 7 {
+			t.Fatalf("expected expectedVersion to be returned, got %d", v)
+		}
+		if evs, _, err := s.Load(ctx, streamID, 0); err != nil || len(evs) != 0 {
+			t.Fatalf("expected no events, got %d (err=%v)", len(evs), err)
+		}
+	})
+
+	t.Run("negative expected version", func(t *testing.T) {
+		t.Parallel()
+		ctx := t.Context()
+		s := newStore(t)
+		streamID := "Stream:negative-version"
+
+		if _, err := s.Append(ctx, streamID, -1, []ges.Event{Opened{ID: "x"}}, nil); !errors.Is(err, ges.ErrInvalidExpectedVersion) {
+			t.Fatalf("expected ErrInvalidExpectedVersion, got %v", err)
+		}
+		if _, err := s.Append(ctx, streamID, -1, nil, nil); !errors.Is(err, ges.ErrInvalidExpectedVersion) {
+			t.Fatalf("expected ErrInvalidExpectedVersion for an empty batch, got %v", err)
+		}
+		if evs, _, err := s.Load(ctx, streamID, 0); err != nil || len(evs) != 0 {
+			t.Fatalf("expected no events, got %d (err=%v)", len(evs), err)
+		}
+	})
+
+	t.Run("nil event", func(t *testing.T) {
+		t.Parallel()
+		ctx := t.Context()
+		s := newStore(t)
+		streamID := "Stream:nil-event"
+
+		if _, err := s.Append(ctx, streamID, 0, []ges.Event{Opened{ID: "x"}, nil}, nil); !errors.Is(err, ges.ErrNilEvent) {
+			t.Fatalf("expected ErrNilEvent, got %v", err)
+		}
+		if evs, _, err := s.Load(ctx, streamID, 0); err != nil || len(evs) != 0 {
+			t.Fatalf("expected no events, got %d (err=%v)", len(evs), err)
+		}
+	})
+
+	t.Run("stream metadata", func(t *testing.T) {
+		t.Parallel()
+		ctx := t.Context()
+		s := newStore(t)
+		ms, ok := s.(ges.StreamMetadataStore)
+		if !ok {
+			t.Skip("store does not implement StreamMetadataStore")
+		}
+		streamID := "Stream:stream-metadata"
+
+		md, err := ms.GetStreamMetadata(ctx, streamID)
+		if err != nil || md != nil {
+			t.Fatalf("expected no metadata, got %v (err=%v)", md, err)
+		}
+		if err := ms.SetStreamMetadata(ctx, streamID, ges.Metadata{"schema": "v1", "deleted": false}); err != nil {
+			t.Fatalf("set failed: %v", err)
+		}
+		if err := ms.SetStreamMetadata(ctx, streamID, ges.Metadata{"schema": "v2"}); err != nil {
+			t.Fatalf("set failed: %v", err)
+		}
+		md, err = ms.GetStreamMetadata(ctx, streamID)
+		if err != nil {
+			t.Fatalf("get failed: %v", err)
+		}
+		if len(md) != 1 || md["schema"] != "v2" {
+			t.Fatalf("expected metadata to be replaced, got %v", md)
+		}
+	})
+
+	RunSnapshots(t, func(t *testing.T) ges.SnapshotStore { return newStore(t) })
+
+	t.Run("load iter", func(t *testing.T) {
+		t.Parallel()
+		ctx := t.Context()
+		s := newStore(t)
+		it, ok := s.(ges.StreamIterator)
+		if !ok {
+			t.Skip("store does not implement StreamIterator")
+		}
+		streamID := "Stream:load-iter"
+
+		if _, err := s.Append(ctx, streamID, 0, []ges.Event{
+			Opened{ID: "iter"},
+			Added{N: 1},
+			Added{N: 2},
+		}, ges.Metadata{"user_id": "u1"}); err != nil {
+			t.Fatalf("append failed: %v", err)
+		}
+
+		var got []ges.StoredEvent
+		for ev, err := range it.LoadIter(ctx, streamID, 1) {
+			if err != nil {
+				t.Fatalf("load iter failed: %v", err)
+			}
+			got = append(got, ev)
+		}
+		if len(got) != 2 {
+			t.Fatalf("expected 2 events, got %d", len(got))
+		}
+		for i, ev := range got {
+			if ev.Version != int64(i+2) {
+				t.Fatalf("expected version %d, got %d", i+2, ev.Version)
+			}
+			if ev.StreamID != streamID || ev.Type != "Added" || ev.ID == "" || ev.Position == 0 {
+				t.Fatalf("unexpected stored event: %+v", ev)
+			}
+			if ev.Metadata["user_id"] != "u1" {
+				t.Fatalf("expected metadata to be loaded, got %v", ev.Metadata)
+			}
+		}
+		if got[0].Position >= got[1].Position {
+			t.Fatalf("expected ascending positions, got %d then %d", got[0].Position, got[1].Position)
+		}
+
+		// Breaking out of the loop early must not fail or leak.
+		for range it.LoadIter(ctx, streamID, 0) {
+			break
+		}
+	})
+
+	t.Run("load last", func(t *testing.T) {
+		t.Parallel()
+		ctx := t.Context()
+		s := newStore(t)
+		loader, ok := s.(ges.LastLoader)
+		if !ok {
+			t.Skip("store does not implement LastLoader")
+		}
+		streamID := "Stream:load-last"
+
+		if _, err := s.Append(ctx, streamID, 0, []ges.Event{
+			Opened{ID: "l"}, Added{N: 1}, Added{N: 2}, Added{N: 3},
+		}, nil); err != nil {
+			t.Fatalf("append failed: %v", err)
+		}
+
+		last, err := loader.LoadLast(ctx, streamID, 2)
+		if err != nil {
+			t.Fatalf("load last failed: %v", err)
+		}
+		if len(last) != 2 || last[0].Version != 3 || last[1].Version != 4 {
+			t.Fatalf("expected versions 3 and 4 in order, got %+v", last)
+		}
+		all, err := loader.LoadLast(ctx, streamID, 10)
+		if err != nil {
+			t.Fatalf("load last failed: %v", err)
+		}
+		if len(all) != 4 || all[0].Version != 1 {
+			t.Fatalf("expected the whole stream when it is shorter than n, got %d events", len(all))
+		}
+		if none, err := loader.LoadLast(ctx, "Stream:load-last-missing", 3); err != nil || len(none) != 0 {
+			t.Fatalf("expected no events for a missing stream, got %d, %v", len(none), err)
+		}
+	})
+
+	t.Run("load page", func(t *testing.T) {
+		t.Parallel()
+		ctx := t.Context()
+		s := newStore(t)
+		loader, ok := s.(ges.PageLoader)
+		if !ok {
+			t.Skip("store does not implement PageLoader")
+		}
+		streamID := "Stream:load-page"
+
+		if _, err := s.Append(ctx, streamID, 0, []ges.Event{
+			Opened{ID: "p"}, Added{N: 1}, Added{N: 2}, Added{N: 3}, Added{N: 4},
+		}, ges.Metadata{"actor": "alice"}); err != nil {
+			t.Fatalf("append failed: %v", err)
+		}
+
+		versions := func(events []ges.StoredEvent) []int64 {
+			out := make([]int64, len(events))
+			for i, ev := range events {
+				out[i] = ev.Version
+			}
+			return out
+		}
+		for _, tc := range []struct {
+			after   int64
+			desc    bool
+			want    []int64
+			hasMore bool
+		}{
+			{after: 0, want: []int64{1, 2}, hasMore: true},
+			{after: 2, want: []int64{3, 4}, hasMore: true},
+			{after: 4, want: []int64{5}},
+			{after: 5},
+			{after: 0, desc: true, want: []int64{5, 4}, hasMore: true},
+			{after: 4, desc: true, want: []int64{3, 2}, hasMore: true},
+			{after: 2, desc: true, want: []int64{1}},
+		} {
+			page, hasMore, err := loader.LoadPage(ctx, streamID, tc.after, 2, tc.desc)
+			if err != nil {
+				t.Fatalf("load page failed: %v", err)
+			}
+			if got := versions(page); !slices.Equal(got, tc.want) || hasMore != tc.hasMore {
+				t.Fatalf("after %d (desc=%v): expected %v, more=%v, got %v, more=%v", tc.after, tc.desc, tc.want, tc.hasMore, got, hasMore)
+			}
+		}
+
+		page, _, err := loader.LoadPage(ctx, streamID, 0, 1, false)
+		if err != nil || len(page) != 1 || page[0].Metadata["actor"] != "alice" {
+			t.Fatalf("expected the page to carry metadata, got %+v, %v", page, err)
+		}
+	})
+
+	t.Run("load many", func(t *testing.T) {
+		t.Parallel()
+		ctx := t.Context()
+		s := newStore(t)
+		loader, ok := s.(ges.MultiLoader)
+		if !ok {
+			t.Skip("store does not implement MultiLoader")
+		}
+		streamA := "Stream:load-many-a"
+		streamB := "Stream:load-many-b"
+		missing := "Stream:load-many-missing"
+
+		if _, err := s.Append(ctx, streamA, 0, []ges.Event{Opened{ID: "a"}, Added{N: 1}, Added{N: 2}}, nil); err != nil {
+			t.Fatalf("append failed: %v", err)
+		}
+		if _, err := s.Append(ctx, streamB, 0, []ges.Event{Opened{ID: "b"}}, nil); err != nil {
+			t.Fatalf("append failed: %v", err)
+		}
+
+		got, err := loader.LoadMany(ctx, map[string]int64{streamA: 1, streamB: 0, missing: 0})
+		if err != nil {
+			t.Fatalf("load many failed: %v", err)
+		}
+		if len(got) != 3 {
+			t.Fatalf("expected an entry per requested stream, got %d", len(got))
+		}
+		if a := got[streamA]; len(a) != 2 || a[0].Version != 2 || a[1].Version != 3 {
+			t.Fatalf("expected versions 2 and 3 of %s, got %+v", streamA, a)
+		}
+		if b := got[streamB]; len(b) != 1 || b[0].Payload != (Opened{ID: "b"}) {
+			t.Fatalf("expected the Opened event of %s, got %+v", streamB, b)
+		}
+		if m, ok := got[missing]; !ok || len(m) != 0 {
+			t.Fatalf("expected an empty entry for %s, got %+v (present=%v)", missing, m, ok)
+		}
+	})
+
+	t.Run("read all filtered by type", func(t *testing.T) {
+		t.Parallel()
+		ctx := t.Context()
+		s := newStore(t)
+		reader, ok := s.(ges.GlobalReader)
+		if !ok {
+			t.Skip("store does not implement GlobalReader")
+		}
+		streamID := "Stream:read-all-filtered"
+
+		if _, err := s.Append(ctx, streamID, 0, []ges.Event{
+			Opened{ID: "f"},
+			Closed{Reason: "first"},
+			Added{N: 1},
+			Closed{Reason: "second"},
+		}, nil); err != nil {
+			t.Fatalf("append failed: %v", err)
+		}
+
+		var reasons []string
+		err := ges.ReplayAll(ctx, reader, func(ev ges.StoredEvent) error {
+			if ev.Type != "Closed" {
+				return fmt.Errorf("unexpected event type %s", ev.Type)
+			}
+			if ev.StreamID == streamID {
+				reasons = append(reasons, ev.Payload.(Closed).Reason)
+			}
+			return nil
+		}, ges.WithEventTypes("Closed"))
+		if err != nil {
+			t.Fatalf("replay all failed: %v", err)
+		}
+		if fmt.Sprint(reasons) != "[first second]" {
+			t.Fatalf("expected only Closed events, got %v", reasons)
+		}
+	})
+
+	t.Run("read all filtered by stream", func(t *testing.T) {
+		t.Parallel()
+		ctx := t.Context()
+		s := newStore(t)
+		reader, ok := s.(ges.GlobalReader)
+		if !ok {
+			t.Skip("store does not implement GlobalReader")
+		}
+		streamID, other := "Stream:read-all-stream", "Stream:read-all-stream-other"
+
+		if _, err := s.Append(ctx, streamID, 0, []ges.Event{Opened{ID: "s"}}, nil); err != nil {
+			t.Fatalf("append failed: %v", err)
+		}
+		if _, err := s.Append(ctx, other, 0, []ges.Event{Opened{ID: "o"}}, nil); err != nil {
+			t.Fatalf("append failed: %v", err)
+		}
+		if _, err := s.Append(ctx, streamID, 1, []ges.Event{Added{N: 1}}, nil); err != nil {
+			t.Fatalf("append failed: %v", err)
+		}
+
+		events, err := reader.ReadAll(ctx, 0, 100, ges.WithStream(streamID))
+		if err != nil {
+			t.Fatalf("read all failed: %v", err)
+		}
+		if len(events) != 2 {
+			t.Fatalf("expected 2 events of %s, got %d", streamID, len(events))
+		}
+		for i, ev := range events {
+			if ev.StreamID != streamID || ev.Version != int64(i+1) {
+				t.Fatalf("event %d: unexpected %s@%d", i, ev.StreamID, ev.Version)
+			}
+		}
+		if events[0].Position >= events[1].Position {
+			t.Fatalf("expected increasing positions, got %d and %d", events[0].Position, events[1].Position)
+		}
+	})
+
+	t.Run("read category with tokens", func(t *testing.T) {
+		t.Parallel()
+		ctx := t.Context()
+		s := newStore(t)
+		reader, ok := s.(ges.GlobalReader)
+		if !ok {
+			t.Skip("store does not implement GlobalReader")
+		}
+		// A category of its own, so events of parallel subtests do not show up.
+		category := "Paged_" + fmt.Sprint(time.Now().UnixNano())
+
+		for _, streamID := range []string{category + ":1", "Other_" + category + ":1", category + ":2"} {
+			if _, err := s.Append(ctx, streamID, 0, []ges.Event{Opened{ID: streamID}}, nil); err != nil {
+				t.Fatalf("append failed: %v", err)
+			}
+		}
+
+		var got []string
+		token := ""
+		for range 5 {
+			page, err := ges.ReadCategory(ctx, reader, category, token, 1)
+			if err != nil {
+				t.Fatalf("read category failed: %v", err)
+			}
+			if page.NextToken == "" {
+				t.Fatal("expected a next token")
+			}
+			for _, ev := range page.Events {
+				got = append(got, ev.StreamID)
+			}
+			token = page.NextToken
+			if len(page.Events) == 0 {
+				break
+			}
+		}
+		if want := fmt.Sprint([]string{category + ":1", category + ":2"}); fmt.Sprint(got) != want {
+			t.Fatalf("expected %s, got %v", want, got)
+		}
+
+		if _, err := ges.ReadPage(ctx, reader, token, 1); !errors.Is(err, ges.ErrInvalidToken) {
+			t.Fatalf("expected ErrInvalidToken for a token of another filter, got %v", err)
+		}
+		if _, err := ges.ReadCategory(ctx, reader, category, "not-a-token", 1); !errors.Is(err, ges.ErrInvalidToken) {
+			t.Fatalf("expected ErrInvalidToken for garbage, got %v", err)
+		}
+	})
+
+	t.Run("stats", func(t *testing.T) {
+		t.Parallel()
+		ctx := t.Context()
+		s := newStore(t)
+		reporter, ok := s.(ges.StatsReporter)
+		if !ok {
+			t.Skip("store does not implement StatsReporter")
+		}
+
+		// The store may be shared, so only the categories of this test are exact.
+		before, err := reporter.Stats(ctx)
+		if err != nil {
+			t.Fatalf("stats failed: %v", err)
+		}
+		for _, streamID := range []string{"StatsAccount:1", "StatsAccount:2", "StatsOrder:1"} {
+			if _, err := s.Append(ctx, streamID, 0, []ges.Event{Opened{ID: streamID}, Added{N: 1}}, nil); err != nil {
+				t.Fatalf("append failed: %v", err)
+			}
+		}
+		if err := s.SaveSnapshot(ctx, "StatsOrder:1", 2, map[string]any{"n": 1}); err != nil {
+			t.Fatalf("save snapshot failed: %v", err)
+		}
+
+		after, err := reporter.Stats(ctx)
+		if err != nil {
+			t.Fatalf("stats failed: %v", err)
+		}
+		if after.Events-before.Events < 6 || after.Streams-before.Streams < 3 || after.Snapshots-before.Snapshots < 1 {
+			t.Fatalf("expected at least 6 events in 3 streams with 1 snapshot more, got %+v then %+v", before, after)
+		}
+		if after.Categories["StatsAccount"] != 4 || after.Categories["StatsOrder"] != 2 {
+			t.Fatalf("expected 4 StatsAccount and 2 StatsOrder events, got %v", after.Categories)
+		}
+	})
+
+	t.Run("read all", func(t *testing.T) {
+		t.Parallel()
+		ctx := t.Context()
+		s := newStore(t)
+		reader, ok := s.(ges.GlobalReader)
+		if !ok {
+			t.Skip("store does not implement GlobalReader")
+		}
+		streamA := "Stream:read-all-a"
+		streamB := "Stream:read-all-b"
+
+		if _, err := s.Append(ctx, streamA, 0, []ges.Event{Opened{ID: "a"}}, nil); err != nil {
+			t.Fatalf("append failed: %v", err)
+		}
+		if _, err := s.Append(ctx, streamB, 0, []ges.Event{Opened{ID: "b"}}, nil); err != nil {
+			t.Fatalf("append failed: %v", err)
+		}
+		if _, err := s.Append(ctx, streamA, 1, []ges.Event{Added{N: 1}}, nil); err != nil {
+			t.Fatalf("append failed: %v", err)
+		}
+
+		var order []string
+		var position int64
+		for {
+			events, err := reader.ReadAll(ctx, position, 2)
+			if err != nil {
+				t.Fatalf("read all failed: %v", err)
+			}
+			if len(events) > 2 {
+				t.Fatalf("expected at most 2 events per page, got %d", len(events))
+			}
+			for _, ev := range events {
+				if ev.Position <= position {
+					t.Fatalf("expected ascending positions, got %d after %d", ev.Position, position)
+				}
+				position = ev.Position
+				if ev.StreamID == streamA || ev.StreamID == streamB {
+					order = append(order, fmt.Sprintf("%s@%d", ev.StreamID, ev.Version))
+				}
+			}
+			if len(events) == 0 {
+				break
+			}
+		}
+
+		want := []string{streamA + "@1", streamB + "@1", streamA + "@2"}
+		if fmt.Sprint(order) != fmt.Sprint(want) {
+			t.Fatalf("expected global order %v, got %v", want, order)
+		}
+	})
+
+	t.Run("replay", func(t *testing.T) {
+		t.Parallel()
+		ctx := t.Context()
+		s := newStore(t)
+		it, ok := s.(ges.StreamIterator)
+		if !ok {
+			t.Skip("store does not implement StreamIterator")
+		}
+		streamID := "Stream:replay"
+
+		if _, err := s.Append(ctx, streamID, 0, []ges.Event{
+			Opened{ID: "replay"},
+			Added{N: 2},
+			Added{N: 3},
+		}, nil); err != nil {
+			t.Fatalf("append failed: %v", err)
+		}
+
+		sum := 0
+		if err := ges.Replay(ctx, it, streamID, func(ev ges.StoredEvent) error {
+			if added, ok := ev.Payload.(Added); ok {
+				sum += added.N
+			}
+			return nil
+		}); err != nil {
+			t.Fatalf("replay failed: %v", err)
+		}
+		if sum != 5 {
+			t.Fatalf("expected sum 5, got %d", sum)
+		}
+
+		errStop := errors.New("stop")
+		err := ges.Replay(ctx, it, streamID, func(ges.StoredEvent) error { return errStop })
+		if !errors.Is(err, errStop) {
+			t.Fatalf("expected handler error, got %v", err)
+		}
+
+		reader, ok := s.(ges.GlobalReader)
+		if !ok {
+			return
+		}
+		seen := 0
+		if err := ges.ReplayAll(ctx, reader, func(ev ges.StoredEvent) error {
+			if ev.StreamID == streamID {
+				seen++
+			}
+			return nil
+		}); err != nil {
+			t.Fatalf("replay all failed: %v", err)
+		}
+		if seen != 3 {
+			t.Fatalf("expected 3 events from ReplayAll, got %d", seen)
+		}
+	})
+
+	t.Run("repository snapshot", func(t *testing.T) {
+		t.Parallel()
+		ctx := t.Context()
+		s := newStore(t)
+		repo := ges.NewRepository(s, NewCounter)
+		streamID := "Counter:repository-snapshot"
+
+		c, err := repo.Load(ctx, streamID)
+		if err != nil {
+			t.Fatalf("load failed: %v", err)
+		}
+		c.Raise(Added{N: 2})
+		c.Raise(Added{N: 3})
+		if err := repo.Save(ctx, c, nil); err != nil {
+			t.Fatalf("save failed: %v", err)
+		}
+		if err := repo.SaveSnapshot(ctx, c); err != nil {
+			t.Fatalf("save snapshot failed: %v", err)
+		}
+		c.Raise(Added{N: 5})
+		if err := repo.Save(ctx, c, nil); err != nil {
+			t.Fatalf("save failed: %v", err)
+		}
+
+		snap, err := s.LoadSnapshot(ctx, streamID)
+		if err != nil {
+			t.Fatalf("load snapshot failed: %v", err)
+		}
+		if !snap.Found || snap.Version != 2 {
+			t.Fatalf("expected snapshot at version 2, got %+v", snap)
+		}
+
+		loaded, err := repo.Load(ctx, streamID)
+		if err != nil {
+			t.Fatalf("load failed: %v", err)
+		}
+		if loaded.Total != 10 || loaded.Version() != 3 {
+			t.Fatalf("expected total 10 at version 3, got %d at %d", loaded.Total, loaded.Version())
+		}
+	})
+
+	t.Run("rehydrate without base", func(t *testing.T) {
+		t.Parallel()
+		ctx := t.Context()
+		s := newStore(t)
+		streamID := "Counter:rehydrate-plain"
+
+		if _, err := s.Append(ctx, streamID, 0, []ges.Event{
+			Opened{ID: "p"}, Added{N: 1}, Added{N: 2}, Added{N: 3},
+		}, nil); err != nil {
+			t.Fatalf("append failed: %v", err)
+		}
+		if err := s.SaveSnapshot(ctx, streamID, 2, map[string]any{"total": 1, "version": 2}); err != nil {
+			t.Fatalf("save snapshot failed: %v", err)
+		}
+
+		c := &plainCounter{id: streamID}
+		if err := ges.Rehydrate(ctx, s, streamID, c); err != nil {
+			t.Fatalf("rehydrate failed: %v", err)
+		}
+		if c.total != 6 || c.version != 4 {
+			t.Fatalf("expected total 6 at version 4, got %d at %d", c.total, c.version)
+		}
+	})
+
+	t.Run("snapshot history and load at", func(t *testing.T) {
+		t.Parallel()
+		ctx := t.Context()
+		s := newStore(t)
+		history, ok := s.(ges.SnapshotHistoryLoader)
+		if !ok {
+			t.Skip("store does not implement SnapshotHistoryLoader")
+		}
+		repo := ges.NewRepository(s, NewCounter)
+		streamID := "Counter:snapshot-history"
+
+		c, err := repo.Load(ctx, streamID)
+		if err != nil {
+			t.Fatalf("load failed: %v", err)
+		}
+		for i := range 5 {
+			c.Raise(Added{N: i + 1})
+			if err := repo.Save(ctx, c, nil); err != nil {
+				t.Fatalf("save failed: %v", err)
+			}
+			if v := c.Version(); v == 2 || v == 4 {
+				if err := repo.SaveSnapshot(ctx, c); err != nil {
+					t.Fatalf("save snapshot failed: %v", err)
+				}
+			}
+		}
+
+		snap, err := history.LoadSnapshotBefore(ctx, streamID, 3)
+		if err != nil {
+			t.Fatalf("load snapshot before failed: %v", err)
+		}
+		if !snap.Found || snap.Version != 2 {
+			t.Fatalf("expected snapshot at version 2, got %+v", snap)
+		}
+		snap, err = history.LoadSnapshotBefore(ctx, streamID, 1)
+		if err != nil {
+			t.Fatalf("load snapshot before failed: %v", err)
+		}
+		if snap.Found {
+			t.Fatalf("expected no snapshot at or before version 1, got %+v", snap)
+		}
+
+		if ranged, ok := s.(ges.RangeLoader); ok {
+			events, err := ranged.LoadRange(ctx, streamID, 1, 3)
+			if err != nil {
+				t.Fatalf("load range failed: %v", err)
+			}
+			if len(events) != 2 || events[0].Version != 2 || events[1].Version != 3 {
+				t.Fatalf("expected versions 2 and 3, got %+v", events)
+			}
+		}
+
+		// 1+2+3 at version 3, rebuilt from the snapshot at 2.
+		at, err := repo.LoadAt(ctx, streamID, 3)
+		if err != nil {
+			t.Fatalf("load at failed: %v", err)
+		}
+		if at.Total != 6 || at.Version() != 3 {
+			t.Fatalf("expected total 6 at version 3, got %d at %d", at.Total, at.Version())
+		}
+		if _, err := repo.LoadAt(ctx, streamID, 6); err == nil {
+			t.Fatalf("expected an error loading past the end of the stream")
+		}
+	})
+
+	t.Run("truncate before snapshot", func(t *testing.T) {
+		t.Parallel()
+		ctx := t.Context()
+		s := newStore(t)
+		truncater, ok := s.(ges.Truncater)
+		if !ok {
+			t.Skip("store does not implement Truncater")
+		}
+		repo := ges.NewRepository(s, NewCounter)
+		streamID := "Counter:truncate"
+
+		c, err := repo.Load(ctx, streamID)
+		if err != nil {
+			t.Fatalf("load failed: %v", err)
+		}
+		c.Raise(Added{N: 1})
+		c.Raise(Added{N: 2})
+		c.Raise(Added{N: 3})
+		if err := repo.Save(ctx, c, nil); err != nil {
+			t.Fatalf("save failed: %v", err)
+		}
+		if err := truncater.TruncateBefore(ctx, streamID, 3); !errors.Is(err, ges.ErrTruncateUnsafe) {
+			t.Fatalf("expected ErrTruncateUnsafe without a snapshot, got %v", err)
+		}
+		if err := s.SaveSnapshot(ctx, streamID, 2, map[string]any{"total": 3}); err != nil {
+			t.Fatalf("save snapshot failed: %v", err)
+		}
+		if err := truncater.TruncateBefore(ctx, streamID, 3); !errors.Is(err, ges.ErrTruncateUnsafe) {
+			t.Fatalf("expected ErrTruncateUnsafe past the snapshot, got %v", err)
+		}
+		if err := truncater.TruncateBefore(ctx, streamID, 2); err != nil {
+			t.Fatalf("truncate failed: %v", err)
+		}
+
+		events, version, err := s.Load(ctx, streamID, 0)
+		if err != nil {
+			t.Fatalf("load failed: %v", err)
+		}
+		if len(events) != 2 || version != 3 {
+			t.Fatalf("expected versions 2 and 3 to remain, got %d events at version %d", len(events), version)
+		}
+
+		loaded, err := repo.Load(ctx, streamID)
+		if err != nil {
+			t.Fatalf("load failed: %v", err)
+		}
+		if loaded.Total != 6 || loaded.Version() != 3 {
+			t.Fatalf("expected total 6 at version 3, got %d at %d", loaded.Total, loaded.Version())
+		}
+		loaded.Raise(Added{N: 4})
+		if err := repo.Save(ctx, loaded, nil); err != nil {
+			t.Fatalf("save after truncate failed: %v", err)
+		}
+	})
+
+	t.Run("repository snapshot policy", func(t *testing.T) {
+		t.Parallel()
+		ctx := t.Context()
+		s := newStore(t)
+		var seen []ges.ReplayStats
+		policy := ges.SnapshotPolicyFunc(func(stats ges.ReplayStats) bool {
+			seen = append(seen, stats)
+			return stats.EventsSinceSnapshot >= 3
+		})
+		repo := ges.NewRepository(s, NewCounter, ges.WithSnapshotPolicy(policy))
+		streamID := "Counter:repository-snapshot-policy"
+
+		for i := range 3 {
+			c, err := repo.Load(ctx, streamID)
+			if err != nil {
+				t.Fatalf("load failed: %v", err)
+			}
+			c.Raise(Added{N: i + 1})
+			c.Raise(Added{N: i + 1})
+			if err := repo.Save(ctx, c, nil); err != nil {
+				t.Fatalf("save failed: %v", err)
+			}
+		}
+
+		if len(seen) != 3 {
+			t.Fatalf("expected the policy to be consulted 3 times, got %d", len(seen))
+		}
+		// 2 new events, then 2 replayed + 2 new (snapshot at 4), then 0 replayed + 2 new.
+		for i, want := range []int{2, 4, 2} {
+			if seen[i].EventsSinceSnapshot != want {
+				t.Fatalf("consultation %d: expected %d events since snapshot, got %+v", i, want, seen[i])
+			}
+		}
+		snap, err := s.LoadSnapshot(ctx, streamID)
+		if err != nil {
+			t.Fatalf("load snapshot failed: %v", err)
+		}
+		if !snap.Found || snap.Version != 4 {
+			t.Fatalf("expected snapshot at version 4, got %+v", snap)
+		}
+	})
+
+	t.Run("export/import", func(t *testing.T) {
+		t.Parallel()
+		ctx := t.Context()
+		s := newStore(t)
+		if _, ok := s.(ges.RawLoader); !ok {
+			t.Skip("store does not implement RawLoader")
+		}
+		if _, ok := s.(ges.RawAppender); !ok {
+			t.Skip("store does not implement RawAppender")
+		}
+		ea, ok := s.(ges.EnvelopeAppender)
+		if !ok {
+			t.Skip("store does not implement EnvelopeAppender")
+		}
+
+		at := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+		if _, err := ea.AppendEnvelopes(ctx, "Stream:export", 0, []ges.Envelope{
+			{Event: Opened{ID: "x"}, OccurredAt: at},
+			{Event: Added{N: 7}, Headers: map[string]string{"schema": "v2"}, OccurredAt: at},
+		}, ges.Metadata{"user_id": "u1"}); err != nil {
+			t.Fatalf("append failed: %v", err)
+		}
+
+		var buf bytes.Buffer
+		if err := ges.ExportStream(ctx, s, "Stream:export", &buf); err != nil {
+			t.Fatalf("export failed: %v", err)
+		}
+		if n := bytes.Count(buf.Bytes(), []byte("\n")); n != 2 {
+			t.Fatalf("expected 2 NDJSON lines, got %d:\n%s", n, buf.String())
+		}
+
+		// Import under another stream ID so the test also works on shared databases.
+		dump := bytes.ReplaceAll(buf.Bytes(), []byte(`"Stream:export"`), []byte(`"Stream:import"`))
+		if err := ges.ImportStream(ctx, s, bytes.NewReader(dump)); err != nil {
+			t.Fatalf("import failed: %v", err)
+		}
+		if err := ges.ImportStream(ctx, s, bytes.NewReader(dump)); !errors.Is(err, ges.ErrVersionConflict) {
+			t.Fatalf("expected re-import to conflict, got %v", err)
+		}
+
+		var got []ges.StoredEvent
+		for ev, err := range s.(ges.StreamIterator).LoadIter(ctx, "Stream:import", 0) {
+			if err != nil {
+				t.Fatalf("load failed: %v", err)
+			}
+			got = append(got, ev)
+		}
+		if len(got) != 2 || got[1].Version != 2 || got[1].Payload != (Added{N: 7}) {
+			t.Fatalf("unexpected imported events: %+v", got)
+		}
+		if got[1].Metadata["user_id"] != "u1" || got[1].Headers["schema"] != "v2" || !got[1].OccurredAt.Equal(at) {
+			t.Fatalf("expected metadata, headers and time to survive, got %+v", got[1])
+		}
+	})
+
+	t.Run("append raw events", func(t *testing.T) {
+		t.Parallel()
+		ctx := t.Context()
+		s := newStore(t)
+		if _, ok := s.(ges.RawAppender); !ok {
+			t.Skip("store does not implement RawAppender")
+		}
+		streamID := "Stream:append-raw"
+
+		v, err := ges.AppendRaw(ctx, s, streamID, 0, []ges.RawEvent{
+			{Type: "Opened", Payload: []byte(`{"ID":"raw"}`)},
+			{Type: "Added", Payload: []byte(`{"N":7}`), Headers: map[string]string{"origin": "relay"}},
+		}, ges.Metadata{"user_id": "u1"})
+		if err != nil || v != 2 {
+			t.Fatalf("expected version 2, got %d, %v", v, err)
+		}
+
+		var got []ges.StoredEvent
+		for ev, err := range s.(ges.StreamIterator).LoadIter(ctx, streamID, 0) {
+			if err != nil {
+				t.Fatalf("load failed: %v", err)
+			}
+			got = append(got, ev)
+		}
+		if len(got) != 2 || got[0].Payload != (Opened{ID: "raw"}) || got[1].Payload != (Added{N: 7}) {
+			t.Fatalf("unexpected events: %+v", got)
+		}
+		if got[1].Metadata["user_id"] != "u1" || got[1].Headers["origin"] != "relay" {
+			t.Fatalf("expected metadata and headers to be stored, got %+v", got[1])
+		}
+	})
+
+	t.Run("content type", func(t *testing.T) {
+		t.Parallel()
+		ctx := t.Context()
+		s := newStore(t)
+		raw, ok := s.(ges.RawAppender)
+		if !ok {
+			t.Skip("store does not implement RawAppender")
+		}
+		streamID := "Stream:content-type"
+
+		// A row written by the legacy codec, as left behind by a codec migration.
+		if _, err := raw.AppendRaw(ctx, streamID, 0, []ges.StoredEvent{
+			{Type: "Added", ContentType: LegacyContentType, Payload: []byte(`{"amount":4}`)},
+		}); err != nil {
+			t.Fatalf("append raw failed: %v", err)
+		}
+		if _, err := s.Append(ctx, streamID, 1, []ges.Event{Added{N: 5}}, nil); err != nil {
+			t.Fatalf("append failed: %v", err)
+		}
+
+		events, _, err := s.Load(ctx, streamID, 0)
+		if err != nil {
+			t.Fatalf("load failed: %v", err)
+		}
+		if len(events) != 2 || events[0] != (Added{N: 4}) || events[1] != (Added{N: 5}) {
+			t.Fatalf("expected each row decoded by its own codec, got %+v", events)
+		}
+
+		if loader, ok := s.(ges.RawLoader); ok {
+			rawEvents, err := loader.LoadRaw(ctx, streamID, 1)
+			if err != nil {
+				t.Fatalf("load raw failed: %v", err)
+			}
+			if len(rawEvents) != 1 || rawEvents[0].ContentType != "application/json" {
+				t.Fatalf("expected the new event recorded as application/json, got %+v", rawEvents)
+			}
+		}
+	})
+
+	t.Run("subscription", func(t *testing.T) {
+		t.Parallel()
+		s := newStore(t)
+		reader, ok := s.(ges.GlobalReader)
+		if !ok {
+			t.Skip("store does not implement GlobalReader")
+		}
+		streamID := "Stream:subscription"
+		name := "storetest-subscription"
+
+		ctx, cancel := context.WithTimeout(t.Context(), 10*time.Second)
+		defer cancel()
+
+		var seen []int64
+		var opts []ges.SubscriptionOption
+		opts = append(opts, ges.WithPollInterval(5*time.Millisecond, 20*time.Millisecond))
+		cp, hasCheckpointer := s.(ges.Checkpointer)
+		if hasCheckpointer {
+			opts = append(opts, ges.WithCheckpointer(cp))
+		}
+		sub := ges.NewSubscription(name, reader, func(_ context.Context, ev ges.StoredEvent) error {
+			if ev.StreamID != streamID {
+				return nil
+			}
+			seen = append(seen, ev.Version)
+			if len(seen) == 3 {
+				cancel()
+			}
+			return nil
+		}, opts...)
+
+		done := make(chan error, 1)
+		go func() { done <- sub.Run(ctx) }()
+
+		// Append while the subscription is polling so it has to pick up new events.
+		if _, err := s.Append(ctx, streamID, 0, []ges.Event{Opened{ID: "sub"}}, nil); err != nil {
+			t.Fatalf("append failed: %v", err)
+		}
+		if _, err := s.Append(ctx, streamID, 1, []ges.Event{Added{N: 1}, Added{N: 2}}, nil); err != nil {
+			t.Fatalf("append failed: %v", err)
+		}
+
+		if err := <-done; !errors.Is(err, context.Canceled) {
+			t.Fatalf("expected context.Canceled, got %v", err)
+		}
+		if fmt.Sprint(seen) != "[1 2 3]" {
+			t.Fatalf("expected versions [1 2 3], got %v", seen)
+		}
+
+		if hasCheckpointer {
+			position, err := cp.LoadCheckpoint(t.Context(), name)
+			if err != nil {
+				t.Fatalf("load checkpoint failed: %v", err)
+			}
+			if position == 0 {
+				t.Fatalf("expected checkpoint to advance")
+			}
+		}
+	})
+
+	t.Run("subscription pages", func(t *testing.T) {
+		t.Parallel()
+		s := newStore(t)
+		reader, ok := s.(ges.GlobalReader)
+		if !ok {
+			t.Skip("store does not implement GlobalReader")
+		}
+		streamID := "Stream:subscription-pages"
+
+		ctx, cancel := context.WithTimeout(t.Context(), 10*time.Second)
+		defer cancel()
+
+		if _, err := s.Append(ctx, streamID, 0, []ges.Event{
+			Opened{ID: "pages"}, Added{N: 1}, Added{N: 2}, Added{N: 3}, Closed{Reason: "done"},
+		}, nil); err != nil {
+			t.Fatalf("append failed: %v", err)
+		}
+
+		limited := &limitRecorder{GlobalReader: reader}
+		var seen []int64
+		sub := ges.NewSubscription("storetest-subscription-pages", limited, func(_ context.Context, ev ges.StoredEvent) error {
+			seen = append(seen, ev.Version)
+			if len(seen) == 5 {
+				cancel()
+			}
+			return nil
+		},
+			ges.WithPageSize(2),
+			ges.WithPollInterval(time.Millisecond, 5*time.Millisecond),
+			ges.WithReadOptions(ges.WithStream(streamID)),
+		)
+
+		if err := sub.Run(ctx); !errors.Is(err, context.Canceled) {
+			t.Fatalf("expected context.Canceled, got %v", err)
+		}
+		if fmt.Sprint(seen) != "[1 2 3 4 5]" {
+			t.Fatalf("expected versions [1 2 3 4 5], got %v", seen)
+		}
+		if limited.largest != 2 {
+			t.Fatalf("expected reads of at most 2 events, got a limit of %d", limited.largest)
+		}
+	})
+
+	t.Run("cursor", func(t *testing.T) {
+		t.Parallel()
+		ctx := t.Context()
+		s := newStore(t)
+		it, ok := s.(ges.StreamIterator)
+		if !ok {
+			t.Skip("store does not implement StreamIterator")
+		}
+		cp, ok := s.(ges.Checkpointer)
+		if !ok {
+			t.Skip("store does not implement Checkpointer")
+		}
+		streamID := "Stream:cursor"
+		name := "storetest-cursor"
+
+		if _, err := s.Append(ctx, streamID, 0, []ges.Event{
+			Opened{ID: "cursor"}, Added{N: 1}, Added{N: 2},
+		}, nil); err != nil {
+			t.Fatalf("append failed: %v", err)
+		}
+
+		cursor := ges.NewCursor(name, it, streamID, cp)
+		events, err := cursor.Next(ctx, 2)
+		if err != nil || len(events) != 2 || events[1].Version != 2 {
+			t.Fatalf("expected versions 1 and 2, got %+v, %v", events, err)
+		}
+
+		// A new cursor with the same name resumes after the checkpoint.
+		resumed := ges.NewCursor(name, it, streamID, cp)
+		events, err = resumed.Next(ctx, 2)
+		if err != nil || len(events) != 1 || events[0].Version != 3 {
+			t.Fatalf("expected version 3 after resuming, got %+v, %v", events, err)
+		}
+		if events, err = resumed.Next(ctx, 2); err != nil || len(events) != 0 {
+			t.Fatalf("expected the end of the stream, got %+v, %v", events, err)
+		}
+		if resumed.Version() != 3 {
+			t.Fatalf("expected the cursor at version 3, got %d", resumed.Version())
+		}
+	})
+
+	t.Run("filtered subscription checkpoints skipped events", func(t *testing.T) {
+		t.Parallel()
+		s := newStore(t)
+		reader, ok := s.(ges.GlobalReader)
+		if !ok {
+			t.Skip("store does not implement GlobalReader")
+		}
+		if _, ok := s.(ges.HeadReader); !ok {
+			t.Skip("store does not implement HeadReader")
+		}
+		cp, ok := s.(ges.Checkpointer)
+		if !ok {
+			t.Skip("store does not implement Checkpointer")
+		}
+		streamID := "Stream:filtered-subscription"
+		name := "storetest-filtered-subscription"
+
+		ctx, cancel := context.WithTimeout(t.Context(), 10*time.Second)
+		defer cancel()
+
+		if _, err := s.Append(ctx, streamID, 0, []ges.Event{
+			Opened{ID: "fs"},
+			Closed{Reason: "done"},
+			Added{N: 1},
+		}, nil); err != nil {
+			t.Fatalf("append failed: %v", err)
+		}
+		var last int64
+		for ev, err := range s.(ges.StreamIterator).LoadIter(ctx, streamID, 2) {
+			if err != nil {
+				t.Fatalf("load failed: %v", err)
+			}
+			last = ev.Position
+		}
+
+		var types []string
+		sub := ges.NewSubscription(name, reader, func(_ context.Context, ev ges.StoredEvent) error {
+			if ev.StreamID == streamID {
+				types = append(types, ev.Type)
+			}
+			return nil
+		},
+			ges.WithCheckpointer(cp),
+			ges.WithPollInterval(time.Millisecond, 5*time.Millisecond),
+			ges.WithReadOptions(ges.WithEventTypes("Closed")),
+		)
+
+		errc := make(chan error, 1)
+		go func() { errc <- sub.Run(ctx) }()
+		for {
+			position, err := cp.LoadCheckpoint(ctx, name)
+			if err != nil {
+				t.Fatalf("load checkpoint failed: %v", err)
+			}
+			if position >= last {
+				break
+			}
+			select {
+			case err := <-errc:
+				t.Fatalf("subscription stopped early: %v", err)
+			case <-ctx.Done():
+				t.Fatalf("checkpoint did not pass the skipped event: at %d, want >= %d", position, last)
+			case <-time.After(time.Millisecond):
+			}
+		}
+		cancel()
+		if err := <-errc; !errors.Is(err, context.Canceled) {
+			t.Fatalf("expected context.Canceled, got %v", err)
+		}
+		if fmt.Sprint(types) != "[Closed]" {
+			t.Fatalf("expected only the Closed event, got %v", types)
+		}
+	})
+
+	t.Run("dead letters", func(t *testing.T) {
+		t.Parallel()
+		s := newStore(t)
+		reader, ok := s.(ges.GlobalReader)
+		if !ok {
+			t.Skip("store does not implement GlobalReader")
+		}
+		dls, ok := s.(ges.DeadLetterStore)
+		if !ok {
+			t.Skip("store does not implement DeadLetterStore")
+		}
+		streamID := "Stream:dead-letters"
+		name := "storetest-dead-letters"
+
+		ctx, cancel := context.WithTimeout(t.Context(), 10*time.Second)
+		defer cancel()
+
+		errPoison := errors.New("poison")
+		poisoned := true
+		var handled []int64
+		sub := ges.NewSubscription(name, reader, func(_ context.Context, ev ges.StoredEvent) error {
+			if ev.StreamID != streamID {
+				return nil
+			}
+			if added, ok := ev.Payload.(Added); ok && added.N < 0 && poisoned {
+				return errPoison
+			}
+			handled = append(handled, ev.Version)
+			if ev.Version == 3 {
+				cancel()
+			}
+			return nil
+		},
+			ges.WithPollInterval(time.Millisecond, 5*time.Millisecond),
+			ges.WithDeadLetter(dls, 2),
+		)
+
+		if _, err := s.Append(ctx, streamID, 0, []ges.Event{
+			Opened{ID: "dl"},
+			Added{N: -1},
+			Added{N: 1},
+		}, nil); err != nil {
+			t.Fatalf("append failed: %v", err)
+		}
+
+		if err := sub.Run(ctx); !errors.Is(err, context.Canceled) {
+			t.Fatalf("expected context.Canceled, got %v", err)
+		}
+		if fmt.Sprint(handled) != "[1 3]" {
+			t.Fatalf("expected the poison event to be skipped, got %v", handled)
+		}
+
+		letters, err := dls.ListDeadLetters(t.Context(), name)
+		if err != nil {
+			t.Fatalf("list dead letters failed: %v", err)
+		}
+		if len(letters) != 1 || letters[0].Version != 2 || letters[0].Attempts != 2 || letters[0].Error != errPoison.Error() {
+			t.Fatalf("expected one dead letter for version 2, got %+v", letters)
+		}
+
+		poisoned = false
+		n, err := sub.Redrive(t.Context())
+		if err != nil {
+			t.Fatalf("redrive failed: %v", err)
+		}
+		if n != 1 || fmt.Sprint(handled) != "[1 3 2]" {
+			t.Fatalf("expected the dead letter to be redriven, got n=%d handled=%v", n, handled)
+		}
+		if letters, _ := dls.ListDeadLetters(t.Context(), name); len(letters) != 0 {
+			t.Fatalf("expected no dead letters after redrive, got %+v", letters)
+		}
+	})
+}
+
+// RunSnapshots executes the snapshot tests of Run against a SnapshotStore, for
+// stores that keep snapshots only. Run includes them.
+func RunSnapshots(t *testing.T, newStore SnapshotFactory) {
+	t.Run("snapshot metadata", func(t *testing.T) {
+		t.Parallel()
+		ctx := t.Context()
+		s := newStore(t)
+		saver, ok := s.(ges.SnapshotMetadataSaver)
+		if !ok {
+			t.Skip("store does not implement SnapshotMetadataSaver")
+		}
+		streamID := "Stream:snapshot-metadata"
+
+		if err := saver.SaveSnapshotWithMeta(ctx, streamID, 3, map[string]any{"n": 3}, ges.Metadata{
+			"correlation_id": "c1",
+		}); err != nil {
+			t.Fatalf("save snapshot failed: %v", err)
+		}
+
+		snap, err := s.LoadSnapshot(ctx, streamID)
+		if err != nil {
+			t.Fatalf("load snapshot failed: %v", err)
+		}
+		if !snap.Found || snap.Version != 3 {
+			t.Fatalf("expected snapshot at version 3, got %+v", snap)
+		}
+		if snap.Metadata["correlation_id"] != "c1" {
+			t.Fatalf("expected correlation_id c1, got %v", snap.Metadata)
+		}
+
+		// The plain SaveSnapshot keeps working and records no metadata.
+		if err := s.SaveSnapshot(ctx, streamID, 4, map[string]any{"n": 4}); err != nil {
+			t.Fatalf("save snapshot failed: %v", err)
+		}
+		snap, err = s.LoadSnapshot(ctx, streamID)
+		if err != nil {
+			t.Fatalf("load snapshot failed: %v", err)
+		}
+		if snap.Version != 4 || len(snap.Metadata) != 0 {
+			t.Fatalf("expected version 4 without metadata, got %+v", snap)
+		}
+	})
+
+	t.Run("snapshot never regresses", func(t *testing.T) {
+		t.Parallel()
+		ctx := t.Context()
+		s := newStore(t)
+		streamID := "Stream:snapshot-guard"
+
+		if err := s.SaveSnapshot(ctx, streamID, 5, map[string]any{"n": 5}); err != nil {
+			t.Fatalf("save snapshot failed: %v", err)
+		}
+		// Slower writers that snapshotted an older version must not win.
+		var wg sync.WaitGroup
+		errs := make(chan error, 4)
+		for range 4 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				errs <- s.SaveSnapshot(ctx, streamID, 3, map[string]any{"n": 3})
+			}()
+		}
+		wg.Wait()
+		close(errs)
+		for err := range errs {
+			if err != nil {
+				t.Fatalf("save snapshot failed: %v", err)
+			}
+		}
+
+		snap, err := s.LoadSnapshot(ctx, streamID)
+		if err != nil {
+			t.Fatalf("load snapshot failed: %v", err)
+		}
+		if snap.Version != 5 {
+			t.Fatalf("expected the snapshot at version 5 to survive, got version %d", snap.Version)
+		}
+	})
+
+	t.Run("snapshot keeps large integers exact", func(t *testing.T) {
+		t.Parallel()
+		ctx := t.Context()
+		s := newStore(t)
+		streamID := "Stream:snapshot-precision"
+
+		type balanceState struct{ Balance int64 }
+		const balance = 9007199254740993 // 2^53 + 1, not representable as float64
+
+		if err := s.SaveSnapshot(ctx, streamID, 1, balanceState{Balance: balance}); err != nil {
+			t.Fatalf("save snapshot failed: %v", err)
+		}
+		snap, err := s.LoadSnapshot(ctx, streamID)
+		if err != nil {
+			t.Fatalf("load snapshot failed: %v", err)
+		}
+		state, err := ges.DecodeState[balanceState](snap.State)
+		if err != nil {
+			t.Fatalf("decode state failed: %v", err)
+		}
+		if state.Balance != balance {
+			t.Fatalf("expected balance %d, got %d", int64(balance), state.Balance)
+		}
+	})
+}
+
+// RunDedupKeys executes the tests of ges.Deduplicated events. newStore must return
+// stores that honor dedup keys, such as those created with WithDedupKeys.
+func RunDedupKeys(t *testing.T, newStore Factory) {
+	t.Run("dedup keys", func(t *testing.T) {
+		t.Parallel()
+		ctx := t.Context()
+		s := newStore(t)
+		streamID := "Stream:dedup"
+
+		version, err := s.Append(ctx, streamID, 0, []ges.Event{Opened{ID: "d"}, Paid{Ref: "p1", Amount: 5}}, nil)
+		if err != nil || version != 2 {
+			t.Fatalf("first append: got %d, %v", version, err)
+		}
+		// A retry with a stale expected version records nothing and succeeds.
+		if version, err := s.Append(ctx, streamID, 0, []ges.Event{Paid{Ref: "p1", Amount: 5}}, nil); err != nil || version != 2 {
+			t.Fatalf("expected the duplicate to be dropped at version 2, got %d, %v", version, err)
+		}
+		// Recorded events are dropped from a batch; the rest is appended.
+		if version, err := s.Append(ctx, streamID, 2, []ges.Event{Paid{Ref: "p1", Amount: 5}, Paid{Ref: "p2", Amount: 7}}, nil); err != nil || version != 3 {
+			t.Fatalf("expected only p2 to be appended, got %d, %v", version, err)
+		}
+		if _, err := s.Append(ctx, streamID, 3, []ges.Event{Paid{Ref: "p2", Amount: 8}}, nil); !errors.Is(err, ges.ErrDuplicateKey) {
+			t.Fatalf("expected ErrDuplicateKey for a different payload, got %v", err)
+		}
+		// Repeated keys within one batch count too.
+		if _, err := s.Append(ctx, streamID, 3, []ges.Event{Paid{Ref: "p3", Amount: 1}, Paid{Ref: "p3", Amount: 2}}, nil); !errors.Is(err, ges.ErrDuplicateKey) {
+			t.Fatalf("expected ErrDuplicateKey within the batch, got %v", err)
+		}
+		if events, _, err := s.Load(ctx, streamID, 0); err != nil || len(events) != 3 {
+			t.Fatalf("expected 3 events, got %v, %v", events, err)
+		}
+
+		// Truncated events no longer count as recorded.
+		truncater, ok := s.(ges.Truncater)
+		if !ok {
+			return
+		}
+		if err := s.SaveSnapshot(ctx, streamID, 3, map[string]any{"paid": 12}); err != nil {
+			t.Fatalf("save snapshot failed: %v", err)
+		}
+		if err := truncater.TruncateBefore(ctx, streamID, 3); err != nil {
+			t.Fatalf("truncate failed: %v", err)
+		}
+		if version, err := s.Append(ctx, streamID, 3, []ges.Event{Paid{Ref: "p1", Amount: 9}}, nil); err != nil || version != 4 {
+			t.Fatalf("expected p1 to be appended again after truncation, got %d, %v", version, err)
+		}
+	})
+}
+
+// RunClock executes the tests of store clocks. newStore must return stores that take
+// the times they record from a clock always returning now.
+func RunClock(t *testing.T, newStore Factory, now time.Time) {
+	t.Run("clock", func(t *testing.T) {
+		t.Parallel()
+		ctx := t.Context()
+		s := newStore(t)
+		it, ok := s.(ges.StreamIterator)
+		if !ok {
+			t.Skip("store does not implement StreamIterator")
+		}
+		streamID := "Stream:clock"
+
+		if _, err := s.Append(ctx, streamID, 0, []ges.Event{Opened{ID: "1"}}, nil); err != nil {
+			t.Fatalf("append failed: %v", err)
+		}
+		for ev, err := range it.LoadIter(ctx, streamID, 0) {
+			if err != nil {
+				t.Fatalf("load failed: %v", err)
+			}
+			if !ev.RecordedAt.Equal(now) || !ev.OccurredAt.Equal(now) {
+				t.Fatalf("expected times from the clock, got recorded %v, occurred %v", ev.RecordedAt, ev.OccurredAt)
+			}
+		}
+
+		if err := s.SaveSnapshot(ctx, streamID, 1, map[string]any{"n": 1}); err != nil {
+			t.Fatalf("save snapshot failed: %v", err)
+		}
+		snap, err := s.LoadSnapshot(ctx, streamID)
+		if err != nil {
+			t.Fatalf("load snapshot failed: %v", err)
+		}
+		if !snap.At.Equal(now) {
+			t.Fatalf("expected snapshot time from the clock, got %v", snap.At)
+		}
+	})
+}
+
+// RunConflictEvents executes the tests of version conflicts carrying the concurrent
+// events. newStore must return stores that attach them, such as those created with
+// WithConflictEvents.
+func RunConflictEvents(t *testing.T, newStore Factory) {
+	t.Run("conflict events", func(t *testing.T) {
+		t.Parallel()
+		ctx := t.Context()
+		s := newStore(t)
+		streamID := "Stream:conflict-events"
+
+		if _, err := s.Append(ctx, streamID, 0, []ges.Event{
+			Opened{ID: "1"},
+			Added{N: 2},
+			Added{N: 3},
+		}, nil); err != nil {
+			t.Fatalf("append failed: %v", err)
+		}
+
+		_, err := s.Append(ctx, streamID, 1, []ges.Event{Added{N: 4}}, nil)
+		var conflict *ges.VersionConflictError
+		if !errors.As(err, &conflict) {
+			t.Fatalf("expected a version conflict, got %v", err)
+		}
+		if n := len(conflict.ConcurrentEvents); n != 2 {
+			t.Fatalf("expected 2 concurrent events, got %d", n)
+		}
+		if ev := conflict.ConcurrentEvents[0]; ev.Version != 2 || ev.Payload != (Added{N: 2}) {
+			t.Fatalf("unexpected concurrent event %+v", ev)
+		}
+	})
+}
+
+// RunSchemaRegistry executes the tests of payload schemas. newStore must return
+// stores that validate payloads against Schemas, such as those created with
+// WithSchemaRegistry.
+func RunSchemaRegistry(t *testing.T, newStore Factory) {
+	t.Run("schema registry", func(t *testing.T) {
+		t.Parallel()
+		ctx := t.Context()
+		s := newStore(t)
+		streamID := "Stream:schema"
+
+		_, err := s.Append(ctx, streamID, 0, []ges.Event{Opened{ID: "s"}, Added{N: -1}}, nil)
+		if !errors.Is(err, ges.ErrSchemaViolation) || !errors.Is(err, ErrNegative) {
+			t.Fatalf("expected a schema violation, got %v", err)
+		}
+		if events, _, err := s.Load(ctx, streamID, 0); err != nil || len(events) != 0 {
+			t.Fatalf("expected the batch to be rejected, got %v, %v", events, err)
+		}
+		if _, err := s.Append(ctx, streamID, 0, []ges.Event{Opened{ID: "s"}, Added{N: 1}}, nil); err != nil {
+			t.Fatalf("append failed: %v", err)
+		}
+	})
+
+	t.Run("schema registry exempts raw appends", func(t *testing.T) {
+		t.Parallel()
+		ctx := t.Context()
+		s := newStore(t)
+		raw, ok := s.(ges.RawAppender)
+		if !ok {
+			t.Skip("store does not implement RawAppender")
+		}
+
+		// Restored events were accepted when first appended, so they are not checked.
+		if _, err := raw.AppendRaw(ctx, "Stream:schema-raw", 0, []ges.StoredEvent{
+			{Type: "Added", ContentType: "application/json", Payload: []byte(`{"N":-1}`)},
+		}); err != nil {
+			t.Fatalf("append raw failed: %v", err)
 		}
 	})
 }
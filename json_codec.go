@@ -0,0 +1,179 @@
+package ges
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// JSONOptions tunes the codec returned by JSONCodecWith.
+type JSONOptions struct {
+	// DisallowUnknownFields makes Decode fail on fields T does not have, which
+	// surfaces producers that added a field this consumer does not know about.
+	DisallowUnknownFields bool
+	// DisableHTMLEscaping keeps <, > and & as they are instead of escaping them
+	// to < and friends.
+	DisableHTMLEscaping bool
+	// TimeLayout, if set, is the layout (see time.Layout) of the time.Time fields
+	// of T, including nested ones, instead of RFC 3339. It costs an extra pass over
+	// each payload, which also writes object keys in sorted order.
+	TimeLayout string
+}
+
+// JSONCodecWith is JSONCodec with options. Its content type is "application/json".
+func JSONCodecWith[T any](opts JSONOptions) EventCodec {
+	c := jsonOptionsCodec[T]{opts: opts}
+	if opts.TimeLayout != "" {
+		c.timePaths = timePaths(reflect.TypeFor[T](), nil, map[reflect.Type]bool{})
+	}
+	return c
+}
+
+type jsonOptionsCodec[T any] struct {
+	opts      JSONOptions
+	timePaths [][]string // JSON paths of time.Time values; "*" matches any element
+}
+
+func (jsonOptionsCodec[T]) ContentType() string { return "application/json" }
+
+func (c jsonOptionsCodec[T]) Encode(v any) ([]byte, error) {
+	b, err := c.marshal(v)
+	if err != nil || len(c.timePaths) == 0 {
+		return b, err
+	}
+	return c.rewriteTimes(b, time.RFC3339Nano, c.opts.TimeLayout)
+}
+
+func (c jsonOptionsCodec[T]) Decode(b []byte) (any, error) {
+	if len(c.timePaths) > 0 {
+		var err error
+		if b, err = c.rewriteTimes(b, c.opts.TimeLayout, time.RFC3339Nano); err != nil {
+			return nil, fmt.Errorf("ges: failed to decode json: %w", err)
+		}
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	if c.opts.DisallowUnknownFields {
+		dec.DisallowUnknownFields()
+	}
+	var v T
+	if err := dec.Decode(&v); err != nil {
+		return nil, fmt.Errorf("ges: failed to decode json: %w", err)
+	}
+	return v, nil
+}
+
+func (c jsonOptionsCodec[T]) marshal(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(!c.opts.DisableHTMLEscaping)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// rewriteTimes reformats the time values of the JSON document b from one layout to another.
+func (c jsonOptionsCodec[T]) rewriteTimes(b []byte, from, to string) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	var doc any
+	if err := dec.Decode(&doc); err != nil {
+		return nil, err
+	}
+	for _, path := range c.timePaths {
+		var err error
+		if doc, err = rewriteTime(doc, path, from, to); err != nil {
+			return nil, err
+		}
+	}
+	return c.marshal(doc)
+}
+
+func rewriteTime(doc any, path []string, from, to string) (any, error) {
+	if len(path) == 0 {
+		s, ok := doc.(string)
+		if !ok {
+			return doc, nil // null or not a time after all
+		}
+		t, err := time.Parse(from, s)
+		if err != nil {
+			return nil, err
+		}
+		return t.Format(to), nil
+	}
+	switch node := doc.(type) {
+	case map[string]any:
+		for k, v := range node {
+			if path[0] != "*" && !strings.EqualFold(path[0], k) {
+				continue
+			}
+			rewritten, err := rewriteTime(v, path[1:], from, to)
+			if err != nil {
+				return nil, err
+			}
+			node[k] = rewritten
+		}
+	case []any:
+		if path[0] != "*" {
+			return doc, nil
+		}
+		for i, v := range node {
+			rewritten, err := rewriteTime(v, path[1:], from, to)
+			if err != nil {
+				return nil, err
+			}
+			node[i] = rewritten
+		}
+	}
+	return doc, nil
+}
+
+var timeType = reflect.TypeFor[time.Time]()
+
+// timePaths returns the JSON paths under t holding time.Time values.
+func timePaths(t reflect.Type, prefix []string, seen map[reflect.Type]bool) [][]string {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == timeType {
+		return [][]string{prefix}
+	}
+	if seen[t] {
+		return nil // recursive type
+	}
+	seen[t] = true
+	defer delete(seen, t)
+
+	var out [][]string
+	switch t.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map:
+		out = timePaths(t.Elem(), append(prefix[:len(prefix):len(prefix)], "*"), seen)
+	case reflect.Struct:
+		for i := range t.NumField() {
+			f := t.Field(i)
+			if !f.IsExported() && !f.Anonymous {
+				continue
+			}
+			name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+			if name == "-" {
+				continue
+			}
+			ft := f.Type
+			for ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if f.Anonymous && name == "" && ft.Kind() == reflect.Struct && ft != timeType {
+				out = append(out, timePaths(ft, prefix, seen)...) // promoted fields
+				continue
+			}
+			if name == "" {
+				name = f.Name
+			}
+			out = append(out, timePaths(f.Type, append(prefix[:len(prefix):len(prefix)], name), seen)...)
+		}
+	}
+	return out
+}
@@ -0,0 +1,55 @@
+package ges_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mickamy/go-event-sourcing"
+)
+
+type scheduled struct {
+	Note  string      `json:"note"`
+	At    time.Time   `json:"at"`
+	Slots []time.Time `json:"slots,omitempty"`
+	Due   *time.Time  `json:"due"`
+}
+
+func TestJSONCodecWith(t *testing.T) {
+	t.Parallel()
+
+	codec := ges.JSONCodecWith[scheduled](ges.JSONOptions{
+		DisallowUnknownFields: true,
+		DisableHTMLEscaping:   true,
+		TimeLayout:            time.DateOnly,
+	})
+	at := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	in := scheduled{Note: "a < b", At: at, Slots: []time.Time{at, at.AddDate(0, 0, 1)}}
+
+	b, err := codec.Encode(in)
+	if err != nil {
+		t.Fatalf("encode failed: %v", err)
+	}
+	if want := `{"at":"2024-03-01","due":null,"note":"a < b","slots":["2024-03-01","2024-03-02"]}`; string(b) != want {
+		t.Fatalf("expected %s, got %s", want, b)
+	}
+	out, err := codec.Decode(b)
+	if err != nil {
+		t.Fatalf("decode failed: %v", err)
+	}
+	if got := out.(scheduled); got.Note != in.Note || !got.At.Equal(at) || len(got.Slots) != 2 || got.Due != nil {
+		t.Fatalf("expected %+v to round-trip, got %+v", in, got)
+	}
+
+	if _, err := codec.Decode([]byte(`{"note":"x","at":"2024-03-01","priority":1}`)); err == nil {
+		t.Fatalf("expected an unknown field to be rejected")
+	}
+
+	// encoding/json matches keys case-insensitively, so the time layout must too.
+	out, err = codec.Decode([]byte(`{"Note":"x","AT":"2024-03-01","Slots":["2024-03-02"]}`))
+	if err != nil {
+		t.Fatalf("decode failed: %v", err)
+	}
+	if got := out.(scheduled); !got.At.Equal(at) || len(got.Slots) != 1 || !got.Slots[0].Equal(at.AddDate(0, 0, 1)) {
+		t.Fatalf("expected the times under differently cased keys to decode, got %+v", got)
+	}
+}
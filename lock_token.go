@@ -0,0 +1,75 @@
+package ges
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// LockToken records the version at which an aggregate was loaded, making the
+// optimistic concurrency contract explicit for flows that hold an aggregate across
+// user think-time: LoadWithToken hands it out and SaveWithToken requires it back.
+// Its String form can travel to a client, e.g. as an ETag, and back via
+// ParseLockToken. Tokens are not signed; do not rely on them for authorization.
+type LockToken struct {
+	streamID string
+	version  int64
+}
+
+// StreamID returns the stream the token was issued for.
+func (t LockToken) StreamID() string { return t.streamID }
+
+// Version returns the version the aggregate was loaded at.
+func (t LockToken) Version() int64 { return t.version }
+
+// String returns an opaque, URL-safe encoding of the token.
+func (t LockToken) String() string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(t.version, 10) + ":" + t.streamID))
+}
+
+// ParseLockToken decodes a token produced by LockToken.String.
+func ParseLockToken(s string) (LockToken, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return LockToken{}, ErrInvalidLockToken
+	}
+	v, streamID, ok := strings.Cut(string(b), ":")
+	version, err := strconv.ParseInt(v, 10, 64)
+	if !ok || err != nil || version < 0 || streamID == "" {
+		return LockToken{}, ErrInvalidLockToken
+	}
+	return LockToken{streamID: streamID, version: version}, nil
+}
+
+// LoadWithToken is Load returning, along with the aggregate, a token recording the
+// version it was loaded at.
+func (r *Repository[A]) LoadWithToken(ctx context.Context, streamID string) (A, LockToken, error) {
+	agg, err := r.Load(ctx, streamID)
+	if err != nil {
+		return agg, LockToken{}, err
+	}
+	return agg, LockToken{streamID: streamID, version: agg.Version()}, nil
+}
+
+// SaveWithToken is Save for an aggregate whose changes were decided against the
+// state token was issued for. If the aggregate was loaded at another version, e.g.
+// because it was reloaded after the token was handed out, it returns a
+// *VersionConflictError without touching the store; its pending events are
+// discarded either way, so reload it before retrying. A token for another stream
+// is rejected with ErrInvalidLockToken.
+func (r *Repository[A]) SaveWithToken(ctx context.Context, agg A, token LockToken, md Metadata) error {
+	events, expected := agg.Flush()
+	if token.streamID != agg.StreamID() {
+		return fmt.Errorf("%w: issued for %q, used with %q", ErrInvalidLockToken, token.streamID, agg.StreamID())
+	}
+	if expected != token.version {
+		return &VersionConflictError{
+			StreamID:        agg.StreamID(),
+			ExpectedVersion: token.version,
+			ActualVersion:   expected,
+		}
+	}
+	return r.save(ctx, agg, events, expected, md)
+}
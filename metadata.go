@@ -2,12 +2,63 @@ package ges
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+)
+
+// Recommended Metadata keys. Keys are lower snake_case by convention, so metadata
+// written by different services can be queried uniformly.
+const (
+	MetadataTenantID      = "tenant_id"
+	MetadataUserID        = "user_id"
+	MetadataCorrelationID = "correlation_id"
+	MetadataCausationID   = "causation_id"
+	MetadataTraceID       = "trace_id"
 )
 
 // Metadata carries contextual information that accompanies events.
 // Typical keys include tenant_id, user_id, correlation_id, and trace_id.
 type Metadata map[string]any
 
+// Validate reports, as an error wrapping ErrInvalidMetadata, an empty key, a value
+// that cannot be encoded as JSON, or a JSON encoding of more than maxBytes bytes.
+// A maxBytes below 1 disables the size check.
+func (m Metadata) Validate(maxBytes int) error {
+	for _, k := range slices.Sorted(maps.Keys(m)) {
+		if k == "" {
+			return fmt.Errorf("%w: empty key", ErrInvalidMetadata)
+		}
+		if _, err := json.Marshal(m[k]); err != nil {
+			return fmt.Errorf("%w: value of %q cannot be encoded as JSON: %w", ErrInvalidMetadata, k, err)
+		}
+	}
+	if maxBytes <= 0 {
+		return nil
+	}
+	b, err := json.Marshal(m)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidMetadata, err)
+	}
+	if len(b) > maxBytes {
+		return fmt.Errorf("%w: %d bytes encoded, limit is %d", ErrInvalidMetadata, len(b), maxBytes)
+	}
+	return nil
+}
+
+// MetadataValidator returns an AppendInterceptor that rejects metadata failing
+// Metadata.Validate(maxBytes), so bad metadata is reported before the store
+// starts a write instead of failing while encoding it.
+func MetadataValidator(maxBytes int) AppendInterceptor {
+	return func(_ context.Context, streamID string, _ []Event, md Metadata) error {
+		if err := md.Validate(maxBytes); err != nil {
+			return fmt.Errorf("ges: append to %s: %w", streamID, err)
+		}
+		return nil
+	}
+}
+
 // Merge returns a new Metadata that combines the receiver with the given maps.
 // It is safe to call on a nil receiver. Later maps take precedence over earlier ones.
 // The receiver is not modified.
@@ -32,3 +83,28 @@ func (m *Metadata) Merge(ms ...Metadata) Metadata {
 // Applications can supply their own extractor that knows about
 // private context keys (tenant_id, user_id, correlation_id, trace_id, etc.).
 type MetadataExtractor func(ctx context.Context) Metadata
+
+// metadataKey is the context key under which WithMetadata stores Metadata.
+type metadataKey struct{}
+
+// WithMetadata returns a copy of ctx carrying md, merged over any Metadata already
+// attached to ctx (md wins on conflicting keys). Middleware can use it to attach
+// request-scoped values such as correlation_id or tenant_id once; stores configured
+// with ContextMetadata as their extractor then record them on every Append.
+func WithMetadata(ctx context.Context, md Metadata) context.Context {
+	prev, _ := ctx.Value(metadataKey{}).(Metadata)
+	return context.WithValue(ctx, metadataKey{}, prev.Merge(md))
+}
+
+// ContextMetadata is a MetadataExtractor returning a copy of the Metadata attached
+// with WithMetadata, or nil if there is none. Metadata passed explicitly to Append
+// still takes precedence over it.
+func ContextMetadata(ctx context.Context) Metadata {
+	md, ok := ctx.Value(metadataKey{}).(Metadata)
+	if !ok {
+		return nil
+	}
+	return md.Merge()
+}
+
+var _ MetadataExtractor = ContextMetadata
@@ -0,0 +1,44 @@
+package ges_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/mickamy/go-event-sourcing"
+)
+
+func TestMetadata_Validate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		md      ges.Metadata
+		wantErr bool
+	}{
+		{"nil", nil, false},
+		{"valid", ges.Metadata{ges.MetadataUserID: "u1", "attempt": 2}, false},
+		{"empty key", ges.Metadata{"": "x"}, true},
+		{"unencodable value", ges.Metadata{"callback": func() {}}, true},
+		{"too large", ges.Metadata{"note": strings.Repeat("x", 64)}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			err := tt.md.Validate(64)
+			if tt.wantErr != errors.Is(err, ges.ErrInvalidMetadata) {
+				t.Fatalf("Validate(%v) = %v, want error: %v", tt.md, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMetadataValidator(t *testing.T) {
+	t.Parallel()
+
+	validate := ges.MetadataValidator(0)
+	err := validate(t.Context(), "Stream:1", nil, ges.Metadata{"callback": func() {}})
+	if !errors.Is(err, ges.ErrInvalidMetadata) || !strings.Contains(err.Error(), `"callback"`) {
+		t.Fatalf("expected an error naming the bad key, got %v", err)
+	}
+}
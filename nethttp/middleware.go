@@ -0,0 +1,81 @@
+// Package nethttp provides net/http middleware that tags events appended while
+// serving a request with its correlation and request IDs.
+//
+// The middleware attaches the IDs with ges.WithMetadata, so they are recorded by
+// stores configured with ges.ContextMetadata as their metadata extractor:
+//
+//	store := pgx.NewEventStore(pool, pgx.WithMetadataExtractor(ges.ContextMetadata))
+//	r.Use(nethttp.Middleware())
+package nethttp
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+
+	"github.com/mickamy/go-event-sourcing"
+)
+
+// Request headers read (and echoed on the response), and the ges.Metadata keys
+// the IDs are stored under.
+const (
+	CorrelationIDHeader = "X-Correlation-ID"
+	RequestIDHeader     = "X-Request-ID"
+
+	CorrelationIDKey = ges.MetadataCorrelationID
+	RequestIDKey     = "request_id"
+)
+
+// Option configures Middleware.
+type Option func(*config)
+
+type config struct {
+	newID func() string
+}
+
+// WithIDGenerator sets how missing IDs are generated. The default is a random
+// 128-bit hex string.
+func WithIDGenerator(newID func() string) Option {
+	return func(c *config) { c.newID = newID }
+}
+
+// Middleware returns middleware that reads X-Request-ID and X-Correlation-ID from
+// the request, generating any that are missing, stores them in the request
+// context's ges.Metadata and sets both headers on the response.
+//
+// A new request ID is generated per hop when the client sent none; a request
+// without a correlation ID starts a new correlation using its request ID.
+func Middleware(opts ...Option) func(http.Handler) http.Handler {
+	c := config{newID: randomID}
+	for _, opt := range opts {
+		opt(&c)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requestID := r.Header.Get(RequestIDHeader)
+			if requestID == "" {
+				requestID = c.newID()
+			}
+			correlationID := r.Header.Get(CorrelationIDHeader)
+			if correlationID == "" {
+				correlationID = requestID
+			}
+
+			w.Header().Set(RequestIDHeader, requestID)
+			w.Header().Set(CorrelationIDHeader, correlationID)
+
+			ctx := ges.WithMetadata(r.Context(), ges.Metadata{
+				CorrelationIDKey: correlationID,
+				RequestIDKey:     requestID,
+			})
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+func randomID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
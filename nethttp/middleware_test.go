@@ -0,0 +1,48 @@
+package nethttp_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mickamy/go-event-sourcing"
+	"github.com/mickamy/go-event-sourcing/nethttp"
+)
+
+func TestMiddleware(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name            string
+		header          http.Header
+		wantCorrelation string
+		wantRequest     string
+	}{
+		{"both given", http.Header{"X-Correlation-Id": {"c1"}, "X-Request-Id": {"r1"}}, "c1", "r1"},
+		{"request only", http.Header{"X-Request-Id": {"r1"}}, "r1", "r1"},
+		{"none", http.Header{}, "generated", "generated"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var got ges.Metadata
+			h := nethttp.Middleware(nethttp.WithIDGenerator(func() string { return "generated" }))(
+				http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+					got = ges.ContextMetadata(r.Context())
+				}),
+			)
+			req := httptest.NewRequest(http.MethodPost, "/", nil)
+			req.Header = tt.header
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			if got["correlation_id"] != tt.wantCorrelation || got["request_id"] != tt.wantRequest {
+				t.Fatalf("unexpected metadata %v", got)
+			}
+			if rec.Header().Get("X-Correlation-ID") != tt.wantCorrelation || rec.Header().Get("X-Request-ID") != tt.wantRequest {
+				t.Fatalf("unexpected response headers %v", rec.Header())
+			}
+		})
+	}
+}
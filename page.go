@@ -0,0 +1,114 @@
+package ges
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"maps"
+	"slices"
+)
+
+// ErrInvalidToken is returned when a continuation token is malformed or was issued
+// for a different filter than the one it is used with.
+var ErrInvalidToken = fmt.Errorf("ges: invalid continuation token")
+
+// tokenVersion is the first byte of every continuation token.
+const tokenVersion = 1
+
+// Page is a page of events read with ReadPage or ReadCategory.
+type Page struct {
+	Events []StoredEvent
+	// NextToken continues after the last event of the page. It is set even when
+	// the page is empty, so a consumer that has caught up can keep polling with it.
+	NextToken string
+}
+
+// ReadPage reads up to limit events after token, which is either empty (start of
+// the store) or a Page.NextToken obtained with the same options.
+//
+// Tokens are opaque to callers: they encode a global position together with a
+// fingerprint of the filter, so a token cannot be replayed against a different
+// filter. They are not signed; do not rely on them for authorization.
+func ReadPage(ctx context.Context, reader GlobalReader, token string, limit int, opts ...ReadOption) (Page, error) {
+	fingerprint := NewReadFilter(opts...).fingerprint()
+
+	var position int64
+	if token != "" {
+		p, err := decodeToken(token, fingerprint)
+		if err != nil {
+			return Page{}, err
+		}
+		position = p
+	}
+
+	events, err := reader.ReadAll(ctx, position, limit, opts...)
+	if err != nil {
+		return Page{}, err
+	}
+	if n := len(events); n > 0 {
+		position = events[n-1].Position
+	}
+	return Page{
+		Events:    events,
+		NextToken: encodeToken(position, fingerprint),
+	}, nil
+}
+
+// ReadByMetadata reads up to limit events after fromPosition whose metadata has key
+// set to value (see WithMetadataValue).
+func ReadByMetadata(ctx context.Context, reader GlobalReader, key, value string, fromPosition int64, limit int, opts ...ReadOption) ([]StoredEvent, error) {
+	return reader.ReadAll(ctx, fromPosition, limit, append(slices.Clip(opts), WithMetadataValue(key, value))...)
+}
+
+// ReadCategory is ReadPage restricted to the streams of category (see WithCategory).
+func ReadCategory(ctx context.Context, reader GlobalReader, category, token string, limit int, opts ...ReadOption) (Page, error) {
+	return ReadPage(ctx, reader, token, limit, append(slices.Clip(opts), WithCategory(category))...)
+}
+
+// fingerprint identifies the filter independently of option order.
+func (f ReadFilter) fingerprint() uint64 {
+	types := slices.Compact(slices.Sorted(slices.Values(f.Types)))
+	h := fnv.New64a()
+	for _, t := range types {
+		_, _ = h.Write([]byte(t))
+		_, _ = h.Write([]byte{0})
+	}
+	_, _ = h.Write([]byte{1})
+	_, _ = h.Write([]byte(f.Category))
+	if f.StreamID != "" {
+		_, _ = h.Write([]byte{1})
+		_, _ = h.Write([]byte(f.StreamID))
+	}
+	for _, key := range slices.Sorted(maps.Keys(f.Metadata)) {
+		_, _ = h.Write([]byte{0})
+		_, _ = h.Write([]byte(key))
+		_, _ = h.Write([]byte{0})
+		_, _ = h.Write([]byte(f.Metadata[key]))
+	}
+	return h.Sum64()
+}
+
+func encodeToken(position int64, fingerprint uint64) string {
+	b := make([]byte, 0, 1+binary.MaxVarintLen64+8)
+	b = append(b, tokenVersion)
+	b = binary.AppendUvarint(b, uint64(position))
+	b = binary.BigEndian.AppendUint64(b, fingerprint)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeToken(token string, fingerprint uint64) (int64, error) {
+	b, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil || len(b) < 1+1+8 || b[0] != tokenVersion {
+		return 0, ErrInvalidToken
+	}
+	position, n := binary.Uvarint(b[1:])
+	if n <= 0 || len(b) != 1+n+8 || position > 1<<63-1 {
+		return 0, ErrInvalidToken
+	}
+	if binary.BigEndian.Uint64(b[1+n:]) != fingerprint {
+		return 0, fmt.Errorf("%w: issued for a different filter", ErrInvalidToken)
+	}
+	return int64(position), nil
+}
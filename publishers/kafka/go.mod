@@ -0,0 +1,15 @@
+module github.com/mickamy/go-event-sourcing/publishers/kafka
+
+go 1.24.0
+
+replace github.com/mickamy/go-event-sourcing => ../..
+
+require (
+	github.com/mickamy/go-event-sourcing v0.0.0
+	github.com/segmentio/kafka-go v0.4.50
+)
+
+require (
+	github.com/klauspost/compress v1.15.9 // indirect
+	github.com/pierrec/lz4/v4 v4.1.15 // indirect
+)
@@ -0,0 +1,243 @@
+// Package kafka publishes events to Kafka with github.com/segmentio/kafka-go.
+//
+// Messages are keyed by stream ID, so with a key-hashing balancer (the kafka-go
+// default is round-robin; use kafka.Hash or kafka.Murmur2Balancer) all events of a
+// stream land in the same partition and keep their order. Topics are chosen per
+// stream category.
+//
+// Run a Publisher as the handler of a ges.Subscription, or call Drain in a loop to
+// publish in batches. Either way the checkpoint only advances past events Kafka has
+// acknowledged, so delivery is at-least-once. The kafka.Writer must be synchronous
+// (Async false), and should require acknowledgement from all in-sync replicas
+// (RequiredAcks kafka.RequireAll), otherwise an acknowledged write may still be lost.
+// Leave its Topic empty: the topic is set on every message.
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/mickamy/go-event-sourcing"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// Headers set on every published message, next to the event's own headers.
+const (
+	EventIDHeader   = "Ges-Event-Id"
+	StreamIDHeader  = "Ges-Stream-Id"
+	VersionHeader   = "Ges-Version"
+	PositionHeader  = "Ges-Position"
+	EventTypeHeader = "Ges-Event-Type"
+	MetadataHeader  = "Ges-Metadata" // JSON-encoded ges.Metadata
+)
+
+// Writer is the part of *kafka.Writer used by Publisher.
+type Writer interface {
+	WriteMessages(ctx context.Context, msgs ...kafka.Message) error
+}
+
+// Encoder turns an event into a message value.
+type Encoder func(ev ges.StoredEvent) ([]byte, error)
+
+// JSONEncoder marshals the decoded payload with encoding/json.
+func JSONEncoder(ev ges.StoredEvent) ([]byte, error) {
+	return json.Marshal(ev.Payload)
+}
+
+// Option configures a Publisher.
+type Option func(*Publisher)
+
+// WithTopic publishes events of streams in category to topic.
+func WithTopic(category, topic string) Option {
+	return func(p *Publisher) { p.topics[category] = topic }
+}
+
+// WithDefaultTopic sets the topic of categories without a WithTopic mapping. The
+// default is "events".
+func WithDefaultTopic(topic string) Option {
+	return func(p *Publisher) { p.defaultTopic = topic }
+}
+
+// WithEncoder sets how message values are produced. The default is JSONEncoder.
+func WithEncoder(enc Encoder) Option {
+	return func(p *Publisher) { p.encode = enc }
+}
+
+// Publisher publishes events to Kafka.
+type Publisher struct {
+	w            Writer
+	topics       map[string]string // category → topic
+	defaultTopic string
+	encode       Encoder
+}
+
+// NewPublisher creates a Publisher writing with w.
+func NewPublisher(w Writer, opts ...Option) *Publisher {
+	p := &Publisher{
+		w:            w,
+		topics:       map[string]string{},
+		defaultTopic: "events",
+		encode:       JSONEncoder,
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// Topic returns the topic ev is published to.
+func (p *Publisher) Topic(ev ges.StoredEvent) string {
+	if topic, ok := p.topics[ges.Category(ev.StreamID)]; ok {
+		return topic
+	}
+	return p.defaultTopic
+}
+
+// Handle publishes ev and returns once Kafka has acknowledged it. It has the
+// signature of ges.SubscriptionHandler.
+func (p *Publisher) Handle(ctx context.Context, ev ges.StoredEvent) error {
+	_, err := p.Publish(ctx, []ges.StoredEvent{ev})
+	return err
+}
+
+// Publish writes events and returns how many of them, counted from the start, Kafka
+// acknowledged. They are written in batches holding at most one event per stream,
+// the first of each stream, then the second, and so on, and no batch is written
+// after a failure, so a failed event is never overtaken by a later event of its
+// stream. The count stops at the first event not acknowledged, so a caller
+// resuming after it never skips an event, though it may publish again events of
+// other streams that were written.
+func (p *Publisher) Publish(ctx context.Context, events []ges.StoredEvent) (int, error) {
+	if len(events) == 0 {
+		return 0, nil
+	}
+	msgs := make([]kafka.Message, len(events))
+	for i, ev := range events {
+		msg, err := p.message(ev)
+		if err != nil {
+			return 0, err
+		}
+		msgs[i] = msg
+	}
+
+	// rounds[k] holds the indexes of the k-th event of every stream.
+	var rounds [][]int
+	seen := map[string]int{}
+	for i, ev := range events {
+		k := seen[ev.StreamID]
+		seen[ev.StreamID]++
+		if k == len(rounds) {
+			rounds = append(rounds, nil)
+		}
+		rounds[k] = append(rounds[k], i)
+	}
+
+	acked := make([]bool, len(events))
+	for _, round := range rounds {
+		batch := make([]kafka.Message, len(round))
+		for j, i := range round {
+			batch[j] = msgs[i]
+		}
+		err := p.w.WriteMessages(ctx, batch...)
+		if err == nil {
+			for _, i := range round {
+				acked[i] = true
+			}
+			continue
+		}
+		var werrs kafka.WriteErrors
+		if !errors.As(err, &werrs) || len(werrs) != len(batch) {
+			return ackedPrefix(acked), fmt.Errorf("ges-kafka: could not publish events: %w", err)
+		}
+		failed := -1
+		for j, i := range round {
+			if werrs[j] == nil {
+				acked[i] = true
+			} else if failed < 0 {
+				failed = j
+			}
+		}
+		if failed >= 0 {
+			ev := events[round[failed]]
+			return ackedPrefix(acked), fmt.Errorf("ges-kafka: could not publish %s at version %d: %w", ev.StreamID, ev.Version, werrs[failed])
+		}
+	}
+	return len(events), nil
+}
+
+// ackedPrefix returns the number of leading events acknowledged.
+func ackedPrefix(acked []bool) int {
+	for i, ok := range acked {
+		if !ok {
+			return i
+		}
+	}
+	return len(acked)
+}
+
+// Drain publishes up to limit events after the checkpoint name and saves the
+// position of the last acknowledged one. It returns the number of events the
+// checkpoint moved past; 0 with a nil error means there was nothing to publish.
+func (p *Publisher) Drain(
+	ctx context.Context,
+	reader ges.GlobalReader,
+	cp ges.Checkpointer,
+	name string,
+	limit int,
+	opts ...ges.ReadOption,
+) (int, error) {
+	from, err := cp.LoadCheckpoint(ctx, name)
+	if err != nil {
+		return 0, fmt.Errorf("ges-kafka: could not load checkpoint: %w", err)
+	}
+	events, err := reader.ReadAll(ctx, from, limit, opts...)
+	if err != nil {
+		return 0, fmt.Errorf("ges-kafka: could not read events: %w", err)
+	}
+
+	n, pubErr := p.Publish(ctx, events)
+	if n > 0 {
+		if err := cp.SaveCheckpoint(ctx, name, events[n-1].Position); err != nil {
+			return 0, fmt.Errorf("ges-kafka: could not save checkpoint: %w", err)
+		}
+	}
+	return n, pubErr
+}
+
+func (p *Publisher) message(ev ges.StoredEvent) (kafka.Message, error) {
+	value, err := p.encode(ev)
+	if err != nil {
+		return kafka.Message{}, fmt.Errorf("ges-kafka: could not encode %s at version %d: %w", ev.StreamID, ev.Version, err)
+	}
+
+	headers := make([]kafka.Header, 0, len(ev.Headers)+6)
+	for k, v := range ev.Headers {
+		headers = append(headers, kafka.Header{Key: k, Value: []byte(v)})
+	}
+	headers = append(headers,
+		kafka.Header{Key: EventIDHeader, Value: []byte(ev.ID)},
+		kafka.Header{Key: StreamIDHeader, Value: []byte(ev.StreamID)},
+		kafka.Header{Key: VersionHeader, Value: []byte(strconv.FormatInt(ev.Version, 10))},
+		kafka.Header{Key: PositionHeader, Value: []byte(strconv.FormatInt(ev.Position, 10))},
+		kafka.Header{Key: EventTypeHeader, Value: []byte(ev.Type)},
+	)
+	if len(ev.Metadata) > 0 {
+		md, err := json.Marshal(ev.Metadata)
+		if err != nil {
+			return kafka.Message{}, fmt.Errorf("ges-kafka: could not encode metadata: %w", err)
+		}
+		headers = append(headers, kafka.Header{Key: MetadataHeader, Value: md})
+	}
+
+	return kafka.Message{
+		Topic:   p.Topic(ev),
+		Key:     []byte(ev.StreamID),
+		Value:   value,
+		Headers: headers,
+		Time:    ev.OccurredAt,
+	}, nil
+}
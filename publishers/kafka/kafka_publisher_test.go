@@ -0,0 +1,155 @@
+package kafka_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/mickamy/go-event-sourcing"
+	"github.com/mickamy/go-event-sourcing/publishers/kafka"
+
+	kafkago "github.com/segmentio/kafka-go"
+)
+
+// fakeWriter records written messages and fails the messages at the indexes in failAt.
+type fakeWriter struct {
+	msgs   []kafkago.Message
+	failAt map[int]bool
+}
+
+func (f *fakeWriter) WriteMessages(_ context.Context, msgs ...kafkago.Message) error {
+	werrs := make(kafkago.WriteErrors, len(msgs))
+	for i, msg := range msgs {
+		if f.failAt[i] {
+			werrs[i] = errors.New("leader not available")
+			continue
+		}
+		f.msgs = append(f.msgs, msg)
+	}
+	if werrs.Count() > 0 {
+		return werrs
+	}
+	return nil
+}
+
+// sliceStore serves ReadAll from a fixed list of events and keeps checkpoints in a map.
+type sliceStore struct {
+	events      []ges.StoredEvent
+	checkpoints map[string]int64
+}
+
+func (s *sliceStore) ReadAll(_ context.Context, from int64, limit int, _ ...ges.ReadOption) ([]ges.StoredEvent, error) {
+	var out []ges.StoredEvent
+	for _, ev := range s.events {
+		if ev.Position > from && len(out) < limit {
+			out = append(out, ev)
+		}
+	}
+	return out, nil
+}
+
+func (s *sliceStore) LoadCheckpoint(_ context.Context, name string) (int64, error) {
+	return s.checkpoints[name], nil
+}
+
+func (s *sliceStore) SaveCheckpoint(_ context.Context, name string, position int64) error {
+	s.checkpoints[name] = position
+	return nil
+}
+
+func newStore() *sliceStore {
+	return &sliceStore{
+		events: []ges.StoredEvent{
+			{StreamID: "Account:1", Version: 1, Position: 1, Type: "Opened", Payload: map[string]string{}},
+			{StreamID: "Order:1", Version: 1, Position: 2, Type: "Placed", Payload: map[string]string{}},
+			{StreamID: "Account:1", Version: 2, Position: 3, Type: "Deposited", Payload: map[string]int{"amount": 5}},
+		},
+		checkpoints: map[string]int64{},
+	}
+}
+
+func TestPublisher_Message(t *testing.T) {
+	t.Parallel()
+
+	w := &fakeWriter{}
+	pub := kafka.NewPublisher(w, kafka.WithTopic("Order", "orders"))
+	store := newStore()
+
+	for _, ev := range store.events {
+		if err := pub.Handle(t.Context(), ev); err != nil {
+			t.Fatalf("handle failed: %v", err)
+		}
+	}
+	if len(w.msgs) != 3 {
+		t.Fatalf("expected 3 messages, got %d", len(w.msgs))
+	}
+	if w.msgs[0].Topic != "events" || w.msgs[1].Topic != "orders" {
+		t.Fatalf("unexpected topics %s, %s", w.msgs[0].Topic, w.msgs[1].Topic)
+	}
+	if string(w.msgs[2].Key) != "Account:1" || string(w.msgs[2].Value) != `{"amount":5}` {
+		t.Fatalf("unexpected message %+v", w.msgs[2])
+	}
+}
+
+func TestPublisher_DrainStopsAtFirstFailure(t *testing.T) {
+	t.Parallel()
+
+	w := &fakeWriter{failAt: map[int]bool{1: true}}
+	pub := kafka.NewPublisher(w)
+	store := newStore()
+
+	n, err := pub.Drain(t.Context(), store, store, "kafka", 10)
+	if err == nil || n != 1 {
+		t.Fatalf("expected 1 event published and an error, got %d, %v", n, err)
+	}
+	if got := store.checkpoints["kafka"]; got != 1 {
+		t.Fatalf("expected the checkpoint to stop before the failed event, got %d", got)
+	}
+
+	w.failAt = nil
+	n, err = pub.Drain(t.Context(), store, store, "kafka", 10)
+	if err != nil || n != 2 {
+		t.Fatalf("expected the remaining 2 events, got %d, %v", n, err)
+	}
+	if got := store.checkpoints["kafka"]; got != 3 {
+		t.Fatalf("expected checkpoint 3, got %d", got)
+	}
+	if n, err := pub.Drain(t.Context(), store, store, "kafka", 10); err != nil || n != 0 {
+		t.Fatalf("expected nothing left, got %d, %v", n, err)
+	}
+}
+
+func TestPublisher_PublishKeepsStreamOrder(t *testing.T) {
+	t.Parallel()
+
+	// Account:1 version 1 fails, so its version 2 must not be written ahead of it.
+	w := &fakeWriter{failAt: map[int]bool{0: true}}
+	pub := kafka.NewPublisher(w)
+	store := newStore()
+
+	n, err := pub.Publish(t.Context(), store.events)
+	if err == nil || n != 0 {
+		t.Fatalf("expected nothing acknowledged and an error, got %d, %v", n, err)
+	}
+	if len(w.msgs) != 1 || string(w.msgs[0].Key) != "Order:1" {
+		t.Fatalf("expected only Order:1 to be written, got %d messages", len(w.msgs))
+	}
+
+	w.failAt = nil
+	if n, err := pub.Publish(t.Context(), store.events); err != nil || n != 3 {
+		t.Fatalf("expected all 3 events, got %d, %v", n, err)
+	}
+	var versions []string
+	for _, msg := range w.msgs[1:] {
+		if string(msg.Key) == "Account:1" {
+			for _, h := range msg.Headers {
+				if h.Key == kafka.VersionHeader {
+					versions = append(versions, string(h.Value))
+				}
+			}
+		}
+	}
+	if len(versions) != 2 || versions[0] != "1" || versions[1] != "2" {
+		t.Fatalf("expected Account:1 in version order, got %v", versions)
+	}
+}
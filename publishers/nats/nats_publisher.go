@@ -0,0 +1,138 @@
+// Package nats publishes events to NATS JetStream.
+//
+// A Publisher sends each event to a subject derived from its stream category and
+// waits for JetStream's acknowledgement. Run it as the handler of a ges.Subscription
+// so the checkpoint only advances once the broker has stored the event:
+//
+//	pub := nats.NewPublisher(js)
+//	sub := ges.NewSubscription("nats-publisher", store, pub.Handle, ges.WithCheckpointer(store))
+//	err := sub.Run(ctx)
+//
+// Delivery is at-least-once. Every message carries the Nats-Msg-Id header, so
+// JetStream discards events republished after a crash within the stream's
+// duplicate window.
+package nats
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/mickamy/go-event-sourcing"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// Headers set on every published message, next to the event's own headers.
+const (
+	StreamIDHeader  = "Ges-Stream-Id"
+	VersionHeader   = "Ges-Version"
+	PositionHeader  = "Ges-Position"
+	EventTypeHeader = "Ges-Event-Type"
+	MetadataHeader  = "Ges-Metadata" // JSON-encoded ges.Metadata
+)
+
+// JetStream is the part of jetstream.JetStream used by Publisher.
+type JetStream interface {
+	PublishMsg(ctx context.Context, msg *nats.Msg, opts ...jetstream.PublishOpt) (*jetstream.PubAck, error)
+}
+
+// Encoder turns an event into a message body.
+type Encoder func(ev ges.StoredEvent) ([]byte, error)
+
+// JSONEncoder marshals the decoded payload with encoding/json.
+func JSONEncoder(ev ges.StoredEvent) ([]byte, error) {
+	return json.Marshal(ev.Payload)
+}
+
+// Option configures a Publisher.
+type Option func(*Publisher)
+
+// WithSubjectPrefix sets the prefix of derived subjects. The default is "events",
+// which publishes events of stream "Account:42" to "events.Account".
+func WithSubjectPrefix(prefix string) Option {
+	return func(p *Publisher) { p.prefix = prefix }
+}
+
+// WithSubject publishes events of eventType to subject instead of the derived one.
+func WithSubject(eventType, subject string) Option {
+	return func(p *Publisher) { p.subjects[eventType] = subject }
+}
+
+// WithEncoder sets how message bodies are produced. The default is JSONEncoder.
+func WithEncoder(enc Encoder) Option {
+	return func(p *Publisher) { p.encode = enc }
+}
+
+// Publisher publishes events to JetStream.
+type Publisher struct {
+	js       JetStream
+	prefix   string
+	subjects map[string]string // event type → subject
+	encode   Encoder
+}
+
+// NewPublisher creates a Publisher sending to js.
+func NewPublisher(js JetStream, opts ...Option) *Publisher {
+	p := &Publisher{
+		js:       js,
+		prefix:   "events",
+		subjects: map[string]string{},
+		encode:   JSONEncoder,
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// Subject returns the subject ev is published to: the one set with WithSubject for
+// its type, otherwise the prefix followed by the category of its stream.
+func (p *Publisher) Subject(ev ges.StoredEvent) string {
+	if subject, ok := p.subjects[ev.Type]; ok {
+		return subject
+	}
+	return p.prefix + "." + ges.Category(ev.StreamID)
+}
+
+// Handle publishes ev and returns once JetStream has acknowledged it. It has the
+// signature of ges.SubscriptionHandler.
+func (p *Publisher) Handle(ctx context.Context, ev ges.StoredEvent) error {
+	data, err := p.encode(ev)
+	if err != nil {
+		return fmt.Errorf("ges-nats: could not encode %s at version %d: %w", ev.StreamID, ev.Version, err)
+	}
+
+	msg := nats.NewMsg(p.Subject(ev))
+	msg.Data = data
+	for k, v := range ev.Headers {
+		msg.Header.Set(k, v)
+	}
+	msg.Header.Set(nats.MsgIdHdr, messageID(ev))
+	msg.Header.Set(StreamIDHeader, ev.StreamID)
+	msg.Header.Set(VersionHeader, strconv.FormatInt(ev.Version, 10))
+	msg.Header.Set(PositionHeader, strconv.FormatInt(ev.Position, 10))
+	msg.Header.Set(EventTypeHeader, ev.Type)
+	if len(ev.Metadata) > 0 {
+		md, err := json.Marshal(ev.Metadata)
+		if err != nil {
+			return fmt.Errorf("ges-nats: could not encode metadata: %w", err)
+		}
+		msg.Header.Set(MetadataHeader, string(md))
+	}
+
+	if _, err := p.js.PublishMsg(ctx, msg); err != nil {
+		return fmt.Errorf("ges-nats: could not publish %s at version %d: %w", ev.StreamID, ev.Version, err)
+	}
+	return nil
+}
+
+// messageID identifies ev for JetStream de-duplication.
+func messageID(ev ges.StoredEvent) string {
+	if ev.ID != "" {
+		return ev.ID
+	}
+	return ev.StreamID + "@" + strconv.FormatInt(ev.Version, 10)
+}
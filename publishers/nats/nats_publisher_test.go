@@ -0,0 +1,80 @@
+package nats_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/mickamy/go-event-sourcing"
+	"github.com/mickamy/go-event-sourcing/publishers/nats"
+
+	natsgo "github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// fakeJetStream records published messages and fails while err is set.
+type fakeJetStream struct {
+	msgs []*natsgo.Msg
+	err  error
+}
+
+func (f *fakeJetStream) PublishMsg(_ context.Context, msg *natsgo.Msg, _ ...jetstream.PublishOpt) (*jetstream.PubAck, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	f.msgs = append(f.msgs, msg)
+	return &jetstream.PubAck{Sequence: uint64(len(f.msgs))}, nil
+}
+
+func TestPublisher_Handle(t *testing.T) {
+	t.Parallel()
+
+	js := &fakeJetStream{}
+	pub := nats.NewPublisher(js, nats.WithSubject("Closed", "audit.closed"))
+
+	ev := ges.StoredEvent{
+		ID:       "e1",
+		StreamID: "Account:42",
+		Version:  3,
+		Position: 10,
+		Type:     "Deposited",
+		Payload:  map[string]int{"amount": 5},
+		Metadata: ges.Metadata{"user_id": "u1"},
+		Headers:  map[string]string{"schema": "v2"},
+	}
+	if err := pub.Handle(t.Context(), ev); err != nil {
+		t.Fatalf("handle failed: %v", err)
+	}
+	if len(js.msgs) != 1 {
+		t.Fatalf("expected 1 message, got %d", len(js.msgs))
+	}
+	msg := js.msgs[0]
+	if msg.Subject != "events.Account" {
+		t.Fatalf("expected subject events.Account, got %s", msg.Subject)
+	}
+	if string(msg.Data) != `{"amount":5}` {
+		t.Fatalf("unexpected body %s", msg.Data)
+	}
+	if msg.Header.Get(natsgo.MsgIdHdr) != "e1" || msg.Header.Get(nats.VersionHeader) != "3" || msg.Header.Get("schema") != "v2" {
+		t.Fatalf("unexpected headers %v", msg.Header)
+	}
+	if msg.Header.Get(nats.MetadataHeader) != `{"user_id":"u1"}` {
+		t.Fatalf("unexpected metadata header %q", msg.Header.Get(nats.MetadataHeader))
+	}
+
+	if got := pub.Subject(ges.StoredEvent{StreamID: "Account:42", Type: "Closed"}); got != "audit.closed" {
+		t.Fatalf("expected the mapped subject, got %s", got)
+	}
+}
+
+func TestPublisher_HandleFailsWithoutAck(t *testing.T) {
+	t.Parallel()
+
+	errDown := errors.New("no responders")
+	pub := nats.NewPublisher(&fakeJetStream{err: errDown})
+
+	err := pub.Handle(t.Context(), ges.StoredEvent{StreamID: "Account:1", Version: 1, Payload: struct{}{}})
+	if !errors.Is(err, errDown) {
+		t.Fatalf("expected the publish error so the checkpoint does not advance, got %v", err)
+	}
+}
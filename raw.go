@@ -0,0 +1,45 @@
+package ges
+
+import (
+	"context"
+	"time"
+)
+
+// RawEvent is an event whose payload is already encoded, e.g. one relayed from
+// another system or store, so it can be appended without a decode/encode round trip.
+type RawEvent struct {
+	Type string
+	// ContentType names the payload's encoding; empty means that of the codec
+	// registered for Type.
+	ContentType string
+	Payload     []byte
+	Headers     map[string]string
+	OccurredAt  time.Time // zero means now
+}
+
+// AppendRaw appends events to streamID with their payloads stored as given, recording
+// md on each of them. The store must implement RawAppender; otherwise it returns
+// ErrRawUnsupported. Loads still decode the payloads with the store's codecs, so each
+// payload must be what the codec for its type and content type produces.
+//
+// Like RawAppender.AppendRaw, it does not apply the store's metadata extractors or
+// append interceptors.
+func AppendRaw(ctx context.Context, store EventStore, streamID string, expectedVersion int64, events []RawEvent, md Metadata) (int64, error) {
+	appender, ok := store.(RawAppender)
+	if !ok {
+		return 0, ErrRawUnsupported
+	}
+	stored := make([]StoredEvent, len(events))
+	for i, ev := range events {
+		stored[i] = StoredEvent{
+			StreamID:    streamID,
+			Type:        ev.Type,
+			ContentType: ev.ContentType,
+			Payload:     ev.Payload,
+			Metadata:    md,
+			Headers:     ev.Headers,
+			OccurredAt:  ev.OccurredAt,
+		}
+	}
+	return appender.AppendRaw(ctx, streamID, expectedVersion, stored)
+}
@@ -0,0 +1,116 @@
+package ges
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// ReadFilter narrows the events returned by GlobalReader.ReadAll. Store
+// implementations obtain it from the passed options with NewReadFilter.
+type ReadFilter struct {
+	// Types restricts the result to events of these types. Empty means every type.
+	Types []string
+	// Category restricts the result to streams of one category, i.e. stream IDs of
+	// the form "<Category>:<id>" (see StreamID). Empty means every stream.
+	Category string
+	// StreamID restricts the result to the events of one stream, in position order,
+	// e.g. to compare a stream's versions with its global positions. Empty means
+	// every stream.
+	StreamID string
+	// Metadata restricts the result to events whose metadata has every key set to
+	// the given value, compared as text. Empty means any metadata.
+	Metadata map[string]string
+}
+
+// ReadOption configures a ReadFilter.
+type ReadOption func(*ReadFilter)
+
+// WithEventTypes makes ReadAll return only events whose type is one of types.
+// Filtering happens in the store, so skipped events are never decoded.
+func WithEventTypes(types ...string) ReadOption {
+	return func(f *ReadFilter) { f.Types = append(f.Types, types...) }
+}
+
+// WithCategory makes ReadAll return only events of streams in category, e.g.
+// "Account" for stream IDs like "Account:42".
+func WithCategory(category string) ReadOption {
+	return func(f *ReadFilter) { f.Category = category }
+}
+
+// WithStream makes ReadAll return only the events of streamID.
+func WithStream(streamID string) ReadOption {
+	return func(f *ReadFilter) { f.StreamID = streamID }
+}
+
+// WithMetadataValue makes ReadAll return only events whose metadata has key set
+// to value, e.g. WithMetadataValue(MetadataTenantID, "acme"). Non-string values
+// are compared in their fmt.Sprint form. Stores may index some keys for this (see
+// the pgx store's WithIndexedMetadata); others scan.
+func WithMetadataValue(key, value string) ReadOption {
+	return func(f *ReadFilter) {
+		if f.Metadata == nil {
+			f.Metadata = make(map[string]string)
+		}
+		f.Metadata[key] = value
+	}
+}
+
+// Category returns the category of streamID: the part before the first ':', or the
+// whole ID if it has none (see StreamID).
+func Category(streamID string) string {
+	return StreamID(streamID).Category()
+}
+
+// NewReadFilter applies opts to an empty ReadFilter.
+func NewReadFilter(opts ...ReadOption) ReadFilter {
+	var f ReadFilter
+	for _, opt := range opts {
+		opt(&f)
+	}
+	return f
+}
+
+// IsZero reports whether f lets every event through.
+func (f ReadFilter) IsZero() bool {
+	return len(f.Types) == 0 && f.Category == "" && f.StreamID == "" && len(f.Metadata) == 0
+}
+
+// MatchType reports whether events of type eventType pass the filter.
+func (f ReadFilter) MatchType(eventType string) bool {
+	return len(f.Types) == 0 || slices.Contains(f.Types, eventType)
+}
+
+// MatchStream reports whether events of streamID pass the category and stream filters.
+func (f ReadFilter) MatchStream(streamID string) bool {
+	if f.StreamID != "" && streamID != f.StreamID {
+		return false
+	}
+	return f.Category == "" || strings.HasPrefix(streamID, f.Category+":")
+}
+
+// MatchMetadata reports whether events carrying md pass the filter.
+func (f ReadFilter) MatchMetadata(md Metadata) bool {
+	for key, want := range f.Metadata {
+		v, ok := md[key]
+		if !ok || v == nil || fmt.Sprint(v) != want {
+			return false
+		}
+	}
+	return true
+}
+
+// Match reports whether ev passes the filter.
+func (f ReadFilter) Match(ev StoredEvent) bool {
+	return f.MatchType(ev.Type) && f.MatchStream(ev.StreamID) && f.MatchMetadata(ev.Metadata)
+}
+
+// HeadReader is implemented by GlobalReaders that can report the highest Position
+// assigned so far. Filtered subscriptions use it to move their checkpoint past
+// events that the filter skipped.
+type HeadReader interface {
+	// HeadPosition returns the Position of the most recently appended event, or 0
+	// for an empty store.
+	HeadPosition(ctx context.Context) (int64, error)
+}
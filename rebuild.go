@@ -0,0 +1,171 @@
+package ges
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"sync"
+)
+
+const (
+	rebuildBatchSize = 500
+	rebuildQueueSize = 64
+)
+
+// RebuildOption configures RebuildParallel.
+type RebuildOption func(*rebuildConfig)
+
+type rebuildConfig struct {
+	checkpointer Checkpointer
+	name         string
+}
+
+// WithRebuildCheckpoint makes RebuildParallel resume from, and record progress to,
+// the checkpoint of name in cp.
+func WithRebuildCheckpoint(cp Checkpointer, name string) RebuildOption {
+	return func(c *rebuildConfig) {
+		c.checkpointer = cp
+		c.name = name
+	}
+}
+
+// RebuildParallel passes every event of the store to projection, like ReplayAll, but
+// with up to workers events in flight. Events are sharded by stream ID, so the events
+// of one stream are still handled one at a time and in version order, while different
+// streams proceed in parallel. The projection must therefore be safe for concurrent
+// use across streams.
+//
+// With WithRebuildCheckpoint, progress is recorded as a watermark: the position up to
+// which every event has been handled, no matter which worker had it. After a crash
+// the rebuild resumes from there, so events handled beyond the watermark are handled
+// again and the projection must tolerate that, as with a Subscription.
+//
+// RebuildParallel returns once it has caught up with the store, or at the first error.
+func RebuildParallel(ctx context.Context, store GlobalReader, projection SubscriptionHandler, workers int, opts ...RebuildOption) error {
+	var cfg rebuildConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	workers = max(workers, 1)
+
+	var position int64
+	if cfg.checkpointer != nil {
+		p, err := cfg.checkpointer.LoadCheckpoint(ctx, cfg.name)
+		if err != nil {
+			return fmt.Errorf("ges: rebuild %s: could not load checkpoint: %w", cfg.name, err)
+		}
+		position = p
+	}
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	w := &watermark{position: position, done: map[int64]bool{}}
+	queues := make([]chan StoredEvent, workers)
+	var wg sync.WaitGroup
+	for i := range queues {
+		queues[i] = make(chan StoredEvent, rebuildQueueSize)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ev := range queues[i] {
+				if ctx.Err() != nil {
+					continue // drain after a failure
+				}
+				if err := projection(ctx, ev); err != nil {
+					cancel(fmt.Errorf("ges: rebuild at position %d: %w", ev.Position, err))
+					continue
+				}
+				w.complete(ev.Position)
+			}
+		}()
+	}
+
+	saved := position
+	checkpoint := func(ctx context.Context) error {
+		p := w.current()
+		if cfg.checkpointer == nil || p <= saved {
+			return nil
+		}
+		if err := cfg.checkpointer.SaveCheckpoint(ctx, cfg.name, p); err != nil {
+			return fmt.Errorf("ges: rebuild %s: could not save checkpoint: %w", cfg.name, err)
+		}
+		saved = p
+		return nil
+	}
+
+	if err := dispatch(ctx, store, queues, w, position, checkpoint); err != nil {
+		cancel(err)
+	}
+	for _, q := range queues {
+		close(q)
+	}
+	wg.Wait()
+
+	// The first failure wins: a projection error, a read error or ctx being done.
+	err := context.Cause(ctx)
+	// Record progress even on failure, so a retry resumes from the watermark.
+	if cpErr := checkpoint(context.WithoutCancel(ctx)); cpErr != nil && err == nil {
+		err = cpErr
+	}
+	return err
+}
+
+// dispatch reads the store after position and routes each event to the queue of its
+// stream, calling checkpoint after every batch.
+func dispatch(ctx context.Context, store GlobalReader, queues []chan StoredEvent, w *watermark, position int64, checkpoint func(context.Context) error) error {
+	for {
+		if err := checkpoint(ctx); err != nil {
+			return err
+		}
+		events, err := store.ReadAll(ctx, position, rebuildBatchSize)
+		if err != nil {
+			return err
+		}
+		for _, ev := range events {
+			w.start(ev.Position)
+			h := fnv.New32a()
+			_, _ = h.Write([]byte(ev.StreamID))
+			select {
+			case queues[h.Sum32()%uint32(len(queues))] <- ev:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+			position = ev.Position
+		}
+		if len(events) < rebuildBatchSize {
+			return nil
+		}
+	}
+}
+
+// watermark tracks the position below which every dispatched event has been handled.
+type watermark struct {
+	mu       sync.Mutex
+	position int64
+	inflight []int64        // dispatched positions not yet below the watermark, ascending
+	done     map[int64]bool // handled positions among inflight
+}
+
+func (w *watermark) current() int64 {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.position
+}
+
+func (w *watermark) start(position int64) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.inflight = append(w.inflight, position)
+}
+
+func (w *watermark) complete(position int64) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.done[position] = true
+	for len(w.inflight) > 0 && w.done[w.inflight[0]] {
+		w.position = w.inflight[0]
+		delete(w.done, w.position)
+		w.inflight = w.inflight[1:]
+	}
+}
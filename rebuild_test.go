@@ -0,0 +1,108 @@
+package ges_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/mickamy/go-event-sourcing"
+)
+
+// logStore is a GlobalReader over a fixed list of events in position order.
+type logStore []ges.StoredEvent
+
+func (s logStore) ReadAll(_ context.Context, from int64, limit int, _ ...ges.ReadOption) ([]ges.StoredEvent, error) {
+	var out []ges.StoredEvent
+	for _, ev := range s {
+		if ev.Position > from && len(out) < limit {
+			out = append(out, ev)
+		}
+	}
+	return out, nil
+}
+
+type checkpoints struct {
+	mu        sync.Mutex
+	positions map[string]int64
+}
+
+func (c *checkpoints) LoadCheckpoint(_ context.Context, name string) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.positions[name], nil
+}
+
+func (c *checkpoints) SaveCheckpoint(_ context.Context, name string, position int64) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.positions[name] = position
+	return nil
+}
+
+// newLog interleaves n events of each of streams streams.
+func newLog(streams, n int) logStore {
+	var log logStore
+	for v := 1; v <= n; v++ {
+		for s := range streams {
+			log = append(log, ges.StoredEvent{
+				StreamID: fmt.Sprintf("Stream:%d", s),
+				Version:  int64(v),
+				Position: int64(len(log) + 1),
+			})
+		}
+	}
+	return log
+}
+
+func TestRebuildParallel(t *testing.T) {
+	t.Parallel()
+
+	log := newLog(10, 120)
+	cp := &checkpoints{positions: map[string]int64{}}
+
+	var mu sync.Mutex
+	versions := map[string]int64{}
+	err := ges.RebuildParallel(t.Context(), log, func(_ context.Context, ev ges.StoredEvent) error {
+		mu.Lock()
+		defer mu.Unlock()
+		if ev.Version != versions[ev.StreamID]+1 {
+			return fmt.Errorf("%s: got version %d after %d", ev.StreamID, ev.Version, versions[ev.StreamID])
+		}
+		versions[ev.StreamID] = ev.Version
+		return nil
+	}, 4, ges.WithRebuildCheckpoint(cp, "balances"))
+	if err != nil {
+		t.Fatalf("rebuild failed: %v", err)
+	}
+	for s, v := range versions {
+		if v != 120 {
+			t.Fatalf("expected %s to reach version 120, got %d", s, v)
+		}
+	}
+	if got := cp.positions["balances"]; got != int64(len(log)) {
+		t.Fatalf("expected the checkpoint at %d, got %d", len(log), got)
+	}
+}
+
+func TestRebuildParallel_CheckpointsWatermarkOnFailure(t *testing.T) {
+	t.Parallel()
+
+	log := newLog(3, 10)
+	cp := &checkpoints{positions: map[string]int64{}}
+	errBroken := errors.New("broken projection")
+
+	err := ges.RebuildParallel(t.Context(), log, func(_ context.Context, ev ges.StoredEvent) error {
+		if ev.Position == 14 {
+			return errBroken
+		}
+		return nil
+	}, 3, ges.WithRebuildCheckpoint(cp, "broken"))
+	if !errors.Is(err, errBroken) {
+		t.Fatalf("expected the projection error, got %v", err)
+	}
+	if got := cp.positions["broken"]; got >= 14 {
+		t.Fatalf("expected the checkpoint below the failed position 14, got %d", got)
+	}
+}
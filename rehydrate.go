@@ -0,0 +1,269 @@
+package ges
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// snapshotApplier is the part of Snapshotter that rehydration needs.
+type snapshotApplier interface {
+	ApplySnapshot(state any) error
+}
+
+// versionSetter is implemented by aggregates whose version can be restored
+// after applying a snapshot (Base provides SetVersion).
+type versionSetter interface {
+	SetVersion(v int64)
+}
+
+// Rehydrate rebuilds agg, which must be freshly initialized, from the events of
+// streamID. It works with any Aggregate, whether or not it embeds Base:
+//
+//   - If agg implements ApplySnapshot(any) error and the stream has a snapshot, the
+//     snapshot is applied first; ErrSnapshotUnsupported falls back to a full replay.
+//     Aggregates with a SetVersion(int64) method are then moved to the snapshot's
+//     version, while others must set it themselves in ApplySnapshot.
+//   - The events after agg.Version() are loaded and passed to Apply in order.
+//   - Finally agg.Version() must equal the store's last version, which catches
+//     appliers that do not advance the version.
+//
+// A stream truncated with Truncater can only be rebuilt from a snapshot at or after
+// the truncation point; otherwise the result wraps ErrStreamTruncated.
+//
+// Snapshots written under another schema version than that of a
+// SnapshotSchemaVersioner agg are ignored, so a truncated stream whose snapshot
+// predates a schema bump fails with ErrStreamTruncated.
+func Rehydrate(ctx context.Context, store EventStore, streamID string, agg Aggregate) error {
+	_, err := rehydrate(ctx, store, store, streamID, agg, true, snapshotSchemaOf(agg))
+	return err
+}
+
+// RehydrateAt rebuilds agg, which must be freshly initialized, as of version of
+// streamID, ignoring any later events. It works like Rehydrate, but uses the newest
+// snapshot at or before version when the store implements SnapshotHistoryLoader
+// (otherwise it replays from the start) and loads only the needed events when the
+// store implements RangeLoader.
+//
+// It fails if the stream has fewer than version events.
+func RehydrateAt(ctx context.Context, store EventStore, streamID string, agg Aggregate, version int64) error {
+	return rehydrateAt(ctx, store, store, streamID, agg, version, snapshotSchemaOf(agg))
+}
+
+// rehydrateAt implements RehydrateAt with the snapshots of snapshots, ignoring those
+// not written under schema (see snapshotSchemaMatches).
+func rehydrateAt(ctx context.Context, store EventStore, snapshots SnapshotStore, streamID string, agg Aggregate, version int64, schema int) error {
+	if history, ok := snapshots.(SnapshotHistoryLoader); ok {
+		if _, ok := agg.(snapshotApplier); ok {
+			snap, err := history.LoadSnapshotBefore(ctx, streamID, version)
+			if err != nil {
+				return err
+			}
+			if _, err := applySnapshot(agg, streamID, snap, schema); err != nil {
+				return err
+			}
+		}
+	}
+
+	if ranged, ok := store.(RangeLoader); ok {
+		events, err := ranged.LoadRange(ctx, streamID, agg.Version(), version)
+		if err != nil {
+			return err
+		}
+		if len(events) > 0 {
+			if err := checkContiguous(streamID, agg, events[0].Version); err != nil {
+				return err
+			}
+		}
+		for _, ev := range events {
+			agg.Apply(ev.Payload)
+		}
+	} else {
+		events, last, err := store.Load(ctx, streamID, agg.Version())
+		if err != nil {
+			return err
+		}
+		if len(events) > 0 {
+			if err := checkContiguous(streamID, agg, last-int64(len(events))+1); err != nil {
+				return err
+			}
+		}
+		for _, e := range events {
+			if agg.Version() >= version {
+				break
+			}
+			agg.Apply(e)
+		}
+	}
+
+	if agg.Version() != version {
+		return fmt.Errorf("ges: could not rebuild %s at version %d: reached version %d", streamID, version, agg.Version())
+	}
+	return nil
+}
+
+// RehydrateUntil replays the events of streamID into agg, which must be freshly
+// initialized, one at a time and stops as soon as stop reports true for agg after
+// an event, e.g. to find the version at which a balance first exceeded a limit. It
+// returns the version reached and whether stop matched; if it never does, the whole
+// stream is replayed and matched is false.
+//
+// Snapshots are not used, since the point of interest may precede them, so a
+// truncated stream fails with ErrStreamTruncated. Stores
+// implementing StreamIterator are read lazily, so nothing after the match is loaded.
+// Like Rehydrate, it fails if an event does not advance agg to its version, and like
+// Repository.Load, it reports the apply error and Validator check of agg, if it has
+// them, as of the version reached.
+func RehydrateUntil(ctx context.Context, store EventStore, streamID string, agg Aggregate, stop func(Aggregate) bool) (version int64, matched bool, err error) {
+	matched, err = replayUntil(ctx, store, streamID, agg, stop)
+	if err == nil {
+		err = validate(agg)
+	}
+	return agg.Version(), matched, err
+}
+
+// replayUntil implements RehydrateUntil without the validation.
+func replayUntil(ctx context.Context, store EventStore, streamID string, agg Aggregate, stop func(Aggregate) bool) (bool, error) {
+	if it, ok := store.(StreamIterator); ok {
+		for ev, err := range it.LoadIter(ctx, streamID, agg.Version()) {
+			if err != nil {
+				return false, err
+			}
+			if err := checkContiguous(streamID, agg, ev.Version); err != nil {
+				return false, err
+			}
+			agg.Apply(ev.Payload)
+			if err := checkReplayed(streamID, agg, ev.Version); err != nil {
+				return false, err
+			}
+			if stop(agg) {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+
+	events, last, err := store.Load(ctx, streamID, agg.Version())
+	if err != nil {
+		return false, err
+	}
+	if len(events) > 0 {
+		if err := checkContiguous(streamID, agg, last-int64(len(events))+1); err != nil {
+			return false, err
+		}
+	}
+	for _, e := range events {
+		want := agg.Version() + 1
+		agg.Apply(e)
+		if err := checkReplayed(streamID, agg, want); err != nil {
+			return false, err
+		}
+		if stop(agg) {
+			return true, nil
+		}
+	}
+	if len(events) > 0 {
+		return false, checkReplayed(streamID, agg, last)
+	}
+	return false, nil
+}
+
+// checkReplayed reports agg not being at version after replaying events of streamID
+// up to it, e.g. because its appliers do not advance the version.
+func checkReplayed(streamID string, agg Aggregate, version int64) error {
+	if agg.Version() != version {
+		return fmt.Errorf("ges: version mismatch after replaying %s: aggregate=%d, store=%d",
+			streamID, agg.Version(), version)
+	}
+	return nil
+}
+
+// checkContiguous reports that streamID was truncated if its events continuing from
+// agg start at version first rather than right after agg.Version(), e.g. because
+// the snapshot covering the truncated events was not used.
+func checkContiguous(streamID string, agg Aggregate, first int64) error {
+	if first > agg.Version()+1 {
+		return fmt.Errorf("%w: %s continues at version %d, but the aggregate is at version %d",
+			ErrStreamTruncated, streamID, first, agg.Version())
+	}
+	return nil
+}
+
+// snapshotError marks an error of the snapshot step of rehydrate, so callers can
+// tell it from errors loading events. It reads as the error it wraps.
+type snapshotError struct{ err error }
+
+func (e *snapshotError) Error() string { return e.err.Error() }
+func (e *snapshotError) Unwrap() error { return e.err }
+
+// rehydrate implements Rehydrate with the snapshots of snapshots and reports how many
+// events were replayed. With useSnapshot false it replays the whole stream without
+// looking for a snapshot; otherwise a snapshot not written under schema is treated
+// as missing.
+func rehydrate(ctx context.Context, store EventStore, snapshots SnapshotStore, streamID string, agg Aggregate, useSnapshot bool, schema int) (int, error) {
+	if _, ok := agg.(snapshotApplier); ok && useSnapshot {
+		snap, err := snapshots.LoadSnapshot(ctx, streamID)
+		if err != nil {
+			return 0, &snapshotError{err}
+		}
+		if _, err := applySnapshot(agg, streamID, snap, schema); err != nil {
+			return 0, &snapshotError{err}
+		}
+	}
+
+	events, last, err := store.Load(ctx, streamID, agg.Version())
+	if err != nil {
+		return 0, err
+	}
+	if len(events) > 0 {
+		if err := checkContiguous(streamID, agg, last-int64(len(events))+1); err != nil {
+			return 0, err
+		}
+	}
+	for _, e := range events {
+		agg.Apply(e)
+	}
+	if len(events) > 0 {
+		return len(events), checkReplayed(streamID, agg, last)
+	}
+	return 0, nil
+}
+
+// applySnapshot applies snap to agg and moves agg to the snapshot's version. It
+// reports false, leaving agg to be replayed in full, if snap is missing or not
+// written under schema, or agg does not take snapshots: it lacks ApplySnapshot or
+// that returns ErrSnapshotUnsupported.
+func applySnapshot(agg Aggregate, streamID string, snap Snapshot, schema int) (bool, error) {
+	s, ok := agg.(snapshotApplier)
+	if !ok || !snap.Found || !snapshotSchemaMatches(snap, schema) {
+		return false, nil
+	}
+	switch err := s.ApplySnapshot(snap.State); {
+	case errors.Is(err, ErrSnapshotUnsupported):
+		return false, nil
+	case err != nil:
+		return false, fmt.Errorf("ges: could not apply snapshot of %s: %w", streamID, err)
+	}
+	if vs, ok := agg.(versionSetter); ok {
+		vs.SetVersion(snap.Version)
+	}
+	return true, nil
+}
+
+// snapshotSchemaOf returns the snapshot schema version of agg, or 0 if it has none.
+func snapshotSchemaOf(agg Aggregate) int {
+	if v, ok := agg.(SnapshotSchemaVersioner); ok {
+		return v.SnapshotSchemaVersion()
+	}
+	return 0
+}
+
+// snapshotSchemaMatches reports whether snap was written under schema. A schema of 0
+// accepts any snapshot. The recorded version may come back as any JSON number type.
+func snapshotSchemaMatches(snap Snapshot, schema int) bool {
+	if schema == 0 {
+		return true
+	}
+	return fmt.Sprint(snap.Metadata[SnapshotSchemaVersionKey]) == strconv.Itoa(schema)
+}
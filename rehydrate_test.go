@@ -0,0 +1,78 @@
+package ges_test
+
+import (
+	"context"
+	"errors"
+	"iter"
+	"testing"
+
+	"github.com/mickamy/go-event-sourcing"
+)
+
+// iterStore is a spyStore that also streams events, counting how many it yielded.
+type iterStore struct {
+	*spyStore
+	yielded int
+}
+
+func (s *iterStore) LoadIter(_ context.Context, streamID string, from int64) iter.Seq2[ges.StoredEvent, error] {
+	return func(yield func(ges.StoredEvent, error) bool) {
+		for i, e := range s.events[streamID][from:] {
+			s.yielded++
+			if !yield(ges.StoredEvent{StreamID: streamID, Version: from + int64(i) + 1, Payload: e}, nil) {
+				return
+			}
+		}
+	}
+}
+
+func TestRehydrateUntil(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	store := &iterStore{spyStore: newSpyStore()}
+	if _, err := store.Append(ctx, "Counter:1", 0, []ges.Event{
+		Deposited{Amount: 4}, Deposited{Amount: 4}, Deposited{Amount: 4}, Deposited{Amount: 4},
+	}, nil); err != nil {
+		t.Fatalf("append failed: %v", err)
+	}
+	over := func(limit int) func(ges.Aggregate) bool {
+		return func(agg ges.Aggregate) bool { return agg.(*counter).total > limit }
+	}
+
+	c := newCounter("Counter:1")
+	version, matched, err := ges.RehydrateUntil(ctx, store, "Counter:1", c, over(10))
+	if err != nil || !matched || version != 3 || c.total != 12 {
+		t.Fatalf("expected a match at version 3 with total 12, got %d, %v, %v (total %d)", version, matched, err, c.total)
+	}
+	if store.yielded != 3 {
+		t.Fatalf("expected reading to stop at the match, got %d events read", store.yielded)
+	}
+
+	// Without a match the whole stream is replayed, also through plain Load.
+	c = newCounter("Counter:1")
+	version, matched, err = ges.RehydrateUntil(ctx, store.spyStore, "Counter:1", c, over(100))
+	if err != nil || matched || version != 4 || c.total != 16 {
+		t.Fatalf("expected the end of the stream without a match, got %d, %v, %v (total %d)", version, matched, err, c.total)
+	}
+
+	// The aggregate is validated as of the version reached.
+	never := func(ges.Aggregate) bool { return false }
+	store.events["Counter:2"] = []ges.Event{Deposited{Amount: 1}, Deposited{Amount: -2}}
+	_, _, err = ges.RehydrateUntil(ctx, store, "Counter:2", nonNegativeCounter{newCounter("Counter:2")}, never)
+	if !errors.Is(err, ges.ErrInvalidAggregate) {
+		t.Fatalf("expected ErrInvalidAggregate, got %v", err)
+	}
+
+	// Appliers that do not advance the version are caught on both paths.
+	for _, s := range []ges.EventStore{store, store.spyStore} {
+		if _, _, err := ges.RehydrateUntil(ctx, s, "Counter:1", stuckCounter{newCounter("Counter:1")}, never); err == nil {
+			t.Fatalf("expected a version mismatch from %T", s)
+		}
+	}
+}
+
+// stuckCounter is a counter whose Apply does not advance the version.
+type stuckCounter struct{ *counter }
+
+func (stuckCounter) Apply(ges.Event) {}
@@ -0,0 +1,51 @@
+package ges
+
+import (
+	"context"
+	"fmt"
+)
+
+// replayBatchSize is the number of events ReplayAll reads per round trip.
+const replayBatchSize = 500
+
+// Replay walks a single stream from the beginning and passes every event to handler.
+// Events are streamed via LoadIter, so arbitrarily long streams are never buffered.
+// It stops at the first error returned by the store or the handler.
+func Replay(ctx context.Context, store StreamIterator, streamID string, handler func(StoredEvent) error) error {
+	for ev, err := range store.LoadIter(ctx, streamID, 0) {
+		if err != nil {
+			return err
+		}
+		if err := handler(ev); err != nil {
+			return fmt.Errorf("ges: replay %s at version %d: %w", streamID, ev.Version, err)
+		}
+	}
+	return nil
+}
+
+// ReplayAll walks every stream in global position order and passes each event to handler,
+// e.g. to rebuild a projection from scratch. Events are read in batches, so memory use
+// stays bounded regardless of store size. It stops at the first error. opts filter the
+// events as for GlobalReader.ReadAll.
+func ReplayAll(ctx context.Context, store GlobalReader, handler func(StoredEvent) error, opts ...ReadOption) error {
+	var position int64
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		events, err := store.ReadAll(ctx, position, replayBatchSize, opts...)
+		if err != nil {
+			return err
+		}
+		for _, ev := range events {
+			if err := handler(ev); err != nil {
+				return fmt.Errorf("ges: replay at position %d: %w", ev.Position, err)
+			}
+			position = ev.Position
+		}
+		if len(events) < replayBatchSize {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,491 @@
+package ges
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Repository loads and saves aggregates of type A using an EventStore.
+//
+// Aggregates that implement Snapshotter (e.g. by embedding Base configured with
+// WithSnapshotter) are restored from the latest snapshot before the remaining
+// events are replayed, and can be snapshotted with SaveSnapshot or automatically
+// through WithSnapshotPolicy or WithSnapshotEvery.
+type Repository[A Aggregate] struct {
+	store     EventStore
+	snapshots SnapshotStore // see WithSnapshotStore
+	factory   func(streamID string) A
+	policy    SnapshotPolicy
+	every     int64
+	serialize func(A) any
+	lenient   bool
+	onSnapErr func(streamID string, err error)
+	observer  func(streamID string, events int, dur time.Duration)
+	pool      *sync.Pool // see WithAggregatePool
+	merger    Merger
+	readTx    bool
+	schema    int
+	stats     statsCache // see WithSnapshotPolicy
+}
+
+// RepositoryOption configures a Repository.
+type RepositoryOption func(*repositoryConfig)
+
+type repositoryConfig struct {
+	policy    SnapshotPolicy
+	every     int64
+	serialize any // func(A) any
+	lenient   bool
+	onSnapErr func(streamID string, err error)
+	observer  func(streamID string, events int, dur time.Duration)
+	pooled    bool
+	merger    Merger
+	readTx    bool
+	schema    int
+	snapshots SnapshotStore
+}
+
+// Merger decides whether pending events, decided against a stale version of a
+// stream, still apply on top of the concurrent events that caused a version
+// conflict, e.g. because both sides are independent deposits.
+type Merger func(pending, concurrent []Event) bool
+
+// WithSnapshotPolicy makes Save take a snapshot whenever policy asks for one, based
+// on the replay cost measured by the preceding Load of the same stream. The costs of
+// the 1024 most recently loaded streams are kept; a Save of a stream loaded longer
+// ago is judged by the events it appends alone.
+func WithSnapshotPolicy(policy SnapshotPolicy) RepositoryOption {
+	return func(c *repositoryConfig) { c.policy = policy }
+}
+
+// WithSnapshotEvery makes Save take a snapshot whenever it moves the stream's version
+// past a multiple of n. Snapshots are caches, so a snapshot that fails this way is
+// ignored: the next Load just replays more events.
+func WithSnapshotEvery(n int64) RepositoryOption {
+	return func(c *repositoryConfig) { c.every = max(n, 0) }
+}
+
+// WithSnapshotSerializer sets how the Repository captures an aggregate's state for
+// a snapshot, instead of asking the aggregate through Snapshotter. Restoring still
+// goes through the aggregate's ApplySnapshot. A nil result means there is nothing
+// to snapshot. NewRepository panics if A does not match the Repository's type.
+func WithSnapshotSerializer[A Aggregate](fn func(A) any) RepositoryOption {
+	return func(c *repositoryConfig) { c.serialize = fn }
+}
+
+// WithStrictSnapshots sets whether Load fails when the latest snapshot cannot be
+// loaded or applied (the default). With strict set to false such a snapshot is
+// treated as a cache miss: Load starts over from a fresh aggregate and replays the
+// stream from version 0, so aggregates stay loadable while the snapshot store is
+// unavailable or holds a corrupt snapshot. Errors from loading events still fail.
+func WithStrictSnapshots(strict bool) RepositoryOption {
+	return func(c *repositoryConfig) { c.lenient = !strict }
+}
+
+// WithSnapshotErrorHandler sets a function called with every snapshot error that
+// Load tolerates under WithStrictSnapshots(false), e.g. to log it.
+func WithSnapshotErrorHandler(fn func(streamID string, err error)) RepositoryOption {
+	return func(c *repositoryConfig) { c.onSnapErr = fn }
+}
+
+// WithRehydrationObserver sets a function called after every successful Load with
+// the number of events replayed on top of the snapshot and the time the load took,
+// snapshot included. Use it to record metrics or to flag aggregates that replay
+// thousands of events without a snapshot. It is called synchronously, so it should
+// be quick.
+func WithRehydrationObserver(fn func(streamID string, events int, dur time.Duration)) RepositoryOption {
+	return func(c *repositoryConfig) { c.observer = fn }
+}
+
+// WithAggregatePool makes Load and LoadAt reuse aggregates handed back with
+// Release instead of calling the factory, resetting them with Resetter first. It
+// saves an allocation per command on hot paths. NewRepository panics if the
+// aggregate type does not implement Resetter.
+func WithAggregatePool() RepositoryOption {
+	return func(c *repositoryConfig) { c.pooled = true }
+}
+
+// WithMerger makes Save resolve version conflicts without re-running command logic
+// when merge accepts: the concurrent events are loaded, and if merge reports that
+// the pending events still apply, they are appended again at the new version and
+// the concurrent events are applied to the aggregate, so it ends up at the stream's
+// version. Only use it for events that commute: the aggregate's state, and any
+// snapshot taken right after the save, is built with the pending events applied
+// before the concurrent ones, the reverse of their order in the stream. When merge
+// declines, or after a few merges that keep conflicting, Save returns the conflict
+// as usual, to be handled with a full retry (see RunWithRetry).
+func WithMerger(merge Merger) RepositoryOption {
+	return func(c *repositoryConfig) { c.merger = merge }
+}
+
+// WithReadTransaction makes Load, LoadAt and LoadMany read inside the store's read
+// transaction (see ReadTransactor), so the snapshot and the events of an aggregate,
+// and all the aggregates of one LoadMany, come from the same consistent state of the
+// store. It costs a transaction per call, so by default every read stands alone. It
+// has no effect on stores that do not implement ReadTransactor.
+func WithReadTransaction() RepositoryOption {
+	return func(c *repositoryConfig) { c.readTx = true }
+}
+
+// WithSnapshotSchemaVersion sets the version of the shape of the aggregate's snapshot
+// state, overriding SnapshotSchemaVersioner. SaveSnapshot records it in the snapshot
+// metadata, which requires a SnapshotMetadataSaver store, and Load, LoadAt and
+// LoadStateOnly ignore snapshots written under another version, rebuilding from
+// events instead; VerifySnapshot reports them as ErrSnapshotNotVerified. Bump it
+// whenever the snapshot state changes shape.
+//
+// The rebuild needs every event, so a bump requires untruncated streams: a stream
+// truncated with Truncater no longer loads and fails with ErrStreamTruncated.
+func WithSnapshotSchemaVersion(version int) RepositoryOption {
+	return func(c *repositoryConfig) { c.schema = version }
+}
+
+// WithSnapshotStore makes the Repository save and load snapshots with snapshots
+// instead of its EventStore, e.g. to keep them in a cache such as Redis while the
+// events stay in Postgres. Since snapshots are only an optimization, snapshots may
+// lose them at any time: aggregates are then rebuilt from their events.
+func WithSnapshotStore(snapshots SnapshotStore) RepositoryOption {
+	return func(c *repositoryConfig) { c.snapshots = snapshots }
+}
+
+// NewRepository creates a Repository. factory must return a fresh, initialized
+// aggregate for the given stream ID (e.g. with Base.Init already called).
+func NewRepository[A Aggregate](store EventStore, factory func(streamID string) A, opts ...RepositoryOption) *Repository[A] {
+	var cfg repositoryConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	r := &Repository[A]{
+		store:     store,
+		snapshots: cfg.snapshots,
+		factory:   factory,
+		policy:    cfg.policy,
+		every:     cfg.every,
+		lenient:   cfg.lenient,
+		onSnapErr: cfg.onSnapErr,
+		observer:  cfg.observer,
+		merger:    cfg.merger,
+		readTx:    cfg.readTx,
+		schema:    cfg.schema,
+	}
+	if r.snapshots == nil {
+		r.snapshots = store
+	}
+	if cfg.serialize != nil {
+		fn, ok := cfg.serialize.(func(A) any)
+		if !ok {
+			var zero A
+			panic(fmt.Sprintf("ges: snapshot serializer %T does not take %T", cfg.serialize, zero))
+		}
+		r.serialize = fn
+	}
+	if cfg.pooled {
+		var zero A
+		if _, ok := any(zero).(Resetter); !ok {
+			panic(fmt.Sprintf("ges: aggregate pool needs %T to implement Resetter", zero))
+		}
+		r.pool = &sync.Pool{}
+	}
+	return r
+}
+
+// Release hands agg back for reuse by a later Load or LoadAt when the Repository
+// was created with WithAggregatePool, and does nothing otherwise. agg must not be
+// used after it has been released.
+func (r *Repository[A]) Release(agg A) {
+	if r.pool != nil {
+		r.pool.Put(agg)
+	}
+}
+
+// newAggregate returns a pooled aggregate reset for streamID, or a new one from the factory.
+func (r *Repository[A]) newAggregate(streamID string) A {
+	if r.pool != nil {
+		if agg, ok := r.pool.Get().(A); ok {
+			any(agg).(Resetter).Reset(streamID)
+			return agg
+		}
+	}
+	return r.factory(streamID)
+}
+
+// Load rehydrates the aggregate for streamID (see Rehydrate): it applies the latest
+// snapshot, if any, and then replays the events recorded after it. A stream without
+// events yields a fresh aggregate at version 0. Aggregates implementing Validator
+// are then validated.
+func (r *Repository[A]) Load(ctx context.Context, streamID string) (agg A, err error) {
+	err = r.read(ctx, func(ctx context.Context) error {
+		agg, err = r.load(ctx, streamID)
+		return err
+	})
+	return agg, err
+}
+
+// LoadMany loads the aggregates of streamIDs like Load, in the same order. With
+// WithReadTransaction they are mutually consistent.
+func (r *Repository[A]) LoadMany(ctx context.Context, streamIDs ...string) ([]A, error) {
+	aggs := make([]A, 0, len(streamIDs))
+	err := r.read(ctx, func(ctx context.Context) error {
+		for _, streamID := range streamIDs {
+			agg, err := r.load(ctx, streamID)
+			if err != nil {
+				return err
+			}
+			aggs = append(aggs, agg)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return aggs, nil
+}
+
+// read runs fn in the store's read transaction if WithReadTransaction asks for one.
+func (r *Repository[A]) read(ctx context.Context, fn func(ctx context.Context) error) error {
+	if t, ok := r.store.(ReadTransactor); ok && r.readTx {
+		return t.ReadInTransaction(ctx, fn)
+	}
+	return fn(ctx)
+}
+
+func (r *Repository[A]) load(ctx context.Context, streamID string) (A, error) {
+	start := time.Now()
+	agg := r.newAggregate(streamID)
+
+	replayed, err := rehydrate(ctx, r.store, r.snapshots, streamID, agg, true, r.snapshotSchema(agg))
+	var snapErr *snapshotError
+	if r.lenient && errors.As(err, &snapErr) {
+		if r.onSnapErr != nil {
+			r.onSnapErr(streamID, snapErr.err)
+		}
+		agg = r.newAggregate(streamID) // the failed snapshot may have been half applied
+		replayed, err = rehydrate(ctx, r.store, r.snapshots, streamID, agg, false, 0)
+	}
+	if err != nil {
+		return agg, err
+	}
+	if err := validate(agg); err != nil {
+		return agg, err
+	}
+	dur := time.Since(start)
+	if r.policy != nil {
+		r.stats.put(streamID, ReplayStats{
+			EventsSinceSnapshot: replayed,
+			Replayed:            replayed,
+			Duration:            dur,
+		})
+	}
+	if r.observer != nil {
+		r.observer(streamID, replayed, dur)
+	}
+	return agg, nil
+}
+
+// LoadAt rebuilds the aggregate for streamID as it was at version (see RehydrateAt).
+// The result reflects history only; do not Save it. Like Load, it validates
+// aggregates implementing Validator.
+func (r *Repository[A]) LoadAt(ctx context.Context, streamID string, version int64) (A, error) {
+	agg := r.newAggregate(streamID)
+	err := r.read(ctx, func(ctx context.Context) error {
+		return rehydrateAt(ctx, r.store, r.snapshots, streamID, agg, version, r.snapshotSchema(agg))
+	})
+	if err != nil {
+		return agg, err
+	}
+	return agg, validate(agg)
+}
+
+// LoadStateOnly returns the state of the latest snapshot of streamID without loading
+// or replaying any events, in a single read, for views that tolerate staleness. The
+// state may be behind the stream by up to the snapshot interval, and it comes in the
+// store's form (see DecodeState). found is false if the stream has no snapshot, or
+// only one written under another snapshot schema version (see
+// WithSnapshotSchemaVersion).
+func (r *Repository[A]) LoadStateOnly(ctx context.Context, streamID string) (state any, found bool, err error) {
+	snap, err := r.snapshots.LoadSnapshot(ctx, streamID)
+	if err != nil || !snap.Found {
+		return nil, false, err
+	}
+	if !snapshotSchemaMatches(snap, r.snapshotSchema(r.factory(streamID))) {
+		return nil, false, nil
+	}
+	return snap.State, true, nil
+}
+
+// VerifySnapshot is like the VerifySnapshot function, but rebuilds the Repository's
+// aggregates from its snapshot store, honoring its snapshot schema version and
+// capturing states with its WithSnapshotSerializer function, if any.
+func (r *Repository[A]) VerifySnapshot(ctx context.Context, streamID string) error {
+	rebuild := func() Aggregate { return r.factory(streamID) }
+	schema := func(agg Aggregate) int { return r.snapshotSchema(agg.(A)) }
+	capture := capturedState
+	if r.serialize != nil {
+		capture = func(agg Aggregate) (any, error) {
+			state := r.serialize(agg.(A))
+			if state == nil {
+				return nil, fmt.Errorf("%w: %T captured no state", ErrSnapshotUnsupported, agg)
+			}
+			return state, nil
+		}
+	}
+	return verifySnapshot(ctx, r.store, r.snapshots, streamID, rebuild, schema, capture)
+}
+
+// validate reports the aggregate's apply error and runs its Validator check, if it
+// has them.
+func validate(agg Aggregate) error {
+	if r, ok := agg.(ApplyErrorReporter); ok {
+		if err := r.ApplyErr(); err != nil {
+			return err
+		}
+	}
+	v, ok := agg.(Validator)
+	if !ok {
+		return nil
+	}
+	if err := v.Validate(); err != nil {
+		return fmt.Errorf("%w: %s at version %d: %w", ErrInvalidAggregate, agg.StreamID(), agg.Version(), err)
+	}
+	return nil
+}
+
+// Save appends the aggregate's pending events using optimistic locking and clears them.
+// It is a no-op when there is nothing pending. An aggregate that reports an apply
+// error (see ApplyErrorReporter), such as a Base under FailOnUnknownEvents that
+// raised an event it has no applier for, is rejected with that error and nothing is
+// appended, since the stream could not be loaded again afterwards.
+//
+// With a SnapshotPolicy configured, Save then consults it with the stats of the last
+// Load of the stream and snapshots the aggregate if asked to. A snapshot failure is
+// returned wrapped, but the events are already persisted at that point. Otherwise,
+// with WithSnapshotEvery, Save snapshots the aggregate when its version crosses a
+// multiple of the interval.
+func (r *Repository[A]) Save(ctx context.Context, agg A, md Metadata) error {
+	events, expected := agg.Flush()
+	return r.save(ctx, agg, events, expected, md)
+}
+
+// save implements Save for events flushed from agg.
+func (r *Repository[A]) save(ctx context.Context, agg A, events []Event, expected int64, md Metadata) error {
+	if len(events) == 0 {
+		return nil
+	}
+	if rep, ok := any(agg).(ApplyErrorReporter); ok {
+		if err := rep.ApplyErr(); err != nil {
+			return err
+		}
+	}
+	if _, err := r.store.Append(ctx, agg.StreamID(), expected, events, md); err != nil {
+		if expected, err = r.merge(ctx, agg, events, expected, md, err); err != nil {
+			return err
+		}
+	}
+
+	if r.policy != nil {
+		streamID := agg.StreamID()
+		stats := r.stats.take(streamID)
+		stats.EventsSinceSnapshot += len(events)
+
+		if r.policy.ShouldSnapshot(stats) {
+			switch err := r.SaveSnapshot(ctx, agg); {
+			case errors.Is(err, ErrSnapshotUnsupported):
+			case err != nil:
+				return fmt.Errorf("ges: could not snapshot %s after save: %w", streamID, err)
+			default:
+				return nil
+			}
+		}
+	}
+
+	if r.every > 0 && expected/r.every != agg.Version()/r.every {
+		_ = r.SaveSnapshot(ctx, agg)
+	}
+	return nil
+}
+
+// merge resolves the version conflict err of appending events at expected with the
+// Merger, if any. It returns the version the events were appended at, or err.
+func (r *Repository[A]) merge(ctx context.Context, agg A, events []Event, expected int64, md Metadata, err error) (int64, error) {
+	streamID := agg.StreamID()
+	var merged []Event
+	for range defaultRetryAttempts {
+		if r.merger == nil || !errors.Is(err, ErrVersionConflict) {
+			return expected, err
+		}
+		concurrent, last, loadErr := r.store.Load(ctx, streamID, expected)
+		if loadErr != nil {
+			return expected, loadErr
+		}
+		if len(concurrent) == 0 || !r.merger(events, concurrent) {
+			return expected, err
+		}
+		merged = append(merged, concurrent...)
+		expected = last
+		if _, err = r.store.Append(ctx, streamID, expected, events, md); err == nil {
+			for _, e := range merged {
+				agg.Apply(e)
+			}
+			return expected, nil
+		}
+	}
+	return expected, err
+}
+
+// SaveWithSnapshot is like Save but then snapshots the aggregate unconditionally,
+// with the serializer configured on the Repository if any, e.g. before a known
+// expensive sequence of commands or from a nightly job. The events are saved as by
+// Save, through the Merger if one is configured. The snapshot is taken even when
+// nothing is pending. A snapshot failure is returned wrapped, but the events are
+// already persisted at that point.
+func (r *Repository[A]) SaveWithSnapshot(ctx context.Context, agg A, md Metadata) error {
+	streamID := agg.StreamID()
+	events, expected := agg.Flush()
+	if err := r.save(ctx, agg, events, expected, md); err != nil {
+		return err
+	}
+	if r.policy != nil {
+		r.stats.take(streamID)
+	}
+	if err := r.SaveSnapshot(ctx, agg); err != nil {
+		return fmt.Errorf("ges: could not snapshot %s after save: %w", streamID, err)
+	}
+	return nil
+}
+
+// SaveSnapshot stores the aggregate's current state at its current version, as
+// captured by the WithSnapshotSerializer function or else by the aggregate's
+// Snapshotter implementation. It returns ErrSnapshotUnsupported if there is no way
+// to capture the state or nothing to snapshot. Only call it after pending events
+// have been saved.
+func (r *Repository[A]) SaveSnapshot(ctx context.Context, agg A) error {
+	var state any
+	if r.serialize != nil {
+		state = r.serialize(agg)
+	} else if s, ok := any(agg).(Snapshotter); ok {
+		state = s.Snapshot()
+	}
+	if state == nil {
+		return ErrSnapshotUnsupported
+	}
+	if schema := r.snapshotSchema(agg); schema != 0 {
+		saver, ok := r.snapshots.(SnapshotMetadataSaver)
+		if !ok {
+			return fmt.Errorf("%w: %T cannot record the snapshot schema version", ErrSnapshotUnsupported, r.snapshots)
+		}
+		return saver.SaveSnapshotWithMeta(ctx, agg.StreamID(), agg.Version(), state, Metadata{SnapshotSchemaVersionKey: schema})
+	}
+	return r.snapshots.SaveSnapshot(ctx, agg.StreamID(), agg.Version(), state)
+}
+
+// snapshotSchema returns the snapshot schema version of agg: the one set with
+// WithSnapshotSchemaVersion, or else its own.
+func (r *Repository[A]) snapshotSchema(agg A) int {
+	if r.schema != 0 {
+		return r.schema
+	}
+	return snapshotSchemaOf(agg)
+}
@@ -0,0 +1,690 @@
+package ges_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mickamy/go-event-sourcing"
+)
+
+// spyStore is a minimal in-memory EventStore that records how many events each Load returned.
+type spyStore struct {
+	mu        sync.Mutex
+	events    map[string][]ges.Event
+	snapshots map[string]ges.Snapshot
+	loaded    []int
+}
+
+func newSpyStore() *spyStore {
+	return &spyStore{events: map[string][]ges.Event{}, snapshots: map[string]ges.Snapshot{}}
+}
+
+func (s *spyStore) Load(_ context.Context, streamID string, from int64) ([]ges.Event, int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	all := s.events[streamID]
+	delta := all[min(from, int64(len(all))):]
+	s.loaded = append(s.loaded, len(delta))
+	return delta, int64(len(all)), nil
+}
+
+func (s *spyStore) Append(_ context.Context, streamID string, expected int64, events []ges.Event, _ ges.Metadata) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if current := int64(len(s.events[streamID])); current != expected {
+		return 0, &ges.VersionConflictError{StreamID: streamID, ExpectedVersion: expected, ActualVersion: current}
+	}
+	s.events[streamID] = append(s.events[streamID], events...)
+	return int64(len(s.events[streamID])), nil
+}
+
+func (s *spyStore) SaveSnapshot(_ context.Context, streamID string, version int64, state any) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.snapshots[streamID] = ges.Snapshot{Version: version, State: state, Found: true}
+	return nil
+}
+
+func (s *spyStore) LoadSnapshot(_ context.Context, streamID string) (ges.Snapshot, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.snapshots[streamID], nil
+}
+
+type counter struct {
+	ges.Base
+	total int
+}
+
+func newCounter(streamID string) *counter {
+	var c counter
+	c.Init(streamID, func(e ges.Event) { c.total += e.(Deposited).Amount },
+		ges.WithSnapshotter(nil, func(state any) error {
+			c.total = state.(int)
+			return nil
+		}),
+	)
+	return &c
+}
+
+func (c *counter) Reset(streamID string) {
+	c.Base.Reset(streamID)
+	c.total = 0
+}
+
+func TestRepository_SnapshotEvery(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	store := newSpyStore()
+	repo := ges.NewRepository(store, newCounter,
+		ges.WithSnapshotEvery(3),
+		ges.WithSnapshotSerializer(func(c *counter) any { return c.total }),
+	)
+
+	for i := range 4 {
+		c, err := repo.Load(ctx, "Counter:1")
+		if err != nil {
+			t.Fatalf("load failed: %v", err)
+		}
+		c.Raise(Deposited{Amount: i + 1})
+		if err := repo.Save(ctx, c, nil); err != nil {
+			t.Fatalf("save failed: %v", err)
+		}
+	}
+
+	snap, _ := store.LoadSnapshot(ctx, "Counter:1")
+	if !snap.Found || snap.Version != 3 || snap.State != 6 {
+		t.Fatalf("expected a snapshot of 6 at version 3, got %+v", snap)
+	}
+
+	store.loaded = nil
+	c, err := repo.Load(ctx, "Counter:1")
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	if c.total != 10 || c.Version() != 4 {
+		t.Fatalf("expected 10 at version 4, got %d at %d", c.total, c.Version())
+	}
+	if len(store.loaded) != 1 || store.loaded[0] != 1 {
+		t.Fatalf("expected Load to replay only the event after the snapshot, got %v", store.loaded)
+	}
+}
+
+// nonNegativeCounter is a counter whose total must never drop below zero.
+type nonNegativeCounter struct{ *counter }
+
+func (c nonNegativeCounter) Validate() error {
+	if c.total < 0 {
+		return errors.New("negative total")
+	}
+	return nil
+}
+
+func TestRepository_Validate(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	store := newSpyStore()
+	repo := ges.NewRepository(store, func(streamID string) nonNegativeCounter {
+		return nonNegativeCounter{newCounter(streamID)}
+	})
+
+	if _, err := store.Append(ctx, "Counter:valid", 0, []ges.Event{Deposited{Amount: 1}}, nil); err != nil {
+		t.Fatalf("append failed: %v", err)
+	}
+	if _, err := repo.Load(ctx, "Counter:valid"); err != nil {
+		t.Fatalf("expected a valid counter to load, got %v", err)
+	}
+
+	if _, err := store.Append(ctx, "Counter:corrupt", 0, []ges.Event{Deposited{Amount: -1}}, nil); err != nil {
+		t.Fatalf("append failed: %v", err)
+	}
+	if _, err := repo.Load(ctx, "Counter:corrupt"); !errors.Is(err, ges.ErrInvalidAggregate) {
+		t.Fatalf("expected ErrInvalidAggregate, got %v", err)
+	}
+	if _, err := repo.LoadAt(ctx, "Counter:corrupt", 1); !errors.Is(err, ges.ErrInvalidAggregate) {
+		t.Fatalf("expected LoadAt to validate as well, got %v", err)
+	}
+}
+
+func TestRepository_LockToken(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	repo := ges.NewRepository(newSpyStore(), newCounter)
+
+	c, token, err := repo.LoadWithToken(ctx, "Counter:token")
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	// The token survives a round trip through a client.
+	token, err = ges.ParseLockToken(token.String())
+	if err != nil || token.StreamID() != "Counter:token" || token.Version() != 0 {
+		t.Fatalf("expected a token for version 0, got %+v, %v", token, err)
+	}
+	c.Raise(Deposited{Amount: 1})
+	if err := repo.SaveWithToken(ctx, c, token, nil); err != nil {
+		t.Fatalf("save failed: %v", err)
+	}
+
+	// Saving a reloaded aggregate with the stale token conflicts before reaching the store.
+	c, err = repo.Load(ctx, "Counter:token")
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	c.Raise(Deposited{Amount: 1})
+	var conflict *ges.VersionConflictError
+	if err := repo.SaveWithToken(ctx, c, token, nil); !errors.As(err, &conflict) || conflict.ActualVersion != 1 {
+		t.Fatalf("expected a conflict with version 1, got %v", err)
+	}
+
+	if _, err := ges.ParseLockToken("not a token"); !errors.Is(err, ges.ErrInvalidLockToken) {
+		t.Fatalf("expected ErrInvalidLockToken, got %v", err)
+	}
+}
+
+// snapshotsDown is a spyStore whose snapshots cannot be loaded.
+type snapshotsDown struct{ *spyStore }
+
+var errSnapshotsDown = errors.New("snapshots table unavailable")
+
+func (snapshotsDown) LoadSnapshot(context.Context, string) (ges.Snapshot, error) {
+	return ges.Snapshot{}, errSnapshotsDown
+}
+
+func TestRepository_StrictSnapshots(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	store := snapshotsDown{newSpyStore()}
+	if _, err := store.Append(ctx, "Counter:1", 0, []ges.Event{Deposited{Amount: 2}, Deposited{Amount: 3}}, nil); err != nil {
+		t.Fatalf("append failed: %v", err)
+	}
+
+	if _, err := ges.NewRepository(store, newCounter).Load(ctx, "Counter:1"); !errors.Is(err, errSnapshotsDown) {
+		t.Fatalf("expected the snapshot error by default, got %v", err)
+	}
+
+	var tolerated []error
+	repo := ges.NewRepository(store, newCounter,
+		ges.WithStrictSnapshots(false),
+		ges.WithSnapshotErrorHandler(func(_ string, err error) { tolerated = append(tolerated, err) }),
+	)
+	c, err := repo.Load(ctx, "Counter:1")
+	if err != nil {
+		t.Fatalf("expected a full replay, got %v", err)
+	}
+	if c.total != 5 || c.Version() != 2 {
+		t.Fatalf("expected total 5 at version 2, got %d at %d", c.total, c.Version())
+	}
+	if len(tolerated) != 1 || !errors.Is(tolerated[0], errSnapshotsDown) {
+		t.Fatalf("expected the handler to see the snapshot error, got %v", tolerated)
+	}
+}
+
+func TestRepository_RehydrationObserver(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	store := newSpyStore()
+	if _, err := store.Append(ctx, "Counter:1", 0, []ges.Event{Deposited{Amount: 1}, Deposited{Amount: 2}, Deposited{Amount: 3}}, nil); err != nil {
+		t.Fatalf("append failed: %v", err)
+	}
+	if err := store.SaveSnapshot(ctx, "Counter:1", 2, 3); err != nil {
+		t.Fatalf("save snapshot failed: %v", err)
+	}
+
+	type observation struct {
+		streamID string
+		events   int
+	}
+	var observed []observation
+	repo := ges.NewRepository(store, newCounter,
+		ges.WithRehydrationObserver(func(streamID string, events int, dur time.Duration) {
+			if dur < 0 {
+				t.Errorf("expected a non-negative duration, got %v", dur)
+			}
+			observed = append(observed, observation{streamID, events})
+		}),
+	)
+	c, err := repo.Load(ctx, "Counter:1")
+	if err != nil || c.total != 6 {
+		t.Fatalf("expected total 6, got %d, %v", c.total, err)
+	}
+	if len(observed) != 1 || observed[0] != (observation{"Counter:1", 1}) {
+		t.Fatalf("expected one observation of 1 event replayed after the snapshot, got %+v", observed)
+	}
+}
+
+func TestRepository_LoadStateOnly(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	store := newSpyStore()
+	repo := ges.NewRepository(store, newCounter)
+
+	if _, found, err := repo.LoadStateOnly(ctx, "Counter:1"); err != nil || found {
+		t.Fatalf("expected no state without a snapshot, got %v, %v", found, err)
+	}
+
+	if _, err := store.Append(ctx, "Counter:1", 0, []ges.Event{Deposited{Amount: 1}, Deposited{Amount: 2}, Deposited{Amount: 3}}, nil); err != nil {
+		t.Fatalf("append failed: %v", err)
+	}
+	if err := store.SaveSnapshot(ctx, "Counter:1", 2, 3); err != nil {
+		t.Fatalf("save snapshot failed: %v", err)
+	}
+	state, found, err := repo.LoadStateOnly(ctx, "Counter:1")
+	if err != nil || !found || state != 3 {
+		t.Fatalf("expected the snapshot state 3, got %v, %v, %v", state, found, err)
+	}
+	if len(store.loaded) != 0 {
+		t.Fatalf("expected no events to be loaded, got %v", store.loaded)
+	}
+}
+
+func TestRepository_SaveWithSnapshot(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	store := newSpyStore()
+	repo := ges.NewRepository(store, newCounter,
+		ges.WithSnapshotSerializer(func(c *counter) any { return c.total }),
+	)
+
+	c, err := repo.Load(ctx, "Counter:1")
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	c.Raise(Deposited{Amount: 4})
+	c.Raise(Deposited{Amount: 5})
+	if err := repo.SaveWithSnapshot(ctx, c, nil); err != nil {
+		t.Fatalf("save with snapshot failed: %v", err)
+	}
+	if snap := store.snapshots["Counter:1"]; snap.Version != 2 || snap.State != 9 {
+		t.Fatalf("expected a snapshot of 9 at version 2, got %+v", snap)
+	}
+	if len(store.events["Counter:1"]) != 2 {
+		t.Fatalf("expected the pending events to be appended, got %v", store.events["Counter:1"])
+	}
+
+	// Without a way to capture the state, the error says so.
+	plain := ges.NewRepository(store, newCounter)
+	if err := plain.SaveWithSnapshot(ctx, c, nil); !errors.Is(err, ges.ErrSnapshotUnsupported) {
+		t.Fatalf("expected ErrSnapshotUnsupported, got %v", err)
+	}
+
+	// A conflict goes through the Merger, and the snapshot includes what was merged.
+	merging := ges.NewRepository(store, newCounter,
+		ges.WithSnapshotSerializer(func(c *counter) any { return c.total }),
+		ges.WithMerger(func(_, _ []ges.Event) bool { return true }),
+	)
+	stale, err := merging.Load(ctx, "Counter:1")
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	store.events["Counter:1"] = append(store.events["Counter:1"], Deposited{Amount: 1})
+	stale.Raise(Deposited{Amount: 2})
+	if err := merging.SaveWithSnapshot(ctx, stale, nil); err != nil {
+		t.Fatalf("expected the conflict to be merged, got %v", err)
+	}
+	if snap := store.snapshots["Counter:1"]; snap.Version != 4 || snap.State != 12 {
+		t.Fatalf("expected a snapshot of 12 at version 4, got %+v", snap)
+	}
+}
+
+func TestRepository_Merger(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	store := newSpyStore()
+	deposits := func(events []ges.Event) bool {
+		for _, e := range events {
+			if _, ok := e.(Deposited); !ok {
+				return false
+			}
+		}
+		return true
+	}
+	repo := ges.NewRepository(store, newCounter,
+		ges.WithMerger(func(pending, concurrent []ges.Event) bool { return deposits(pending) && deposits(concurrent) }),
+	)
+
+	stale, err := repo.Load(ctx, "Counter:1")
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	fresh, err := repo.Load(ctx, "Counter:1")
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	fresh.Raise(Deposited{Amount: 3})
+	if err := repo.Save(ctx, fresh, nil); err != nil {
+		t.Fatalf("save failed: %v", err)
+	}
+
+	stale.Raise(Deposited{Amount: 4})
+	if err := repo.Save(ctx, stale, nil); err != nil {
+		t.Fatalf("expected the conflict to be merged, got %v", err)
+	}
+	if stale.total != 7 || stale.Version() != 2 {
+		t.Fatalf("expected 7 at version 2, got %d at %d", stale.total, stale.Version())
+	}
+	if len(store.events["Counter:1"]) != 2 {
+		t.Fatalf("expected both deposits to be stored, got %v", store.events["Counter:1"])
+	}
+
+	// A merge that declines leaves the conflict to the caller.
+	declining := ges.NewRepository(store, newCounter,
+		ges.WithMerger(func(_, _ []ges.Event) bool { return false }),
+	)
+	c, err := declining.Load(ctx, "Counter:1")
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	store.events["Counter:1"] = append(store.events["Counter:1"], Deposited{Amount: 1})
+	c.Raise(Deposited{Amount: 2})
+	if err := declining.Save(ctx, c, nil); !errors.Is(err, ges.ErrVersionConflict) {
+		t.Fatalf("expected a version conflict, got %v", err)
+	}
+}
+
+// racingStore is a spyStore where another writer appends a deposit of 10 right
+// before each of the next races appends.
+type racingStore struct {
+	*spyStore
+	races int
+}
+
+func (s *racingStore) Append(ctx context.Context, streamID string, expected int64, events []ges.Event, md ges.Metadata) (int64, error) {
+	if s.races > 0 {
+		s.races--
+		s.mu.Lock()
+		s.events[streamID] = append(s.events[streamID], Deposited{Amount: 10})
+		s.mu.Unlock()
+	}
+	return s.spyStore.Append(ctx, streamID, expected, events, md)
+}
+
+func TestRepository_MergerRetries(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	store := &racingStore{spyStore: newSpyStore()}
+	repo := ges.NewRepository(store, newCounter,
+		ges.WithMerger(func(_, _ []ges.Event) bool { return true }),
+	)
+
+	c, err := repo.Load(ctx, "Counter:1")
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	// The first append and the first retry both lose the race; the second retry wins.
+	store.races = 2
+	c.Raise(Deposited{Amount: 1})
+	if err := repo.Save(ctx, c, nil); err != nil {
+		t.Fatalf("expected the conflicts to be merged, got %v", err)
+	}
+	if c.total != 21 || c.Version() != 3 {
+		t.Fatalf("expected 21 at version 3, got %d at %d", c.total, c.Version())
+	}
+	if events := store.events["Counter:1"]; len(events) != 3 || events[2] != (Deposited{Amount: 1}) {
+		t.Fatalf("expected the deposit after both concurrent ones, got %v", events)
+	}
+
+	// Losing every race gives up with the conflict.
+	store.races = 10
+	c.Raise(Deposited{Amount: 1})
+	if err := repo.Save(ctx, c, nil); !errors.Is(err, ges.ErrVersionConflict) {
+		t.Fatalf("expected a version conflict, got %v", err)
+	}
+}
+
+// txStore is a spyStore with a ReadTransactor that counts its transactions.
+type txStore struct {
+	*spyStore
+	transactions int
+}
+
+type inTxKey struct{}
+
+func (s *txStore) ReadInTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if ctx.Value(inTxKey{}) != nil {
+		return fn(ctx)
+	}
+	s.transactions++
+	return fn(context.WithValue(ctx, inTxKey{}, true))
+}
+
+func (s *txStore) LoadSnapshot(ctx context.Context, streamID string) (ges.Snapshot, error) {
+	if ctx.Value(inTxKey{}) == nil {
+		return ges.Snapshot{}, errors.New("read outside the transaction")
+	}
+	return s.spyStore.LoadSnapshot(ctx, streamID)
+}
+
+func TestRepository_ReadTransaction(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	store := &txStore{spyStore: newSpyStore()}
+	store.events["Counter:1"] = []ges.Event{Deposited{Amount: 1}}
+	store.events["Counter:2"] = []ges.Event{Deposited{Amount: 2}, Deposited{Amount: 3}}
+	repo := ges.NewRepository(store, newCounter, ges.WithReadTransaction())
+
+	counters, err := repo.LoadMany(ctx, "Counter:1", "Counter:2")
+	if err != nil {
+		t.Fatalf("load many failed: %v", err)
+	}
+	if len(counters) != 2 || counters[0].total != 1 || counters[1].total != 5 {
+		t.Fatalf("expected totals 1 and 5, got %v", counters)
+	}
+	if _, err := repo.Load(ctx, "Counter:1"); err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	if store.transactions != 2 {
+		t.Fatalf("expected one transaction per call, got %d", store.transactions)
+	}
+
+	// Without the option, reads stand alone.
+	plain := ges.NewRepository(store, newCounter)
+	if _, err := plain.Load(ctx, "Counter:1"); err == nil {
+		t.Fatalf("expected the read outside a transaction to fail")
+	}
+}
+
+// metaSpy is a spyStore that records snapshot metadata.
+type metaSpy struct{ *spyStore }
+
+func (s metaSpy) SaveSnapshotWithMeta(_ context.Context, streamID string, version int64, state any, md ges.Metadata) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.snapshots[streamID] = ges.Snapshot{Version: version, State: state, Metadata: md, Found: true}
+	return nil
+}
+
+func TestRepository_SnapshotSchemaVersion(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	store := metaSpy{newSpyStore()}
+	store.events["Counter:1"] = []ges.Event{Deposited{Amount: 1}, Deposited{Amount: 2}, Deposited{Amount: 3}}
+	serialize := ges.WithSnapshotSerializer(func(c *counter) any { return c.total })
+
+	v1 := ges.NewRepository(store, newCounter, serialize, ges.WithSnapshotSchemaVersion(1))
+	c, err := v1.Load(ctx, "Counter:1")
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	if err := v1.SaveSnapshot(ctx, c); err != nil {
+		t.Fatalf("save snapshot failed: %v", err)
+	}
+
+	store.loaded = nil
+	if c, err = v1.Load(ctx, "Counter:1"); err != nil || c.total != 6 || store.loaded[0] != 0 {
+		t.Fatalf("expected 6 from the snapshot alone, got %d after loading %v, %v", c.total, store.loaded, err)
+	}
+
+	// After a shape change the old snapshot is a cache miss.
+	v2 := ges.NewRepository(store, newCounter, serialize, ges.WithSnapshotSchemaVersion(2))
+	store.loaded = nil
+	if c, err = v2.Load(ctx, "Counter:1"); err != nil || c.total != 6 || store.loaded[0] != 3 {
+		t.Fatalf("expected 6 from a full replay, got %d after loading %v, %v", c.total, store.loaded, err)
+	}
+
+	if _, found, err := v2.LoadStateOnly(ctx, "Counter:1"); err != nil || found {
+		t.Fatalf("expected the old snapshot state to be a miss, got %v, %v", found, err)
+	}
+	if _, found, err := v1.LoadStateOnly(ctx, "Counter:1"); err != nil || !found {
+		t.Fatalf("expected the snapshot state under its own version, got %v, %v", found, err)
+	}
+
+	// VerifySnapshot honors the version and the serializer too.
+	if err := v1.VerifySnapshot(ctx, "Counter:1"); err != nil {
+		t.Fatalf("expected the snapshot to verify, got %v", err)
+	}
+	if err := store.SaveSnapshotWithMeta(ctx, "Counter:1", 3, 60, ges.Metadata{ges.SnapshotSchemaVersionKey: 1}); err != nil {
+		t.Fatalf("save snapshot failed: %v", err)
+	}
+	var mismatch *ges.SnapshotMismatchError
+	if err := v1.VerifySnapshot(ctx, "Counter:1"); !errors.As(err, &mismatch) || mismatch.Restored != 60 {
+		t.Fatalf("expected a *SnapshotMismatchError, got %v", err)
+	}
+	if err := v2.VerifySnapshot(ctx, "Counter:1"); !errors.Is(err, ges.ErrSnapshotNotVerified) {
+		t.Fatalf("expected a snapshot of another version to be ErrSnapshotNotVerified, got %v", err)
+	}
+
+	// Without the old snapshot, a truncated stream has nothing to rebuild from.
+	truncated := ges.NewRepository(truncatedStore{store.spyStore, 2}, newCounter, serialize, ges.WithSnapshotSchemaVersion(2))
+	if _, err := truncated.Load(ctx, "Counter:1"); !errors.Is(err, ges.ErrStreamTruncated) {
+		t.Fatalf("expected ErrStreamTruncated after a schema bump, got %v", err)
+	}
+
+	// A store that cannot record the version cannot take versioned snapshots.
+	plain := ges.NewRepository(newSpyStore(), newCounter, serialize, ges.WithSnapshotSchemaVersion(1))
+	if err := plain.SaveSnapshot(ctx, c); !errors.Is(err, ges.ErrSnapshotUnsupported) {
+		t.Fatalf("expected ErrSnapshotUnsupported, got %v", err)
+	}
+}
+
+func TestRepository_SnapshotPolicyStats(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	store := newSpyStore()
+	store.events["Counter:hot"] = []ges.Event{Deposited{Amount: 1}, Deposited{Amount: 2}}
+	var seen []ges.ReplayStats
+	repo := ges.NewRepository(store, newCounter, ges.WithSnapshotPolicy(ges.SnapshotPolicyFunc(func(stats ges.ReplayStats) bool {
+		seen = append(seen, stats)
+		return false
+	})))
+
+	c, err := repo.Load(ctx, "Counter:hot")
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	// Loading many other streams in between evicts the stats of the first one.
+	for i := range 1024 {
+		if _, err := repo.Load(ctx, fmt.Sprintf("Counter:%d", i)); err != nil {
+			t.Fatalf("load failed: %v", err)
+		}
+	}
+	c.Raise(Deposited{Amount: 1})
+	if err := repo.Save(ctx, c, nil); err != nil {
+		t.Fatalf("save failed: %v", err)
+	}
+	if got := seen[0]; got.Replayed != 0 || got.EventsSinceSnapshot != 1 {
+		t.Fatalf("expected the stats of the evicted stream to be forgotten, got %+v", got)
+	}
+
+	if c, err = repo.Load(ctx, "Counter:hot"); err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	c.Raise(Deposited{Amount: 1})
+	if err := repo.Save(ctx, c, nil); err != nil {
+		t.Fatalf("save failed: %v", err)
+	}
+	if got := seen[1]; got.Replayed != 3 || got.EventsSinceSnapshot != 4 {
+		t.Fatalf("expected the stats of the last Load, got %+v", got)
+	}
+}
+
+func TestRepository_SnapshotStore(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	events, snapshots := newSpyStore(), newSpyStore()
+	events.events["Counter:1"] = []ges.Event{Deposited{Amount: 1}, Deposited{Amount: 2}, Deposited{Amount: 3}}
+	repo := ges.NewRepository(events, newCounter,
+		ges.WithSnapshotSerializer(func(c *counter) any { return c.total }),
+		ges.WithSnapshotStore(snapshots),
+	)
+
+	c, err := repo.Load(ctx, "Counter:1")
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	if err := repo.SaveSnapshot(ctx, c); err != nil {
+		t.Fatalf("save snapshot failed: %v", err)
+	}
+	if len(events.snapshots) != 0 || snapshots.snapshots["Counter:1"].Version != 3 {
+		t.Fatalf("expected the snapshot in the snapshot store only, got %v and %v", events.snapshots, snapshots.snapshots)
+	}
+
+	events.loaded = nil
+	if c, err = repo.Load(ctx, "Counter:1"); err != nil || c.total != 6 || events.loaded[0] != 0 {
+		t.Fatalf("expected 6 from the separate snapshot, got %d after loading %v, %v", c.total, events.loaded, err)
+	}
+}
+
+func TestRepository_AggregatePool(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	store := newSpyStore()
+	repo := ges.NewRepository(store, newCounter, ges.WithAggregatePool())
+
+	for i, streamID := range []string{"Counter:1", "Counter:2", "Counter:1"} {
+		c, err := repo.Load(ctx, streamID)
+		if err != nil {
+			t.Fatalf("load failed: %v", err)
+		}
+		if c.StreamID() != streamID || c.Version() != int64(i/2) || c.total != i/2*5 {
+			t.Fatalf("load %d: expected a clean %s, got %s@%d with total %d", i, streamID, c.StreamID(), c.Version(), c.total)
+		}
+		c.Raise(Deposited{Amount: 5})
+		if err := repo.Save(ctx, c, nil); err != nil {
+			t.Fatalf("save failed: %v", err)
+		}
+		repo.Release(c)
+	}
+}
+
+// BenchmarkRepository_Load measures the allocations of a load/save cycle with and
+// without reusing aggregates.
+func BenchmarkRepository_Load(b *testing.B) {
+	for _, bc := range []struct {
+		name string
+		opts []ges.RepositoryOption
+	}{
+		{"factory", nil},
+		{"pool", []ges.RepositoryOption{ges.WithAggregatePool()}},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			ctx := b.Context()
+			repo := ges.NewRepository(newSpyStore(), newCounter, bc.opts...)
+			b.ReportAllocs()
+			for b.Loop() {
+				c, err := repo.Load(ctx, "Counter:bench")
+				if err != nil {
+					b.Fatal(err)
+				}
+				repo.Release(c)
+			}
+		})
+	}
+}
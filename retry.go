@@ -0,0 +1,108 @@
+package ges
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"time"
+)
+
+// ErrRetriesExhausted is returned by RunWithRetry when every attempt ended in a
+// version conflict. The last conflict is wrapped as well, so both
+// errors.Is(err, ErrRetriesExhausted) and errors.Is(err, ErrVersionConflict) hold.
+var ErrRetriesExhausted = fmt.Errorf("ges: retries exhausted")
+
+// ErrRetryAborted is returned by RunWithRetry when ctx was cancelled, or its deadline
+// would pass before the next attempt, while retrying a version conflict. The context
+// error and the last conflict are wrapped as well.
+var ErrRetryAborted = fmt.Errorf("ges: retry aborted")
+
+const defaultRetryAttempts = 3
+
+// RetryOption configures RunWithRetry.
+type RetryOption func(*retryConfig)
+
+type retryConfig struct {
+	attempts int
+	backoff  func(attempt int) time.Duration
+	jitter   bool
+}
+
+// WithRetryAttempts sets the maximum number of attempts, including the first one.
+// Values below 1 are treated as 1 (no retries).
+func WithRetryAttempts(n int) RetryOption {
+	return func(c *retryConfig) { c.attempts = max(n, 1) }
+}
+
+// WithRetryBackoff sets the delay before each retry. attempt is the number of
+// attempts made so far (1 before the first retry).
+func WithRetryBackoff(backoff func(attempt int) time.Duration) RetryOption {
+	return func(c *retryConfig) { c.backoff = backoff }
+}
+
+// WithRetryJitter sets whether each delay is drawn at random between zero and the
+// backoff ("full jitter"), so that writers that conflicted together do not retry in
+// lockstep. It is enabled by default.
+func WithRetryJitter(enabled bool) RetryOption {
+	return func(c *retryConfig) { c.jitter = enabled }
+}
+
+// ExponentialBackoff returns a backoff that starts at base and doubles on every
+// attempt, capped at maxDelay.
+func ExponentialBackoff(base, maxDelay time.Duration) func(attempt int) time.Duration {
+	return func(attempt int) time.Duration {
+		d := base
+		for i := 1; i < attempt && d < maxDelay; i++ {
+			d *= 2
+		}
+		return min(d, maxDelay)
+	}
+}
+
+// RunWithRetry runs fn and re-runs it whenever it fails with a version conflict.
+//
+// fn should perform the whole load → handle → append cycle: a conflict means another
+// writer advanced the stream, so the command has to be re-decided against fresh state
+// rather than re-appended blindly. Any other error is returned immediately.
+//
+// After the configured number of attempts, RunWithRetry returns an error wrapping
+// both ErrRetriesExhausted and the last conflict. If ctx is done while waiting, or
+// its deadline is closer than the next delay, it gives up early with an error
+// wrapping ErrRetryAborted, the context error and the last conflict.
+func RunWithRetry(ctx context.Context, fn func(ctx context.Context) error, opts ...RetryOption) error {
+	cfg := retryConfig{
+		attempts: defaultRetryAttempts,
+		backoff:  ExponentialBackoff(10*time.Millisecond, 500*time.Millisecond),
+		jitter:   true,
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := fn(ctx)
+		if err == nil || !errors.Is(err, ErrVersionConflict) {
+			return err
+		}
+		if attempt >= cfg.attempts {
+			return fmt.Errorf("%w after %d attempts: %w", ErrRetriesExhausted, attempt, err)
+		}
+
+		delay := cfg.backoff(attempt)
+		if cfg.jitter && delay > 0 {
+			delay = rand.N(delay + 1)
+		}
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+			return fmt.Errorf("%w after %d attempts: %w: %w", ErrRetryAborted, attempt, context.DeadlineExceeded, err)
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("%w after %d attempts: %w: %w", ErrRetryAborted, attempt, ctx.Err(), err)
+		case <-timer.C:
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package ges_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mickamy/go-event-sourcing"
+)
+
+func TestRunWithRetry(t *testing.T) {
+	t.Parallel()
+
+	conflict := &ges.VersionConflictError{StreamID: "Stream:1", ExpectedVersion: 1, ActualVersion: 2}
+	noBackoff := ges.WithRetryBackoff(func(int) time.Duration { return 0 })
+
+	t.Run("retries conflicts until success", func(t *testing.T) {
+		t.Parallel()
+		calls := 0
+		err := ges.RunWithRetry(t.Context(), func(context.Context) error {
+			calls++
+			if calls < 3 {
+				return conflict
+			}
+			return nil
+		}, ges.WithRetryAttempts(3), noBackoff)
+		if err != nil {
+			t.Fatalf("expected success, got %v", err)
+		}
+		if calls != 3 {
+			t.Fatalf("expected 3 calls, got %d", calls)
+		}
+	})
+
+	t.Run("returns other errors immediately", func(t *testing.T) {
+		t.Parallel()
+		errBoom := errors.New("boom")
+		calls := 0
+		err := ges.RunWithRetry(t.Context(), func(context.Context) error {
+			calls++
+			return errBoom
+		}, noBackoff)
+		if !errors.Is(err, errBoom) || calls != 1 {
+			t.Fatalf("expected boom after 1 call, got %v after %d calls", err, calls)
+		}
+	})
+
+	t.Run("surfaces exhaustion", func(t *testing.T) {
+		t.Parallel()
+		calls := 0
+		err := ges.RunWithRetry(t.Context(), func(context.Context) error {
+			calls++
+			return conflict
+		}, ges.WithRetryAttempts(2), noBackoff)
+		if !errors.Is(err, ges.ErrRetriesExhausted) || !errors.Is(err, ges.ErrVersionConflict) {
+			t.Fatalf("expected exhausted conflict, got %v", err)
+		}
+		if calls != 2 {
+			t.Fatalf("expected 2 calls, got %d", calls)
+		}
+	})
+
+	t.Run("gives up before the deadline", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := context.WithTimeout(t.Context(), 50*time.Millisecond)
+		defer cancel()
+		calls := 0
+		err := ges.RunWithRetry(ctx, func(context.Context) error {
+			calls++
+			return conflict
+		}, ges.WithRetryBackoff(func(int) time.Duration { return time.Hour }), ges.WithRetryJitter(false))
+		if !errors.Is(err, ges.ErrRetryAborted) || !errors.Is(err, context.DeadlineExceeded) || !errors.Is(err, ges.ErrVersionConflict) {
+			t.Fatalf("expected an aborted conflict, got %v", err)
+		}
+		if errors.Is(err, ges.ErrRetriesExhausted) || calls != 1 {
+			t.Fatalf("expected to give up after 1 call without exhausting, got %v after %d calls", err, calls)
+		}
+	})
+
+	t.Run("stops when cancelled", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := context.WithCancel(t.Context())
+		err := ges.RunWithRetry(ctx, func(context.Context) error {
+			cancel()
+			return conflict
+		}, ges.WithRetryBackoff(func(int) time.Duration { return time.Hour }), ges.WithRetryJitter(false))
+		if !errors.Is(err, ges.ErrRetryAborted) || !errors.Is(err, context.Canceled) {
+			t.Fatalf("expected a cancelled retry, got %v", err)
+		}
+	})
+}
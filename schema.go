@@ -0,0 +1,46 @@
+package ges
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// PayloadSchema validates an event payload decoded from JSON, with numbers decoded
+// as json.Number. The *Schema of github.com/santhosh-tekuri/jsonschema satisfies it,
+// so compiled JSON Schemas can be registered with the stores' WithSchemaRegistry
+// option as they are.
+type PayloadSchema interface {
+	Validate(v any) error
+}
+
+// ValidatePayload checks payload, encoded as contentType for an event of eventType,
+// against the schema registered for eventType in schemas. It returns an error
+// wrapping ErrSchemaViolation if the payload does not conform, and nil if eventType
+// has no schema or contentType is not JSON ("application/json" or a "+json" type).
+// Stores call it on the output of the codec before appending, except in AppendRaw
+// (see RawAppender).
+func ValidatePayload(schemas map[string]PayloadSchema, eventType, contentType string, payload []byte) error {
+	schema := schemas[eventType]
+	if schema == nil || !isJSONContentType(contentType) {
+		return nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(payload))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return fmt.Errorf("%w: %q: %w", ErrSchemaViolation, eventType, err)
+	}
+	if err := schema.Validate(v); err != nil {
+		return fmt.Errorf("%w: %q: %w", ErrSchemaViolation, eventType, err)
+	}
+	return nil
+}
+
+// isJSONContentType reports whether contentType, without parameters, is a JSON media type.
+func isJSONContentType(contentType string) bool {
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	mediaType = strings.TrimSpace(mediaType)
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
@@ -0,0 +1,57 @@
+package ges_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/mickamy/go-event-sourcing"
+)
+
+// positiveAmount is a PayloadSchema requiring a positive numeric "Amount".
+type positiveAmount struct{}
+
+func (positiveAmount) Validate(v any) error {
+	obj, ok := v.(map[string]any)
+	if !ok {
+		return fmt.Errorf("expected an object, got %T", v)
+	}
+	n, ok := obj["Amount"].(json.Number)
+	if !ok {
+		return errors.New("Amount must be a number")
+	}
+	if f, err := n.Float64(); err != nil || f <= 0 {
+		return errors.New("Amount must be positive")
+	}
+	return nil
+}
+
+func TestValidatePayload(t *testing.T) {
+	t.Parallel()
+
+	schemas := map[string]ges.PayloadSchema{"Deposited": positiveAmount{}}
+	tests := []struct {
+		name        string
+		eventType   string
+		contentType string
+		payload     string
+		wantErr     bool
+	}{
+		{name: "valid", eventType: "Deposited", contentType: "application/json", payload: `{"Amount":5}`},
+		{name: "violation", eventType: "Deposited", contentType: "application/json", payload: `{"Amount":-1}`, wantErr: true},
+		{name: "malformed", eventType: "Deposited", contentType: "application/json", payload: `{"Amount":`, wantErr: true},
+		{name: "json suffix", eventType: "Deposited", contentType: "application/vnd.deposit+json; charset=utf-8", payload: `{}`, wantErr: true},
+		{name: "no schema", eventType: "Withdrawn", contentType: "application/json", payload: `{}`},
+		{name: "not json", eventType: "Deposited", contentType: "application/cbor", payload: "\xa0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			err := ges.ValidatePayload(schemas, tt.eventType, tt.contentType, []byte(tt.payload))
+			if got := errors.Is(err, ges.ErrSchemaViolation); got != tt.wantErr || (err != nil && !got) {
+				t.Fatalf("expected violation %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
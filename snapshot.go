@@ -1,14 +1,64 @@
 package ges
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
 	"time"
 )
 
 // Snapshot represents the current persisted state of an aggregate
 // at a specific version, optionally loaded from storage.
 type Snapshot struct {
-	State   any       // The deserialized state
-	Version int64     // Aggregate version at which the snapshot was taken
-	Found   bool      // Whether a snapshot exists
-	At      time.Time // Timestamp of when it was taken
+	State    any       // The deserialized state
+	Version  int64     // Aggregate version at which the snapshot was taken
+	Found    bool      // Whether a snapshot exists
+	At       time.Time // Timestamp of when it was taken
+	Metadata Metadata  // Context under which it was taken (nil if none was recorded)
+}
+
+// SnapshotSchemaVersionKey is the snapshot Metadata key under which Repository
+// records the snapshot schema version (see SnapshotSchemaVersioner).
+const SnapshotSchemaVersionKey = "snapshot_schema_version"
+
+// SnapshotMetadataSaver is implemented by stores that can persist Metadata
+// alongside a snapshot, e.g. the correlation or trace ID of the operation
+// that triggered it. The metadata is returned from LoadSnapshot.
+//
+// SaveSnapshot is equivalent to SaveSnapshotWithMeta with nil metadata.
+type SnapshotMetadataSaver interface {
+	SaveSnapshotWithMeta(ctx context.Context, streamID string, version int64, state any, md Metadata) error
+}
+
+// SnapshotHistoryLoader is implemented by stores that can return snapshots older
+// than the latest one, which allows rebuilding an aggregate as of a past version.
+type SnapshotHistoryLoader interface {
+	// LoadSnapshotBefore returns the newest snapshot of streamID whose Version is at
+	// most maxVersion. If there is none, the returned Snapshot has Found=false.
+	LoadSnapshotBefore(ctx context.Context, streamID string, maxVersion int64) (Snapshot, error)
+}
+
+// DecodeState converts snapshot state into T.
+// State that already is a T (or *T) is returned as-is, which is what in-memory stores
+// hand back. Anything else, such as the map[string]any produced by JSON-backed stores,
+// is converted by a JSON round trip.
+func DecodeState[T any](state any) (T, error) {
+	switch s := state.(type) {
+	case T:
+		return s, nil
+	case *T:
+		if s != nil {
+			return *s, nil
+		}
+	}
+
+	var out T
+	raw, err := json.Marshal(state)
+	if err != nil {
+		return out, fmt.Errorf("ges: failed to encode snapshot state: %w", err)
+	}
+	if err := json.Unmarshal(raw, &out); err != nil {
+		return out, fmt.Errorf("ges: failed to decode snapshot state: %w", err)
+	}
+	return out, nil
 }
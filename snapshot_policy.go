@@ -0,0 +1,110 @@
+package ges
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// ReplayStats describes how expensive it is to rehydrate a stream. The Repository
+// measures it during Load and completes it with the events appended by Save.
+type ReplayStats struct {
+	// EventsSinceSnapshot is the number of events recorded after the latest snapshot,
+	// i.e. the events the next Load will have to replay.
+	EventsSinceSnapshot int
+	// Replayed is the number of events applied by the last Load.
+	Replayed int
+	// Duration is the wall-clock time of the last Load, snapshot included.
+	Duration time.Duration
+}
+
+// SnapshotPolicy decides whether the Repository should take a snapshot after Save.
+type SnapshotPolicy interface {
+	ShouldSnapshot(stats ReplayStats) bool
+}
+
+// SnapshotPolicyFunc adapts a function to a SnapshotPolicy.
+type SnapshotPolicyFunc func(stats ReplayStats) bool
+
+// ShouldSnapshot calls f(stats).
+func (f SnapshotPolicyFunc) ShouldSnapshot(stats ReplayStats) bool {
+	return f(stats)
+}
+
+// CostBasedPolicy returns a SnapshotPolicy that snapshots once rehydrating the stream
+// is expected to take at least target. The per-event cost is estimated from the last
+// Load, so streams with cheap events are snapshotted less often than streams with
+// expensive ones. A Load that already took target or longer triggers a snapshot as
+// well.
+//
+// Streams whose last Load replayed no events have no cost estimate yet; they are
+// snapshotted only after a Load has measured some replay.
+func CostBasedPolicy(target time.Duration) SnapshotPolicy {
+	return SnapshotPolicyFunc(func(stats ReplayStats) bool {
+		if stats.EventsSinceSnapshot == 0 {
+			return false
+		}
+		if stats.Duration >= target {
+			return true
+		}
+		if stats.Replayed == 0 {
+			return false
+		}
+		perEvent := stats.Duration / time.Duration(stats.Replayed)
+		return perEvent*time.Duration(stats.EventsSinceSnapshot) >= target
+	})
+}
+
+// replayStatsCapacity bounds the number of streams whose ReplayStats a Repository
+// keeps between Load and Save. The least recently loaded are forgotten first; Save
+// then consults the policy with only the events it appends.
+const replayStatsCapacity = 1024
+
+// statsCache holds the ReplayStats of the most recently loaded streams, up to
+// replayStatsCapacity of them. The zero value is ready to use.
+type statsCache struct {
+	mu      sync.Mutex
+	order   list.List // of *statsEntry, most recently loaded first
+	entries map[string]*list.Element
+}
+
+type statsEntry struct {
+	streamID string
+	stats    ReplayStats
+}
+
+// put records stats for streamID, evicting the least recently loaded stream if the
+// cache is full.
+func (c *statsCache) put(streamID string, stats ReplayStats) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.entries == nil {
+		c.entries = make(map[string]*list.Element)
+	}
+	if el, ok := c.entries[streamID]; ok {
+		el.Value.(*statsEntry).stats = stats
+		c.order.MoveToFront(el)
+		return
+	}
+	c.entries[streamID] = c.order.PushFront(&statsEntry{streamID: streamID, stats: stats})
+	if c.order.Len() > replayStatsCapacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*statsEntry).streamID)
+	}
+}
+
+// take removes and returns the stats of streamID, or zero stats if there are none.
+func (c *statsCache) take(streamID string) ReplayStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.entries[streamID]
+	if !ok {
+		return ReplayStats{}
+	}
+	c.order.Remove(el)
+	delete(c.entries, streamID)
+	return el.Value.(*statsEntry).stats
+}
@@ -0,0 +1,33 @@
+package ges_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mickamy/go-event-sourcing"
+)
+
+func TestCostBasedPolicy(t *testing.T) {
+	t.Parallel()
+
+	policy := ges.CostBasedPolicy(10 * time.Millisecond)
+	tests := []struct {
+		name  string
+		stats ges.ReplayStats
+		want  bool
+	}{
+		{"nothing to replay", ges.ReplayStats{Duration: time.Second}, false},
+		{"slow load", ges.ReplayStats{EventsSinceSnapshot: 1, Duration: 10 * time.Millisecond}, true},
+		{"no estimate yet", ges.ReplayStats{EventsSinceSnapshot: 100, Duration: time.Millisecond}, false},
+		{"below target", ges.ReplayStats{EventsSinceSnapshot: 9, Replayed: 5, Duration: 5 * time.Millisecond}, false},
+		{"estimated over target", ges.ReplayStats{EventsSinceSnapshot: 10, Replayed: 5, Duration: 5 * time.Millisecond}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := policy.ShouldSnapshot(tt.stats); got != tt.want {
+				t.Fatalf("ShouldSnapshot(%+v) = %v, want %v", tt.stats, got, tt.want)
+			}
+		})
+	}
+}
@@ -2,6 +2,7 @@ package ges
 
 import (
 	"context"
+	"iter"
 )
 
 // EventStore defines the interface for persisting and retrieving events
@@ -16,6 +17,11 @@ import (
 type EventStore interface {
 	// Load returns all events for the given stream starting from a specific version.
 	// The returned slice must be ordered by version ascending.
+	//
+	// The returned version is the stream's current version, also when no events
+	// follow fromVersion. A stream without events yields nil events and version 0;
+	// since versions start at 1, version 0 always means the stream does not exist.
+	// ExistenceChecker asks the same question without loading anything.
 	Load(ctx context.Context, streamID string, fromVersion int64) ([]Event, int64, error)
 
 	// Append writes a batch of events to the store.
@@ -28,8 +34,20 @@ type EventStore interface {
 	//
 	// Implementations must ensure atomicity — either all events are appended,
 	// or none are.
+	//
+	// Appending an empty batch is a no-op that returns expectedVersion without
+	// touching the store; use VersionChecker.EnsureVersion to assert a version.
+	// A negative expectedVersion is rejected with ErrInvalidExpectedVersion, and a
+	// batch containing a nil event with ErrNilEvent.
 	Append(ctx context.Context, streamID string, expectedVersion int64, events []Event, md Metadata) (int64, error)
 
+	SnapshotStore
+}
+
+// SnapshotStore persists aggregate snapshots. Every EventStore is one, and
+// WithSnapshotStore lets a Repository keep its snapshots in a separate one instead,
+// such as a cache, while the events stay in the EventStore.
+type SnapshotStore interface {
 	// SaveSnapshot stores a serialized representation of the aggregate’s current state.
 	// This is an optional optimization to avoid replaying the entire event history
 	// when reloading aggregates. Snapshots are safe to treat as caches — failure
@@ -42,3 +60,188 @@ type EventStore interface {
 	// and zero values for State and Version.
 	LoadSnapshot(ctx context.Context, streamID string) (Snapshot, error)
 }
+
+// EnvelopeAppender is implemented by stores that can persist per-event headers
+// and occurrence times alongside the batch-level Metadata.
+type EnvelopeAppender interface {
+	// AppendEnvelopes behaves like EventStore.Append, with each envelope's Event
+	// appended in order and its Headers and OccurredAt persisted with it.
+	AppendEnvelopes(ctx context.Context, streamID string, expectedVersion int64, envelopes []Envelope, md Metadata) (int64, error)
+}
+
+// VersionChecker is implemented by stores that can assert a stream's version
+// without writing to it.
+type VersionChecker interface {
+	// EnsureVersion returns nil if the stream is currently at expectedVersion and a
+	// *VersionConflictError otherwise. A stream without events is at version 0.
+	EnsureVersion(ctx context.Context, streamID string, expectedVersion int64) error
+}
+
+// ExistenceChecker is implemented by stores that can tell whether a stream exists
+// without loading it.
+type ExistenceChecker interface {
+	// Exists reports whether streamID has at least one event. Stream metadata alone
+	// does not make a stream exist.
+	Exists(ctx context.Context, streamID string) (bool, error)
+}
+
+// StreamIterator is implemented by stores that can stream the events of a single
+// stream without buffering the whole stream in memory.
+type StreamIterator interface {
+	// LoadIter yields the events of streamID strictly after fromVersion, ordered
+	// by version ascending. If an error occurs, it is yielded with a zero
+	// StoredEvent and iteration stops.
+	LoadIter(ctx context.Context, streamID string, fromVersion int64) iter.Seq2[StoredEvent, error]
+}
+
+// RangeLoader is implemented by stores that can load a bounded slice of a stream.
+type RangeLoader interface {
+	// LoadRange returns the events of streamID with fromVersion < Version <= toVersion,
+	// ordered by version ascending.
+	LoadRange(ctx context.Context, streamID string, fromVersion, toVersion int64) ([]StoredEvent, error)
+}
+
+// AutoAppender is implemented by stores that can append after whatever the current
+// version of a stream is, for callers that do not track versions.
+type AutoAppender interface {
+	// AppendAuto appends events after the current version of streamID and returns the
+	// new version. It gives up the optimistic concurrency guard of Append: events are
+	// appended even if the stream changed since the caller last read it, so it suits
+	// single-writer streams and prototypes rather than aggregates enforcing invariants.
+	// It only fails with a version conflict when another writer appends between the
+	// store reading the version and inserting the events.
+	AppendAuto(ctx context.Context, streamID string, events []Event, md Metadata) (int64, error)
+}
+
+// PositionAppender is implemented by GlobalReaders that can report where an append
+// landed in the global order, e.g. to wait for a read replica with WaitForPosition.
+type PositionAppender interface {
+	// AppendWithPosition is like Append but also returns the Position of the last
+	// event it appended, or 0 if it appended none.
+	AppendWithPosition(ctx context.Context, streamID string, expectedVersion int64, events []Event, md Metadata) (version, position int64, err error)
+}
+
+// Truncater is implemented by stores that can delete the head of a stream once a
+// snapshot makes it unnecessary for rehydration.
+type Truncater interface {
+	// TruncateBefore deletes the events of streamID with a version lower than version.
+	// It fails with ErrTruncateUnsafe unless the stream's latest snapshot is at or
+	// after version, so Rehydrate still finds every event it needs. The stream keeps
+	// its version. Rebuilding a version before the truncation point (see LoadAt) is
+	// no longer possible afterwards, and rebuilding without that snapshot fails with
+	// ErrStreamTruncated.
+	TruncateBefore(ctx context.Context, streamID string, version int64) error
+}
+
+// LastLoader is implemented by stores that can read the most recent events of a
+// stream without loading it from the start, e.g. for activity views.
+type LastLoader interface {
+	// LoadLast returns the last n events of streamID in version order, or fewer if
+	// the stream is shorter.
+	LoadLast(ctx context.Context, streamID string, n int) ([]StoredEvent, error)
+}
+
+// PageLoader is implemented by stores that can page through a stream by version,
+// e.g. for event log viewers that scroll through long streams without loading them.
+type PageLoader interface {
+	// LoadPage returns up to limit events of streamID, with their metadata, that follow
+	// afterVersion in the direction of travel: versions above afterVersion in
+	// ascending order, or with desc, versions below afterVersion in descending order,
+	// where an afterVersion of 0 starts at the latest event. hasMore reports whether
+	// more events follow the page; pass the Version of its last event as afterVersion
+	// to load the next one.
+	LoadPage(ctx context.Context, streamID string, afterVersion int64, limit int, desc bool) (events []StoredEvent, hasMore bool, err error)
+}
+
+// MultiLoader is implemented by stores that can load several streams in one round trip.
+type MultiLoader interface {
+	// LoadMany returns, for every stream ID in fromVersions, the events strictly
+	// after its from-version, ordered by version ascending. Every requested stream
+	// has an entry in the result; streams without such events map to an empty slice.
+	LoadMany(ctx context.Context, fromVersions map[string]int64) (map[string][]StoredEvent, error)
+}
+
+// GlobalReader is implemented by stores that assign every appended event a
+// store-wide Position, allowing all streams to be read in append order.
+type GlobalReader interface {
+	// ReadAll returns up to limit events whose Position is strictly greater than
+	// fromPosition, ordered by Position ascending. An empty result means the
+	// reader has caught up with the head of the store. Options such as
+	// WithEventTypes narrow the result; limit applies to the filtered events.
+	ReadAll(ctx context.Context, fromPosition int64, limit int, opts ...ReadOption) ([]StoredEvent, error)
+}
+
+// RawLoader is implemented by stores that can return events without decoding
+// their payloads, e.g. for archival or for moving data between stores.
+type RawLoader interface {
+	// LoadRaw is like EventStore.Load, except that each StoredEvent.Payload holds
+	// the encoded payload as a []byte.
+	LoadRaw(ctx context.Context, streamID string, fromVersion int64) ([]StoredEvent, error)
+}
+
+// RawRangeLoader is implemented by RawLoaders that can return a bounded slice of a
+// stream without decoding it, which lets Sync and Backup read exactly the events
+// they copy instead of the rest of the stream.
+type RawRangeLoader interface {
+	// LoadRawRange is like LoadRaw, restricted to fromVersion < Version <= toVersion.
+	LoadRawRange(ctx context.Context, streamID string, fromVersion, toVersion int64) ([]StoredEvent, error)
+}
+
+// RawAppender is implemented by stores that can persist already encoded events,
+// the counterpart of RawLoader.
+type RawAppender interface {
+	// AppendRaw appends events whose Payload is a []byte produced by the registered
+	// codec of their Type. Metadata, Headers, OccurredAt and RecordedAt are stored as
+	// given (zero times mean now) and the versions continue from expectedVersion.
+	// Metadata extractors and append interceptors are not applied: the events are
+	// restored, not recorded. For the same reason payload schemas (see
+	// PayloadSchema) are not checked. A stream without events may start after a
+	// positive expectedVersion if it has a snapshot beyond it, which restores a
+	// stream that was truncated with Truncater.TruncateBefore.
+	AppendRaw(ctx context.Context, streamID string, expectedVersion int64, events []StoredEvent) (int64, error)
+}
+
+// StreamMetadataStore is implemented by stores that can keep stream-level metadata,
+// such as a creation time, a soft-delete flag or a schema version for the whole
+// stream, separately from the events and snapshots.
+type StreamMetadataStore interface {
+	// SetStreamMetadata replaces the metadata of streamID with md. The stream does
+	// not need to have events.
+	SetStreamMetadata(ctx context.Context, streamID string, md Metadata) error
+
+	// GetStreamMetadata returns the metadata of streamID, or nil if none was set.
+	GetStreamMetadata(ctx context.Context, streamID string) (Metadata, error)
+}
+
+// StoreStats summarizes the contents of a store, e.g. for capacity planning.
+type StoreStats struct {
+	Events    int64 // stored events, excluding truncated ones
+	Streams   int64 // streams with at least one stored event
+	Snapshots int64 // streams with a snapshot
+
+	// Categories counts the stored events per stream category (see StreamID.Category).
+	Categories map[string]int64
+}
+
+// StatsReporter is implemented by stores that can summarize their contents.
+type StatsReporter interface {
+	// Stats counts the events, streams and snapshots of the store. It may scan the
+	// whole store, so it suits dashboards and admin pages, not request paths.
+	Stats(ctx context.Context) (StoreStats, error)
+}
+
+// ReadTransactor is implemented by stores that can make several reads see the same
+// consistent state of the store, such as a repeatable-read database transaction.
+type ReadTransactor interface {
+	// ReadInTransaction runs fn so that the reads made with the ctx passed to it all
+	// see the same state of the store. Nested calls join the outer one.
+	ReadInTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// AppendInterceptor is invoked by a store before any events are persisted.
+//
+// It receives the target stream, the events about to be written, and the
+// effective Metadata (after any context-derived metadata has been merged).
+// Returning an error aborts the append; nothing is written. Interceptors may
+// enrich md in place, as the store always passes a map it owns.
+type AppendInterceptor func(ctx context.Context, streamID string, events []Event, md Metadata) error
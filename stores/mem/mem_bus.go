@@ -0,0 +1,122 @@
+package mem
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"github.com/mickamy/go-event-sourcing"
+)
+
+// defaultSubscriberBuffer is the channel capacity of a subscriber unless
+// WithSubscriberBuffer says otherwise.
+const defaultSubscriberBuffer = 64
+
+// Overflow decides what an append does when a subscriber's channel is full.
+type Overflow int
+
+const (
+	// OverflowBlock makes the append wait until the subscriber has room (the default).
+	OverflowBlock Overflow = iota
+	// OverflowDrop discards the event for that subscriber instead of waiting.
+	OverflowDrop
+)
+
+// WithSubscriberBuffer sets the channel capacity of subscribers created with Subscribe.
+func WithSubscriberBuffer(n int) Option {
+	return func(s *Store) { s.bus.buffer = max(n, 0) }
+}
+
+// WithSubscriberOverflow sets what happens when a subscriber's channel is full.
+func WithSubscriberOverflow(o Overflow) Option {
+	return func(s *Store) { s.bus.overflow = o }
+}
+
+// Subscribe returns a channel receiving every event appended to the store after the
+// call, in position order. Events are delivered before Append returns, so a test can
+// append and then receive without polling. The channel is closed once ctx is done.
+//
+// With OverflowBlock, an append waits for every subscriber to have room; a subscriber
+// must therefore not append to the store from the goroutine that drains its channel
+// while the channel is full.
+func (s *Store) Subscribe(ctx context.Context) <-chan ges.StoredEvent {
+	return s.bus.subscribe(ctx)
+}
+
+// bus fans appended events out to subscribers.
+type bus struct {
+	buffer   int
+	overflow Overflow
+
+	deliver sync.Mutex // held while delivering, so events are delivered in order
+
+	mu    sync.Mutex // guards subs and queue
+	subs  []*subscriber
+	queue []ges.StoredEvent
+}
+
+type subscriber struct {
+	ch   chan ges.StoredEvent
+	done <-chan struct{}
+}
+
+func (b *bus) subscribe(ctx context.Context) <-chan ges.StoredEvent {
+	sub := &subscriber{ch: make(chan ges.StoredEvent, b.buffer), done: ctx.Done()}
+
+	b.mu.Lock()
+	b.subs = append(b.subs, sub)
+	b.mu.Unlock()
+
+	context.AfterFunc(ctx, func() {
+		// Deliveries to sub give up once ctx is done; wait for them before closing.
+		b.deliver.Lock()
+		defer b.deliver.Unlock()
+
+		b.mu.Lock()
+		b.subs = slices.DeleteFunc(b.subs, func(s *subscriber) bool { return s == sub })
+		b.mu.Unlock()
+		close(sub.ch)
+	})
+	return sub.ch
+}
+
+// enqueue queues events for delivery. It is called with the store lock held, so
+// the queue is in position order.
+func (b *bus) enqueue(events []*storedEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if len(b.subs) == 0 {
+		return
+	}
+	for _, e := range events {
+		b.queue = append(b.queue, e.toStored())
+	}
+}
+
+// flush delivers the queued events. It is called after the store lock is released;
+// when it returns, every event enqueued before the call has been delivered.
+func (b *bus) flush() {
+	b.deliver.Lock()
+	defer b.deliver.Unlock()
+
+	b.mu.Lock()
+	queue, subs := b.queue, slices.Clone(b.subs)
+	b.queue = nil
+	b.mu.Unlock()
+
+	for _, ev := range queue {
+		for _, sub := range subs {
+			if b.overflow == OverflowDrop {
+				select {
+				case sub.ch <- ev:
+				default:
+				}
+				continue
+			}
+			select {
+			case sub.ch <- ev:
+			case <-sub.done:
+			}
+		}
+	}
+}
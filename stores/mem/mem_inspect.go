@@ -0,0 +1,55 @@
+package mem
+
+import (
+	"context"
+	"maps"
+	"slices"
+
+	"github.com/mickamy/go-event-sourcing"
+)
+
+// StreamIDs returns the IDs of every stream with events, sorted. Together with
+// EventCount and SnapshotVersion it lets tests and debugging tools check what was
+// written without going through Load.
+func (s *Store) StreamIDs() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return slices.Sorted(maps.Keys(s.streams))
+}
+
+// EventCount returns the number of events stored for streamID. After TruncateBefore
+// it is less than the stream's version.
+func (s *Store) EventCount(streamID string) int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.streams[streamID])
+}
+
+// SnapshotVersion returns the version of the latest snapshot of streamID, and false
+// if it has none.
+func (s *Store) SnapshotVersion(streamID string) (int64, bool) {
+	s.snapMu.RLock()
+	defer s.snapMu.RUnlock()
+	snap, ok := s.snapshots[streamID]
+	return snap.version, ok
+}
+
+// Stats counts the events, streams and snapshots of the store.
+func (s *Store) Stats(_ context.Context) (ges.StoreStats, error) {
+	stats := ges.StoreStats{Categories: map[string]int64{}}
+	s.mu.RLock()
+	for streamID, events := range s.streams {
+		if len(events) == 0 {
+			continue
+		}
+		stats.Events += int64(len(events))
+		stats.Streams++
+		stats.Categories[ges.StreamID(streamID).Category()] += int64(len(events))
+	}
+	s.mu.RUnlock()
+
+	s.snapMu.RLock()
+	stats.Snapshots = int64(len(s.snapshots))
+	s.snapMu.RUnlock()
+	return stats, nil
+}
@@ -1,7 +1,14 @@
 package mem_test
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/mickamy/go-event-sourcing"
 	"github.com/mickamy/go-event-sourcing/internal/storetest"
@@ -12,6 +19,620 @@ func TestStore_Compliance(t *testing.T) {
 	t.Parallel()
 	storetest.Run(t, func(t *testing.T) ges.EventStore {
 		t.Helper()
-		return mem.New()
+		return mem.New(
+			mem.WithTypeRegistry(storetest.Registry()),
+			mem.WithContentTypeRegistry(storetest.ContentTypeRegistry()),
+		)
+	})
+}
+
+func TestStore_AppendInterceptor(t *testing.T) {
+	t.Parallel()
+
+	errNoTenant := errors.New("tenant_id is required")
+	s := mem.New(
+		mem.WithAppendInterceptor(func(_ context.Context, _ string, _ []ges.Event, md ges.Metadata) error {
+			if md["tenant_id"] == nil {
+				return errNoTenant
+			}
+			return nil
+		}),
+		mem.WithAppendInterceptor(func(_ context.Context, _ string, _ []ges.Event, md ges.Metadata) error {
+			md["enriched"] = true
+			return nil
+		}),
+	)
+
+	ctx := t.Context()
+	streamID := "Stream:interceptor"
+
+	_, err := s.Append(ctx, streamID, 0, []ges.Event{storetest.Opened{ID: "1"}}, nil)
+	if !errors.Is(err, errNoTenant) {
+		t.Fatalf("expected interceptor error, got %v", err)
+	}
+	if _, last, _ := s.Load(ctx, streamID, 0); last != 0 {
+		t.Fatalf("expected nothing appended, got version %d", last)
+	}
+
+	md := ges.Metadata{"tenant_id": "t1"}
+	if _, err := s.Append(ctx, streamID, 0, []ges.Event{storetest.Opened{ID: "1"}}, md); err != nil {
+		t.Fatalf("append failed: %v", err)
+	}
+	if _, ok := md["enriched"]; ok {
+		t.Fatalf("caller metadata must not be modified by interceptors")
+	}
+}
+
+func TestStore_ContextMetadata(t *testing.T) {
+	t.Parallel()
+
+	s := mem.New(mem.WithMetadataExtractor(ges.ContextMetadata))
+	streamID := "Stream:context-metadata"
+
+	ctx := ges.WithMetadata(t.Context(), ges.Metadata{"tenant_id": "t1", "correlation_id": "outer"})
+	ctx = ges.WithMetadata(ctx, ges.Metadata{"correlation_id": "c1"})
+
+	if _, err := s.Append(ctx, streamID, 0, []ges.Event{storetest.Opened{ID: "1"}}, ges.Metadata{"user_id": "u1"}); err != nil {
+		t.Fatalf("append failed: %v", err)
+	}
+	if _, err := s.Append(ctx, streamID, 1, []ges.Event{storetest.Added{N: 1}}, ges.Metadata{"tenant_id": "explicit"}); err != nil {
+		t.Fatalf("append failed: %v", err)
+	}
+
+	events, err := s.ReadAll(ctx, 0, 10)
+	if err != nil {
+		t.Fatalf("read all failed: %v", err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(events))
+	}
+	first, second := events[0].Metadata, events[1].Metadata
+	if first["tenant_id"] != "t1" || first["correlation_id"] != "c1" || first["user_id"] != "u1" {
+		t.Fatalf("expected context metadata merged with explicit, got %v", first)
+	}
+	if second["tenant_id"] != "explicit" {
+		t.Fatalf("expected explicit metadata to take precedence, got %v", second)
+	}
+}
+
+func TestStore_Subscribe(t *testing.T) {
+	t.Parallel()
+
+	s := mem.New()
+	ctx, cancel := context.WithCancel(t.Context())
+	a, b := s.Subscribe(ctx), s.Subscribe(ctx)
+
+	if _, err := s.Append(ctx, "Stream:bus", 0, []ges.Event{
+		storetest.Opened{ID: "1"},
+		storetest.Added{N: 2},
+	}, nil); err != nil {
+		t.Fatalf("append failed: %v", err)
+	}
+
+	// Delivered before Append returned, so no waiting is needed.
+	for _, ch := range []<-chan ges.StoredEvent{a, b} {
+		for want := int64(1); want <= 2; want++ {
+			select {
+			case ev := <-ch:
+				if ev.Version != want || ev.Position != want {
+					t.Fatalf("expected version and position %d, got %+v", want, ev)
+				}
+			default:
+				t.Fatalf("expected event %d to be delivered", want)
+			}
+		}
+	}
+
+	cancel()
+	if _, ok := <-a; ok {
+		t.Fatalf("expected the channel to be closed after cancel")
+	}
+}
+
+func TestStore_SubscribeDrop(t *testing.T) {
+	t.Parallel()
+
+	s := mem.New(mem.WithSubscriberBuffer(1), mem.WithSubscriberOverflow(mem.OverflowDrop))
+	ctx := t.Context()
+	ch := s.Subscribe(ctx)
+
+	if _, err := s.Append(ctx, "Stream:bus-drop", 0, []ges.Event{
+		storetest.Opened{ID: "1"},
+		storetest.Added{N: 2},
+		storetest.Added{N: 3},
+	}, nil); err != nil {
+		t.Fatalf("append failed: %v", err)
+	}
+
+	if ev := <-ch; ev.Version != 1 {
+		t.Fatalf("expected the first event, got %+v", ev)
+	}
+	select {
+	case ev := <-ch:
+		t.Fatalf("expected overflowing events to be dropped, got %+v", ev)
+	default:
+	}
+}
+
+func TestStore_Clock(t *testing.T) {
+	t.Parallel()
+
+	fixed := time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+	storetest.RunClock(t, func(t *testing.T) ges.EventStore {
+		t.Helper()
+		return mem.New(mem.WithClock(func() time.Time { return fixed }))
+	}, fixed)
+}
+
+func TestStore_ConflictEvents(t *testing.T) {
+	t.Parallel()
+
+	storetest.RunConflictEvents(t, func(t *testing.T) ges.EventStore {
+		t.Helper()
+		return mem.New(mem.WithConflictEvents())
+	})
+}
+
+func TestStore_AppendRawDecodeError(t *testing.T) {
+	t.Parallel()
+
+	s := mem.New(mem.WithTypeRegistry(storetest.Registry()))
+	streamID := "Stream:decode-error"
+
+	_, err := s.AppendRaw(t.Context(), streamID, 0, []ges.StoredEvent{
+		{Type: "Opened", Payload: []byte(`{"ID":"d"}`)},
+		{Type: "Added", Payload: []byte(`{"N":"not a number"}`)},
+	})
+	var decodeErr *ges.DecodeError
+	if !errors.As(err, &decodeErr) {
+		t.Fatalf("expected a *ges.DecodeError, got %v", err)
+	}
+	if decodeErr.StreamID != streamID || decodeErr.Version != 2 || decodeErr.EventType != "Added" {
+		t.Fatalf("expected the error to point at Added at version 2, got %+v", decodeErr)
+	}
+}
+
+func TestStore_OnDecodeErrorSkip(t *testing.T) {
+	t.Parallel()
+
+	s := mem.New(
+		mem.WithTypeRegistry(storetest.Registry()),
+		mem.WithOnDecodeError(func(ges.DecodeError) ges.DecodeAction { return ges.DecodeSkip }),
+	)
+	streamID := "Stream:decode-skip"
+
+	if _, err := s.AppendRaw(t.Context(), streamID, 0, []ges.StoredEvent{
+		{Type: "Opened", Payload: []byte(`{"ID":"d"}`)},
+		{Type: "Added", Payload: []byte(`{"N":"not a number"}`), ContentType: "application/json"},
+	}); err != nil {
+		t.Fatalf("append failed: %v", err)
+	}
+	events, version, err := s.Load(t.Context(), streamID, 0)
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	if version != 2 || len(events) != 2 {
+		t.Fatalf("expected 2 events at version 2, got %d at %d", len(events), version)
+	}
+	if skipped, ok := events[1].(ges.SkippedEvent); !ok || skipped.Err.EventType != "Added" {
+		t.Fatalf("expected Added to be skipped, got %#v", events[1])
+	}
+
+	// The undecodable bytes are kept, so raw readers such as Sync and Backup get them back.
+	raw, err := s.LoadRaw(t.Context(), streamID, 1)
+	if err != nil {
+		t.Fatalf("load raw failed: %v", err)
+	}
+	if got := string(raw[0].Payload.([]byte)); got != `{"N":"not a number"}` || raw[0].ContentType != "application/json" {
+		t.Fatalf("expected the payload as appended, got %s (%s)", got, raw[0].ContentType)
+	}
+}
+
+func TestSync(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	src := mem.New(mem.WithTypeRegistry(storetest.Registry()))
+	dst := mem.New(mem.WithTypeRegistry(storetest.Registry()))
+
+	if _, err := src.Append(ctx, "Stream:a", 0, []ges.Event{storetest.Opened{ID: "a"}, storetest.Added{N: 1}}, ges.Metadata{"user_id": "u1"}); err != nil {
+		t.Fatalf("append failed: %v", err)
+	}
+	if _, err := src.Append(ctx, "Stream:b", 0, []ges.Event{storetest.Opened{ID: "b"}}, nil); err != nil {
+		t.Fatalf("append failed: %v", err)
+	}
+	if _, err := src.Append(ctx, "Stream:a", 2, []ges.Event{storetest.Added{N: 2}}, nil); err != nil {
+		t.Fatalf("append failed: %v", err)
+	}
+	// dst already has the start of Stream:a.
+	if _, err := dst.Append(ctx, "Stream:a", 0, []ges.Event{storetest.Opened{ID: "a"}}, nil); err != nil {
+		t.Fatalf("append failed: %v", err)
+	}
+
+	position, err := ges.Sync(ctx, src, dst, 0)
+	if err != nil || position != 4 {
+		t.Fatalf("expected to sync up to position 4, got %d, %v", position, err)
+	}
+	events, version, err := dst.Load(ctx, "Stream:a", 0)
+	if err != nil || version != 3 || events[2] != (storetest.Added{N: 2}) {
+		t.Fatalf("expected Stream:a at version 3, got %v at %d, %v", events, version, err)
+	}
+	if _, version, _ := dst.Load(ctx, "Stream:b", 0); version != 1 {
+		t.Fatalf("expected Stream:b at version 1, got %d", version)
+	}
+
+	// Re-running from the start skips what is already there.
+	if position, err := ges.Sync(ctx, src, dst, 0); err != nil || position != 4 {
+		t.Fatalf("expected an idempotent re-sync, got %d, %v", position, err)
+	}
+	if position, err := ges.Sync(ctx, src, dst, 4); err != nil || position != 4 {
+		t.Fatalf("expected nothing to sync after position 4, got %d, %v", position, err)
+	}
+}
+
+func TestBackupRestore(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	src := mem.New(mem.WithTypeRegistry(storetest.Registry()))
+	dst := mem.New(mem.WithTypeRegistry(storetest.Registry()))
+
+	if _, err := src.Append(ctx, "Stream:a", 0, []ges.Event{storetest.Opened{ID: "a"}}, ges.Metadata{"user_id": "u1"}); err != nil {
+		t.Fatalf("append failed: %v", err)
+	}
+	if _, err := src.Append(ctx, "Stream:b", 0, []ges.Event{storetest.Opened{ID: "b"}}, nil); err != nil {
+		t.Fatalf("append failed: %v", err)
+	}
+	if _, err := src.Append(ctx, "Stream:a", 1, []ges.Event{storetest.Added{N: 2}}, nil); err != nil {
+		t.Fatalf("append failed: %v", err)
+	}
+	if err := src.SaveSnapshot(ctx, "Stream:a", 2, map[string]any{"n": 2}); err != nil {
+		t.Fatalf("save snapshot failed: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := ges.Backup(ctx, src, &buf); err != nil {
+		t.Fatalf("backup failed: %v", err)
+	}
+	// dst already has the start of Stream:a, as after an interrupted restore.
+	if _, err := dst.Append(ctx, "Stream:a", 0, []ges.Event{storetest.Opened{ID: "a"}}, nil); err != nil {
+		t.Fatalf("append failed: %v", err)
+	}
+	for range 2 {
+		if err := ges.Restore(ctx, dst, bytes.NewReader(buf.Bytes())); err != nil {
+			t.Fatalf("restore failed: %v", err)
+		}
+	}
+
+	all, err := dst.ReadAll(ctx, 0, 10)
+	if err != nil || len(all) != 3 {
+		t.Fatalf("expected 3 events, got %v, %v", all, err)
+	}
+	if all[1].StreamID != "Stream:b" || all[2].StreamID != "Stream:a" || all[2].Version != 2 || all[2].Payload != (storetest.Added{N: 2}) {
+		t.Fatalf("expected the events in their original order, got %+v", all)
+	}
+	snap, err := dst.LoadSnapshot(ctx, "Stream:a")
+	if err != nil || !snap.Found || snap.Version != 2 {
+		t.Fatalf("expected the snapshot at version 2, got %+v, %v", snap, err)
+	}
+	if state, err := ges.DecodeState[map[string]int](snap.State); err != nil || state["n"] != 2 {
+		t.Fatalf("expected the snapshot state to survive, got %v, %v", state, err)
+	}
+
+	if err := ges.Restore(ctx, dst, strings.NewReader(`{"format":"ges-backup","version":2}`)); err == nil {
+		t.Fatalf("expected an unknown archive version to be rejected")
+	}
+}
+
+func TestBackupRestore_Truncated(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	src := mem.New(mem.WithTypeRegistry(storetest.Registry()))
+	dst := mem.New(mem.WithTypeRegistry(storetest.Registry()))
+
+	if _, err := src.Append(ctx, "Stream:a", 0, []ges.Event{storetest.Opened{ID: "a"}, storetest.Added{N: 1}, storetest.Added{N: 2}}, nil); err != nil {
+		t.Fatalf("append failed: %v", err)
+	}
+	if err := src.SaveSnapshot(ctx, "Stream:a", 2, map[string]any{"n": 1}); err != nil {
+		t.Fatalf("save snapshot failed: %v", err)
+	}
+	if err := src.TruncateBefore(ctx, "Stream:a", 2); err != nil {
+		t.Fatalf("truncate failed: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := ges.Backup(ctx, src, &buf); err != nil {
+		t.Fatalf("backup failed: %v", err)
+	}
+	for range 2 {
+		if err := ges.Restore(ctx, dst, bytes.NewReader(buf.Bytes())); err != nil {
+			t.Fatalf("restore failed: %v", err)
+		}
+	}
+
+	all, err := dst.ReadAll(ctx, 0, 10)
+	if err != nil || len(all) != 2 || all[0].Version != 2 || all[1].Payload != (storetest.Added{N: 2}) {
+		t.Fatalf("expected versions 2 and 3 to be restored, got %+v, %v", all, err)
+	}
+	snap, err := dst.LoadSnapshot(ctx, "Stream:a")
+	if err != nil || !snap.Found || snap.Version != 2 {
+		t.Fatalf("expected the snapshot at version 2, got %+v, %v", snap, err)
+	}
+}
+
+// withoutSnapshots hides the snapshots of a Store, as if they had been deleted.
+type withoutSnapshots struct{ *mem.Store }
+
+func (withoutSnapshots) LoadSnapshot(context.Context, string) (ges.Snapshot, error) {
+	return ges.Snapshot{}, nil
+}
+
+func TestStore_TruncatedWithoutSnapshot(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	s := mem.New()
+	if _, err := s.Append(ctx, "Stream:cut", 0, []ges.Event{storetest.Opened{ID: "c"}, storetest.Added{N: 1}, storetest.Added{N: 2}}, nil); err != nil {
+		t.Fatalf("append failed: %v", err)
+	}
+	if err := s.SaveSnapshot(ctx, "Stream:cut", 2, map[string]any{"total": 1}); err != nil {
+		t.Fatalf("save snapshot failed: %v", err)
+	}
+	if err := s.TruncateBefore(ctx, "Stream:cut", 2); err != nil {
+		t.Fatalf("truncate failed: %v", err)
+	}
+
+	c := storetest.NewCounter("Stream:cut")
+	if err := ges.Rehydrate(ctx, s, "Stream:cut", c); err != nil || c.Total != 3 {
+		t.Fatalf("expected 3 from the snapshot and the tail, got %d, %v", c.Total, err)
+	}
+
+	// Without the snapshot the tail cannot be replayed onto a fresh aggregate.
+	err := ges.Rehydrate(ctx, withoutSnapshots{s}, "Stream:cut", storetest.NewCounter("Stream:cut"))
+	if !errors.Is(err, ges.ErrStreamTruncated) {
+		t.Fatalf("expected ErrStreamTruncated from Rehydrate, got %v", err)
+	}
+	never := func(ges.Aggregate) bool { return false }
+	if _, _, err := ges.RehydrateUntil(ctx, s, "Stream:cut", storetest.NewCounter("Stream:cut"), never); !errors.Is(err, ges.ErrStreamTruncated) {
+		t.Fatalf("expected ErrStreamTruncated from RehydrateUntil, got %v", err)
+	}
+}
+
+func TestStore_SchemaRegistry(t *testing.T) {
+	t.Parallel()
+
+	storetest.RunSchemaRegistry(t, func(t *testing.T) ges.EventStore {
+		t.Helper()
+		return mem.New(mem.WithTypeRegistry(storetest.Registry()), mem.WithSchemaRegistry(storetest.Schemas()))
+	})
+}
+
+func TestStore_UnknownEventFallback(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	legacy := ges.StoredEvent{Type: "Legacy", Payload: []byte(`{"x":1}`)}
+
+	strict := mem.New(mem.WithTypeRegistry(storetest.Registry()))
+	if _, err := strict.AppendRaw(ctx, "Stream:unknown", 0, []ges.StoredEvent{legacy}); err == nil {
+		t.Fatalf("expected an unknown type to fail without the fallback")
+	}
+
+	s := mem.New(mem.WithTypeRegistry(storetest.Registry()), mem.WithUnknownEventFallback())
+	if _, err := s.AppendRaw(ctx, "Stream:unknown", 0, []ges.StoredEvent{legacy}); err != nil {
+		t.Fatalf("append raw failed: %v", err)
+	}
+	events, _, err := s.Load(ctx, "Stream:unknown", 0)
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	unknown, ok := events[0].(ges.UnknownEvent)
+	if !ok || unknown.Type != "Legacy" || string(unknown.Raw) != `{"x":1}` {
+		t.Fatalf("expected an UnknownEvent with the stored bytes, got %#v", events[0])
+	}
+	raw, err := s.LoadRaw(ctx, "Stream:unknown", 0)
+	if err != nil || raw[0].Type != "Legacy" || string(raw[0].Payload.([]byte)) != `{"x":1}` {
+		t.Fatalf("expected the bytes to be forwarded as given, got %+v, %v", raw, err)
+	}
+}
+
+func TestStore_MaxVersion(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	s := mem.New(mem.WithMaxVersion(2))
+	streamID := "Stream:max-version"
+
+	if _, err := s.Append(ctx, streamID, 0, []ges.Event{storetest.Opened{ID: "m"}, storetest.Added{N: 1}}, nil); err != nil {
+		t.Fatalf("append up to the limit failed: %v", err)
+	}
+	if _, err := s.Append(ctx, streamID, 2, []ges.Event{storetest.Added{N: 2}}, nil); !errors.Is(err, ges.ErrVersionLimit) {
+		t.Fatalf("expected ErrVersionLimit, got %v", err)
+	}
+	if _, err := s.AppendAuto(ctx, streamID, []ges.Event{storetest.Added{N: 2}}, nil); !errors.Is(err, ges.ErrVersionLimit) {
+		t.Fatalf("expected ErrVersionLimit from AppendAuto, got %v", err)
+	}
+	if _, version, _ := s.Load(ctx, streamID, 0); version != 2 {
+		t.Fatalf("expected the stream to stay at version 2, got %d", version)
+	}
+}
+
+func TestStore_Inspection(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	s := mem.New()
+
+	if ids := s.StreamIDs(); len(ids) != 0 {
+		t.Fatalf("expected no streams, got %v", ids)
+	}
+	if _, err := s.Append(ctx, "Stream:b", 0, []ges.Event{storetest.Opened{ID: "b"}, storetest.Added{N: 1}}, nil); err != nil {
+		t.Fatalf("append failed: %v", err)
+	}
+	if _, err := s.Append(ctx, "Stream:a", 0, []ges.Event{storetest.Opened{ID: "a"}}, nil); err != nil {
+		t.Fatalf("append failed: %v", err)
+	}
+	if err := s.SaveSnapshot(ctx, "Stream:b", 2, map[string]any{"n": 1}); err != nil {
+		t.Fatalf("save snapshot failed: %v", err)
+	}
+
+	if ids := s.StreamIDs(); len(ids) != 2 || ids[0] != "Stream:a" || ids[1] != "Stream:b" {
+		t.Fatalf("expected [Stream:a Stream:b], got %v", ids)
+	}
+	if n := s.EventCount("Stream:b"); n != 2 {
+		t.Fatalf("expected 2 events, got %d", n)
+	}
+	if v, ok := s.SnapshotVersion("Stream:b"); !ok || v != 2 {
+		t.Fatalf("expected a snapshot at version 2, got %d, %v", v, ok)
+	}
+	if _, ok := s.SnapshotVersion("Stream:a"); ok {
+		t.Fatalf("expected no snapshot for Stream:a")
+	}
+}
+
+func TestStore_DedupKeys(t *testing.T) {
+	t.Parallel()
+
+	storetest.RunDedupKeys(t, func(t *testing.T) ges.EventStore {
+		t.Helper()
+		return mem.New(mem.WithTypeRegistry(storetest.Registry()), mem.WithDedupKeys())
+	})
+}
+
+func TestStore_AppendWithPosition(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	s := mem.New()
+	if _, err := s.Append(ctx, "Stream:other", 0, []ges.Event{storetest.Opened{ID: "o"}}, nil); err != nil {
+		t.Fatalf("append failed: %v", err)
+	}
+
+	version, position, err := s.AppendWithPosition(ctx, "Stream:position", 0, []ges.Event{storetest.Opened{ID: "p"}, storetest.Added{N: 1}}, nil)
+	if err != nil || version != 2 || position != 3 {
+		t.Fatalf("expected version 2 at position 3, got %d, %d, %v", version, position, err)
+	}
+	if err := ges.WaitForPosition(ctx, s, position, time.Second); err != nil {
+		t.Fatalf("expected the store to have reached its own position, got %v", err)
+	}
+}
+
+func TestStore_StreamIDValidator(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	s := mem.New(mem.WithStreamIDValidator(ges.ValidateStreamID))
+
+	if _, err := s.Append(ctx, "Account:", 0, []ges.Event{storetest.Opened{ID: ""}}, nil); !errors.Is(err, ges.ErrInvalidStreamID) {
+		t.Fatalf("expected ErrInvalidStreamID from Append, got %v", err)
+	}
+	if _, _, err := s.Load(ctx, "Account:", 0); !errors.Is(err, ges.ErrInvalidStreamID) {
+		t.Fatalf("expected ErrInvalidStreamID from Load, got %v", err)
+	}
+
+	// Every read or write of the stream checks it too.
+	raw := []ges.StoredEvent{{Type: "Opened", Payload: []byte(`{"ID":""}`)}}
+	if _, err := s.AppendRaw(ctx, "Account:", 0, raw); !errors.Is(err, ges.ErrInvalidStreamID) {
+		t.Fatalf("expected ErrInvalidStreamID from AppendRaw, got %v", err)
+	}
+	if err := s.EnsureVersion(ctx, "Account:", 0); !errors.Is(err, ges.ErrInvalidStreamID) {
+		t.Fatalf("expected ErrInvalidStreamID from EnsureVersion, got %v", err)
+	}
+	if _, err := s.Exists(ctx, "Account:"); !errors.Is(err, ges.ErrInvalidStreamID) {
+		t.Fatalf("expected ErrInvalidStreamID from Exists, got %v", err)
+	}
+	if _, err := s.LoadRange(ctx, "Account:", 0, 10); !errors.Is(err, ges.ErrInvalidStreamID) {
+		t.Fatalf("expected ErrInvalidStreamID from LoadRange, got %v", err)
+	}
+	if _, err := s.LoadLast(ctx, "Account:", 1); !errors.Is(err, ges.ErrInvalidStreamID) {
+		t.Fatalf("expected ErrInvalidStreamID from LoadLast, got %v", err)
+	}
+	if _, _, err := s.LoadPage(ctx, "Account:", 0, 10, false); !errors.Is(err, ges.ErrInvalidStreamID) {
+		t.Fatalf("expected ErrInvalidStreamID from LoadPage, got %v", err)
+	}
+	if _, err := s.LoadMany(ctx, map[string]int64{"Account:": 0}); !errors.Is(err, ges.ErrInvalidStreamID) {
+		t.Fatalf("expected ErrInvalidStreamID from LoadMany, got %v", err)
+	}
+	for _, err := range s.LoadIter(ctx, "Account:", 0) {
+		if !errors.Is(err, ges.ErrInvalidStreamID) {
+			t.Fatalf("expected ErrInvalidStreamID from LoadIter, got %v", err)
+		}
+	}
+	if ids := s.StreamIDs(); len(ids) != 0 {
+		t.Fatalf("expected nothing to be stored, got %v", ids)
+	}
+	if _, err := s.Append(ctx, "Account:1", 0, []ges.Event{storetest.Opened{ID: "1"}}, nil); err != nil {
+		t.Fatalf("expected a valid ID to be accepted, got %v", err)
+	}
+}
+
+func TestStore_TypeNamer(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	s := mem.New(mem.WithTypeNamer(func(e ges.Event) string {
+		return "storetest." + ges.EventType(e) + ".v2"
+	}))
+
+	if _, err := s.Append(ctx, "Stream:named", 0, []ges.Event{storetest.Opened{ID: "n"}, storetest.Added{N: 1}}, nil); err != nil {
+		t.Fatalf("append failed: %v", err)
+	}
+	events, err := s.ReadAll(ctx, 0, 10, ges.WithEventTypes("storetest.Added.v2"))
+	if err != nil {
+		t.Fatalf("read all failed: %v", err)
+	}
+	if len(events) != 1 || events[0].Type != "storetest.Added.v2" {
+		t.Fatalf("expected the Added event under its versioned name, got %+v", events)
+	}
+}
+
+func TestStore_ReadAllByMetadata(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	s := mem.New()
+	for i, tenant := range []any{"acme", "globex", nil, "acme"} {
+		md := ges.Metadata{}
+		if tenant != nil {
+			md[ges.MetadataTenantID] = tenant
+		}
+		if _, err := s.AppendAuto(ctx, "Stream:tenants", []ges.Event{storetest.Added{N: i}}, md); err != nil {
+			t.Fatalf("append failed: %v", err)
+		}
+	}
+
+	events, err := ges.ReadByMetadata(ctx, s, ges.MetadataTenantID, "acme", 0, 10)
+	if err != nil {
+		t.Fatalf("read failed: %v", err)
+	}
+	if len(events) != 2 || events[0].Position != 1 || events[1].Position != 4 {
+		t.Fatalf("expected the acme events at positions 1 and 4, got %+v", events)
+	}
+}
+
+// BenchmarkAppendWithSnapshots appends to per-goroutine streams while every other
+// operation snapshots a hot aggregate, which must not slow the appends down.
+func BenchmarkAppendWithSnapshots(b *testing.B) {
+	s := mem.New()
+	ctx := b.Context()
+	var next atomic.Int64
+
+	b.RunParallel(func(pb *testing.PB) {
+		streamID := "Stream:bench-" + strconv.FormatInt(next.Add(1), 10)
+		var version int64
+		for i := 0; pb.Next(); i++ {
+			if i%2 == 1 {
+				if err := s.SaveSnapshot(ctx, "Stream:hot", int64(i), map[string]any{"i": i}); err != nil {
+					b.Fatal(err)
+				}
+				continue
+			}
+			v, err := s.Append(ctx, streamID, version, []ges.Event{storetest.Added{N: i}}, nil)
+			if err != nil {
+				b.Fatal(err)
+			}
+			version = v
+		}
 	})
 }
@@ -2,10 +2,73 @@ package pgx
 
 import (
 	"errors"
+	"fmt"
+	"io"
+	"net"
+	"regexp"
 
 	"github.com/jackc/pgx/v5/pgconn"
 )
 
+// ErrUnknownEventType is returned by Append when an event's type has no codec in
+// the type registry. It is detected before any transaction is started. Reads of
+// such events fail with a *ges.DecodeError wrapping it.
+var ErrUnknownEventType = errors.New("ges-pgx: no codec registered for event type")
+
+// ErrPayloadNotJSON is returned by appends when a codec produced a payload that a
+// JSONB payload column cannot hold (see WithPayloadColumnType): invalid JSON, or
+// JSON with a \u0000 escape, which JSONB rejects. The error names the event type.
+// Nothing is written.
+var ErrPayloadNotJSON = errors.New("ges-pgx: payload is not valid JSON")
+
+// ErrInvalidPartitionCount is returned by CreatePartitionedEvents for a partition
+// count out of range. Nothing is created.
+var ErrInvalidPartitionCount = errors.New("ges-pgx: invalid partition count")
+
+// ErrBatchTooLarge is returned by Append when a batch exceeds the limit set with
+// WithMaxBatchSize. Nothing is written.
+var ErrBatchTooLarge = errors.New("ges-pgx: batch too large")
+
+// ErrSnapshotTooLarge is returned by SaveSnapshot when a state exceeds the limit set
+// with WithMaxSnapshotSize. Nothing is written.
+var ErrSnapshotTooLarge = errors.New("ges-pgx: snapshot too large")
+
+// StoreError is returned when a database or encoding step of a store operation
+// fails. It wraps the underlying error, so errors.Is and errors.As still reach
+// e.g. a *pgconn.PgError, while errors.As on a *StoreError tells which step failed
+// and for which stream without parsing the message.
+type StoreError struct {
+	// Op describes the step that failed, e.g. "query events" or "begin transaction".
+	Op string
+	// StreamID is the stream the operation was working on, or empty for operations
+	// across streams such as ReadAll.
+	StreamID string
+	Err      error
+}
+
+func (e *StoreError) Error() string {
+	if e.StreamID == "" {
+		return fmt.Sprintf("ges-pgx: could not %s: %v", e.Op, e.Err)
+	}
+	return fmt.Sprintf("ges-pgx: could not %s of %s: %v", e.Op, e.StreamID, e.Err)
+}
+
+func (e *StoreError) Unwrap() error { return e.Err }
+
+// errCommit marks failed commits. Unless the server rejected the transaction, it
+// may have been applied, so such errors are not retried as transient.
+var errCommit = errors.New("ges-pgx: could not commit transaction")
+
+// commitError marks a failed commit with errCommit. It reads as the error it wraps.
+type commitError struct{ err error }
+
+func (e commitError) Error() string   { return e.err.Error() }
+func (e commitError) Unwrap() []error { return []error{errCommit, e.err} }
+
+// metadataKeyPattern keeps "md_" + key a plain identifier within PostgreSQL's
+// 63-byte limit.
+var metadataKeyPattern = regexp.MustCompile(`^[a-z0-9_]{1,60}$`)
+
 func pgErrorCode(err error) string {
 	var pgErr *pgconn.PgError
 	if errors.As(err, &pgErr) {
@@ -17,3 +80,20 @@ func pgErrorCode(err error) string {
 func isUniqueViolation(err error) bool {
 	return pgErrorCode(err) == "23505"
 }
+
+// isTransient reports whether err is worth retrying the whole transaction for:
+// serialization failures, deadlocks and connections lost before committing.
+func isTransient(err error) bool {
+	switch pgErrorCode(err) {
+	case "40001", "40P01": // serialization_failure, deadlock_detected
+		return true
+	case "":
+	default:
+		return false
+	}
+	if errors.Is(err, errCommit) {
+		return false
+	}
+	var netErr net.Error
+	return pgconn.SafeToRetry(err) || errors.As(err, &netErr) || errors.Is(err, io.ErrUnexpectedEOF)
+}
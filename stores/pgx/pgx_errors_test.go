@@ -0,0 +1,209 @@
+package pgx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/mickamy/go-event-sourcing"
+)
+
+func TestIsTransient(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"serialization failure", &pgconn.PgError{Code: "40001"}, true},
+		{"deadlock", &StoreError{Op: "insert event", StreamID: "Stream:1", Err: &pgconn.PgError{Code: "40P01"}}, true},
+		{"rejected commit", fmt.Errorf("%w: %w", errCommit, &pgconn.PgError{Code: "40001"}), true},
+		{"lost connection", &StoreError{Op: "insert event", StreamID: "Stream:1", Err: io.ErrUnexpectedEOF}, true},
+		{"lost connection at commit", fmt.Errorf("%w: %w", errCommit, io.ErrUnexpectedEOF), false},
+		{"commit StoreError", &StoreError{Op: "commit transaction", StreamID: "Stream:1", Err: commitError{io.ErrUnexpectedEOF}}, false},
+		{"unique violation", &pgconn.PgError{Code: "23505"}, false},
+		{"version conflict", &ges.VersionConflictError{StreamID: "Stream:1"}, false},
+		{"other", errors.New("boom"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := isTransient(tt.err); got != tt.want {
+				t.Fatalf("isTransient(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryTransient(t *testing.T) {
+	t.Parallel()
+
+	transient := &pgconn.PgError{Code: "40001"}
+	// newStore returns a store retrying up to attempts times, recording the attempt
+	// each backoff is asked for.
+	newStore := func(attempts int, delay time.Duration) (*EventStore, *[]int) {
+		var backoffs []int
+		s := NewEventStore(nil, WithTransientRetry(attempts, func(attempt int) time.Duration {
+			backoffs = append(backoffs, attempt)
+			return delay
+		}))
+		return s, &backoffs
+	}
+
+	t.Run("retries until success", func(t *testing.T) {
+		t.Parallel()
+		s, backoffs := newStore(3, 0)
+		calls := 0
+		version, err := s.retryTransient(t.Context(), func() (int64, error) {
+			if calls++; calls < 3 {
+				return 0, transient
+			}
+			return 5, nil
+		})
+		if err != nil || version != 5 || calls != 3 {
+			t.Fatalf("expected version 5 after 3 calls, got %d after %d, %v", version, calls, err)
+		}
+		if !slices.Equal(*backoffs, []int{1, 2}) {
+			t.Fatalf("expected backoffs for attempts 1 and 2, got %v", *backoffs)
+		}
+	})
+
+	t.Run("gives up after the last attempt", func(t *testing.T) {
+		t.Parallel()
+		s, _ := newStore(3, 0)
+		calls := 0
+		_, err := s.retryTransient(t.Context(), func() (int64, error) {
+			calls++
+			return 0, transient
+		})
+		if !errors.Is(err, transient) || calls != 3 {
+			t.Fatalf("expected the transient error after 3 calls, got %v after %d", err, calls)
+		}
+	})
+
+	t.Run("does not retry other errors", func(t *testing.T) {
+		t.Parallel()
+		s, _ := newStore(3, 0)
+		calls := 0
+		conflict := &ges.VersionConflictError{StreamID: "Stream:1"}
+		_, err := s.retryTransient(t.Context(), func() (int64, error) {
+			calls++
+			return 0, conflict
+		})
+		if !errors.Is(err, conflict) || calls != 1 {
+			t.Fatalf("expected the conflict after 1 call, got %v after %d", err, calls)
+		}
+	})
+
+	t.Run("runs once by default", func(t *testing.T) {
+		t.Parallel()
+		s := NewEventStore(nil)
+		calls := 0
+		_, err := s.retryTransient(t.Context(), func() (int64, error) {
+			calls++
+			return 0, transient
+		})
+		if !errors.Is(err, transient) || calls != 1 {
+			t.Fatalf("expected the transient error after 1 call, got %v after %d", err, calls)
+		}
+	})
+
+	t.Run("stops waiting when the context is done", func(t *testing.T) {
+		t.Parallel()
+		s, _ := newStore(3, time.Hour)
+		ctx, cancel := context.WithCancel(t.Context())
+		calls := 0
+		_, err := s.retryTransient(ctx, func() (int64, error) {
+			calls++
+			cancel()
+			return 0, transient
+		})
+		if !errors.Is(err, transient) || calls != 1 {
+			t.Fatalf("expected the transient error after 1 call, got %v after %d", err, calls)
+		}
+	})
+}
+
+func TestStoreError(t *testing.T) {
+	t.Parallel()
+
+	var err error = &StoreError{Op: "query events", StreamID: "Stream:1", Err: io.ErrUnexpectedEOF}
+	if got := err.Error(); got != "ges-pgx: could not query events of Stream:1: unexpected EOF" {
+		t.Fatalf("unexpected message %q", got)
+	}
+	wrapped := fmt.Errorf("load: %w", err)
+	var storeErr *StoreError
+	if !errors.As(wrapped, &storeErr) || storeErr.Op != "query events" || storeErr.StreamID != "Stream:1" {
+		t.Fatalf("expected the StoreError through errors.As, got %+v", storeErr)
+	}
+	if !errors.Is(wrapped, io.ErrUnexpectedEOF) {
+		t.Fatal("expected the cause to stay reachable")
+	}
+	if got := (&StoreError{Op: "get head position", Err: io.EOF}).Error(); got != "ges-pgx: could not get head position: EOF" {
+		t.Fatalf("unexpected message %q", got)
+	}
+
+	// Op stays the same for every event type; the type is in the message.
+	s := NewEventStore(nil,
+		WithTypeRegistry(map[string]ges.EventCodec{"Broken": failingCodec{}}),
+		WithTypeNamer(func(ges.Event) string { return "Broken" }),
+	)
+	_, err = s.encodeEnvelopes([]ges.Envelope{{Event: struct{}{}}})
+	if !errors.As(err, &storeErr) || storeErr.Op != "encode event" || !errors.Is(err, io.ErrShortWrite) {
+		t.Fatalf("expected an encode event StoreError, got %v", err)
+	}
+	if got := err.Error(); got != `ges-pgx: could not encode event: "Broken": short write` {
+		t.Fatalf("unexpected message %q", got)
+	}
+}
+
+func TestStoreError_SaveSnapshot(t *testing.T) {
+	t.Parallel()
+
+	// Nothing listens on port 1, so every statement fails to connect.
+	pool, err := pgxpool.New(t.Context(), "postgres://postgres@127.0.0.1:1/ges?connect_timeout=1")
+	if err != nil {
+		t.Fatalf("failed to create pool: %v", err)
+	}
+	t.Cleanup(pool.Close)
+
+	err = NewEventStore(pool).SaveSnapshot(t.Context(), "Stream:1", 1, map[string]int{"n": 1})
+	var storeErr *StoreError
+	if !errors.As(err, &storeErr) || storeErr.Op != "save snapshot" || storeErr.StreamID != "Stream:1" {
+		t.Fatalf("expected a save snapshot StoreError, got %v", err)
+	}
+}
+
+// failingCodec fails to encode anything.
+type failingCodec struct{}
+
+func (failingCodec) Encode(any) ([]byte, error) { return nil, io.ErrShortWrite }
+func (failingCodec) Decode([]byte) (any, error) { return nil, io.ErrShortWrite }
+
+func TestHasNULEscape(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		json string
+		want bool
+	}{
+		{`{"a":"b"}`, false},
+		{`{"a":"\u0000"}`, true},
+		{`{"a":"x\\u0000"}`, false}, // an escaped backslash, then text
+		{`{"a":"x\\\u0000"}`, true}, // an escaped backslash, then the escape
+		{`{"a":"\u00001"}`, true},
+	}
+	for _, tt := range tests {
+		if got := hasNULEscape([]byte(tt.json)); got != tt.want {
+			t.Errorf("hasNULEscape(%s) = %v, want %v", tt.json, got, tt.want)
+		}
+	}
+}
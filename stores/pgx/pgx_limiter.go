@@ -0,0 +1,60 @@
+package pgx
+
+import (
+	"context"
+	"sync"
+)
+
+// streamLimiter bounds the number of in-flight operations per key. Keys are
+// tracked only while they are in use, so idle streams cost nothing.
+type streamLimiter struct {
+	max int
+
+	mu    sync.Mutex
+	slots map[string]*streamSlot
+}
+
+type streamSlot struct {
+	sem  chan struct{}
+	refs int // acquirers holding or waiting for sem
+}
+
+func newStreamLimiter(maxInFlight int) *streamLimiter {
+	return &streamLimiter{
+		max:   maxInFlight,
+		slots: make(map[string]*streamSlot),
+	}
+}
+
+// acquire blocks until fewer than max operations hold key or ctx is done.
+// On success the returned func must be called to release the slot.
+func (l *streamLimiter) acquire(ctx context.Context, key string) (func(), error) {
+	l.mu.Lock()
+	slot := l.slots[key]
+	if slot == nil {
+		slot = &streamSlot{sem: make(chan struct{}, l.max)}
+		l.slots[key] = slot
+	}
+	slot.refs++
+	l.mu.Unlock()
+
+	select {
+	case slot.sem <- struct{}{}:
+		return func() {
+			<-slot.sem
+			l.unref(key, slot)
+		}, nil
+	case <-ctx.Done():
+		l.unref(key, slot)
+		return nil, ctx.Err()
+	}
+}
+
+func (l *streamLimiter) unref(key string, slot *streamSlot) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	slot.refs--
+	if slot.refs == 0 {
+		delete(l.slots, key)
+	}
+}
@@ -0,0 +1,72 @@
+package pgx
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestStreamLimiter(t *testing.T) {
+	t.Parallel()
+
+	l := newStreamLimiter(2)
+
+	var inFlight, peak atomic.Int32
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			release, err := l.acquire(t.Context(), "hot")
+			if err != nil {
+				t.Errorf("acquire failed: %v", err)
+				return
+			}
+			defer release()
+			n := inFlight.Add(1)
+			for {
+				p := peak.Load()
+				if n <= p || peak.CompareAndSwap(p, n) {
+					break
+				}
+			}
+			time.Sleep(time.Millisecond)
+			inFlight.Add(-1)
+		}()
+	}
+
+	// Other streams are not held up by the hot one.
+	release, err := l.acquire(t.Context(), "cold")
+	if err != nil {
+		t.Fatalf("acquire on another stream failed: %v", err)
+	}
+	release()
+
+	wg.Wait()
+	if peak.Load() > 2 {
+		t.Fatalf("expected at most 2 concurrent holders, got %d", peak.Load())
+	}
+	if len(l.slots) != 0 {
+		t.Fatalf("expected idle slots to be dropped, got %d", len(l.slots))
+	}
+}
+
+func TestStreamLimiter_ContextCanceled(t *testing.T) {
+	t.Parallel()
+
+	l := newStreamLimiter(1)
+	release, err := l.acquire(t.Context(), "s")
+	if err != nil {
+		t.Fatalf("acquire failed: %v", err)
+	}
+	defer release()
+
+	ctx, cancel := context.WithTimeout(t.Context(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := l.acquire(ctx, "s"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected context.DeadlineExceeded, got %v", err)
+	}
+}
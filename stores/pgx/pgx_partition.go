@@ -0,0 +1,82 @@
+package pgx
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// maxPartitions bounds the partition count accepted by CreatePartitionedEvents.
+const maxPartitions = 1024
+
+// CreatePartitionedEvents creates table as an events table hash-partitioned by
+// stream_id into partitions partitions named <table>_p0 to <table>_p<n-1>, for
+// write-bound deployments. It is the migration to run instead of creating the
+// events table of init.sql; the store needs no option, as PostgreSQL routes every
+// row to its partition. All the events of a stream land in the same partition, so
+// per-stream ordering and the (stream_id, version) key that detects version
+// conflicts are unchanged. Use "events" for the shared table, or events_<suffix>
+// for a table of WithTenantRouter. payload is the type of the payload column, which
+// must match the store's WithPayloadColumnType.
+//
+// PostgreSQL only enforces unique constraints that include the partition key, so
+// unlike in init.sql, event_id and position are indexed but not constrained to be
+// unique; they stay unique as long as they come from gen_random_uuid and the table's
+// sequence. The partition count cannot be changed later without copying the table.
+// It fails with ErrInvalidPartitionCount unless 1 <= partitions <= 1024.
+func CreatePartitionedEvents(ctx context.Context, pool *pgxpool.Pool, table string, partitions int, payload PayloadColumnType) error {
+	if partitions < 1 || partitions > maxPartitions {
+		return fmt.Errorf("%w: %d (must be 1 to %d)", ErrInvalidPartitionCount, partitions, maxPartitions)
+	}
+	if _, err := pool.Exec(ctx, partitionedEventsDDL(table, partitions, payload)); err != nil {
+		return &StoreError{Op: "create partitioned table", Err: fmt.Errorf("%s: %w", table, err)}
+	}
+	return nil
+}
+
+// partitionedEventsDDL returns the statements creating table with its partitions and
+// a payload column of type payload.
+func partitionedEventsDDL(table string, partitions int, payload PayloadColumnType) string {
+	name := func(suffix string) string { return pgx.Identifier{table + suffix}.Sanitize() }
+	payloadType := "JSONB"
+	if payload == PayloadBytea {
+		payloadType = "BYTEA"
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `
+		CREATE TABLE IF NOT EXISTS %s
+		(
+		    position     BIGSERIAL   NOT NULL,
+		    stream_id    TEXT        NOT NULL,
+		    version      BIGINT      NOT NULL,
+		    event_id     UUID                 DEFAULT gen_random_uuid(),
+		    event_type   TEXT        NOT NULL,
+		    content_type TEXT        NOT NULL DEFAULT '',
+		    payload      %-11s NOT NULL,
+		    metadata     JSONB       NOT NULL DEFAULT '{}'::jsonb,
+		    headers      JSONB       NOT NULL DEFAULT '{}'::jsonb,
+		    occurred_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+		    at           TIMESTAMPTZ NOT NULL DEFAULT now(),
+		    dedup_key    TEXT,
+		    md_tenant_id TEXT,
+		    md_user_id   TEXT,
+		    PRIMARY KEY (stream_id, version)
+		) PARTITION BY HASH (stream_id);
+		`, name(""), payloadType)
+	for i := range partitions {
+		fmt.Fprintf(&b, "CREATE TABLE IF NOT EXISTS %s PARTITION OF %s FOR VALUES WITH (MODULUS %d, REMAINDER %d);\n",
+			name(fmt.Sprintf("_p%d", i)), name(""), partitions, i)
+	}
+	fmt.Fprintf(&b, `
+		CREATE INDEX IF NOT EXISTS %[2]s ON %[1]s (position);
+		CREATE INDEX IF NOT EXISTS %[3]s ON %[1]s (event_id);
+		CREATE UNIQUE INDEX IF NOT EXISTS %[4]s ON %[1]s (stream_id, dedup_key) WHERE dedup_key IS NOT NULL;
+		CREATE INDEX IF NOT EXISTS %[5]s ON %[1]s (md_tenant_id, position);
+		CREATE INDEX IF NOT EXISTS %[6]s ON %[1]s (md_user_id, position);
+		`, name(""), name("_position"), name("_event_id"), name("_dedup_key"), name("_md_tenant_id"), name("_md_user_id"))
+	return b.String()
+}
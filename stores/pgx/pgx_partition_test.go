@@ -0,0 +1,38 @@
+package pgx
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestPartitionedEventsDDL(t *testing.T) {
+	t.Parallel()
+
+	ddl := partitionedEventsDDL("events_acme", 4, PayloadJSONB)
+	if n := strings.Count(ddl, "PARTITION OF"); n != 4 {
+		t.Fatalf("expected 4 partitions, got %d:\n%s", n, ddl)
+	}
+	for _, want := range []string{
+		`CREATE TABLE IF NOT EXISTS "events_acme"`,
+		`) PARTITION BY HASH (stream_id)`,
+		`"events_acme_p3" PARTITION OF "events_acme" FOR VALUES WITH (MODULUS 4, REMAINDER 3)`,
+		`PRIMARY KEY (stream_id, version)`,
+		`payload      JSONB       NOT NULL,`,
+	} {
+		if !strings.Contains(ddl, want) {
+			t.Fatalf("expected %q in:\n%s", want, ddl)
+		}
+	}
+	if ddl := partitionedEventsDDL("events_acme", 4, PayloadBytea); !strings.Contains(ddl, `payload      BYTEA       NOT NULL,`) {
+		t.Fatalf("expected a BYTEA payload column in:\n%s", ddl)
+	}
+
+	for _, n := range []int{0, maxPartitions + 1} {
+		// The count is checked before the pool is used.
+		if err := CreatePartitionedEvents(context.Background(), nil, "events", n, PayloadJSONB); !errors.Is(err, ErrInvalidPartitionCount) {
+			t.Fatalf("expected ErrInvalidPartitionCount for %d, got %v", n, err)
+		}
+	}
+}
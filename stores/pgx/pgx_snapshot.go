@@ -0,0 +1,136 @@
+package pgx
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// snapshotStatements are the statements reading and writing snapshots, which
+// depend on the table layout (see WithSingleTable). Writes take (stream_id,
+// version, state, metadata, at); loads select (version, state, metadata, at).
+type snapshotStatements struct {
+	upsertLatest  string
+	upsertHistory string
+	loadLatest    string // $1 stream_id
+	loadBefore    string // $1 stream_id, $2 maximum version
+	lockVersion   string // $1 stream_id; selects the latest version FOR UPDATE
+	count         string // counts the streams with a snapshot
+}
+
+var separateSnapshotStatements = snapshotStatements{
+	upsertLatest: `
+		INSERT INTO snapshots (stream_id, version, state, metadata, at)
+		VALUES ($1, $2, $3, $4, COALESCE($5, now()))
+		ON CONFLICT (stream_id) DO UPDATE
+		SET version  = EXCLUDED.version,
+		    state    = EXCLUDED.state,
+		    metadata = EXCLUDED.metadata,
+		    at       = EXCLUDED.at
+		WHERE snapshots.version <= EXCLUDED.version
+		`,
+	upsertHistory: `
+		INSERT INTO snapshot_history (stream_id, version, state, metadata, at)
+		VALUES ($1, $2, $3, $4, COALESCE($5, now()))
+		ON CONFLICT (stream_id, version) DO UPDATE
+		SET state    = EXCLUDED.state,
+		    metadata = EXCLUDED.metadata,
+		    at       = EXCLUDED.at
+		`,
+	loadLatest: `SELECT version, state, metadata, at FROM snapshots WHERE stream_id = $1`,
+	loadBefore: `
+		SELECT version, state, metadata, at
+		FROM snapshot_history
+		WHERE stream_id = $1 AND version <= $2
+		ORDER BY version DESC
+		LIMIT 1
+		`,
+	lockVersion: `SELECT version FROM snapshots WHERE stream_id = $1 FOR UPDATE`,
+	count:       `SELECT count(*) FROM snapshots`,
+}
+
+// snapshotStatements returns the snapshot statements for ctx. With WithSingleTable
+// they target the events table of ctx, keeping states in its payload column.
+func (s *EventStore) snapshotStatements(ctx context.Context) (snapshotStatements, error) {
+	if !s.singleTable {
+		return separateSnapshotStatements, nil
+	}
+	table, err := s.eventsTable(ctx)
+	if err != nil {
+		return snapshotStatements{}, err
+	}
+	return snapshotStatements{
+		upsertLatest: `
+		INSERT INTO ` + table + ` (record_type, stream_id, version, event_type, payload, metadata, at)
+		VALUES ('snapshot', $1, $2, '', $3, $4, COALESCE($5, now()))
+		ON CONFLICT (stream_id) WHERE record_type = 'snapshot' DO UPDATE
+		SET version  = EXCLUDED.version,
+		    payload  = EXCLUDED.payload,
+		    metadata = EXCLUDED.metadata,
+		    at       = EXCLUDED.at
+		WHERE ` + table + `.version <= EXCLUDED.version
+		`,
+		upsertHistory: `
+		INSERT INTO ` + table + ` (record_type, stream_id, version, event_type, payload, metadata, at)
+		VALUES ('snapshot_history', $1, $2, '', $3, $4, COALESCE($5, now()))
+		ON CONFLICT (stream_id, record_type, version) DO UPDATE
+		SET payload  = EXCLUDED.payload,
+		    metadata = EXCLUDED.metadata,
+		    at       = EXCLUDED.at
+		`,
+		loadLatest: `
+		SELECT version, payload, metadata, at
+		FROM ` + table + `
+		WHERE stream_id = $1 AND record_type = 'snapshot'
+		`,
+		loadBefore: `
+		SELECT version, payload, metadata, at
+		FROM ` + table + `
+		WHERE stream_id = $1 AND record_type = 'snapshot_history' AND version <= $2
+		ORDER BY version DESC
+		LIMIT 1
+		`,
+		lockVersion: `SELECT version FROM ` + table + ` WHERE stream_id = $1 AND record_type = 'snapshot' FOR UPDATE`,
+		count:       `SELECT count(*) FROM ` + table + ` WHERE record_type = 'snapshot'`,
+	}, nil
+}
+
+// encodeSnapshotState marshals state for the state column. Compressed states are
+// stored as a JSON string holding the base64 of the gzipped JSON: states are JSON
+// objects otherwise, so the leading quote marks them and decodeSnapshotState
+// recognizes both forms regardless of the current configuration.
+func encodeSnapshotState(state any, compress bool) ([]byte, error) {
+	data, err := json.Marshal(state)
+	if err != nil || !compress {
+		return data, err
+	}
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return json.Marshal(buf.Bytes())
+}
+
+// decodeSnapshotState returns the JSON of a state written by encodeSnapshotState.
+func decodeSnapshotState(raw []byte) ([]byte, error) {
+	if len(raw) == 0 || raw[0] != '"' {
+		return raw, nil
+	}
+	var compressed []byte
+	if err := json.Unmarshal(raw, &compressed); err != nil {
+		return nil, err
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return nil, fmt.Errorf("could not decompress: %w", err)
+	}
+	defer func() { _ = zr.Close() }()
+	return io.ReadAll(zr)
+}
@@ -1,10 +1,18 @@
 package pgx
 
 import (
+	"bytes"
+	"cmp"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"iter"
+	"maps"
+	"math"
+	"regexp"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/mickamy/go-event-sourcing"
@@ -14,14 +22,46 @@ import (
 )
 
 // EventStore is a concrete EventStore backed by PostgreSQL (pgx).
-// It supports optimistic concurrency, JSON-encoded payloads, and optional
-// context-derived Metadata injection via a user-supplied MetadataExtractor.
+// It supports optimistic concurrency, JSONB or binary payloads (see
+// WithPayloadColumnType), and optional context-derived Metadata injection via a
+// user-supplied MetadataExtractor.
 type EventStore struct {
 	pool         *pgxpool.Pool
+	snapPool     *pgxpool.Pool
 	typeRegistry map[string]ges.EventCodec
+	byContent    map[ges.CodecKey]ges.EventCodec
 	extractor    ges.MetadataExtractor
+	interceptors []ges.AppendInterceptor
+	tenantRouter TenantRouter
+	limiter      *streamLimiter
+	maxBatchSize int
+	payloadType  PayloadColumnType
+
+	snapshotHistory bool
+	singleTable     bool
+	compress        bool
+	maxSnapshotSize int
+	clock           func() time.Time
+	conflictEvents  bool
+	verifyVersions  bool
+	dedup           bool
+	indexedMeta     []string
+	validateID      func(streamID string) error
+	typeNamer       ges.TypeNamer
+	schemas         map[string]ges.PayloadSchema
+	maxVersion      int64
+	retryAttempts   int
+	retryBackoff    func(attempt int) time.Duration
+	onDecodeError   func(ges.DecodeError) ges.DecodeAction
+	unknownFallback bool
+	beforeCommit    []func(ctx context.Context, tx pgx.Tx) error
+	afterCommit     []func(ctx context.Context)
 }
 
+// TenantRouter derives a tenant table suffix from context. It returns ok=false
+// when the context carries no tenant, in which case the shared table is used.
+type TenantRouter func(ctx context.Context) (tableSuffix string, ok bool)
+
 // Option configures EventStore.
 type Option func(*EventStore)
 
@@ -30,6 +70,45 @@ func WithTypeRegistry(reg map[string]ges.EventCodec) Option {
 	return func(s *EventStore) { s.typeRegistry = reg }
 }
 
+// WithContentTypeRegistry sets codecs keyed by event type and content type. Every
+// event is written with the codec from WithTypeRegistry and the content type it
+// reports (see ges.ContentTyper) is stored in the content_type column; reads pick
+// the codec registered here for the row's type and content type, and fall back to
+// the type registry when there is none or the row has no content type. This lets a
+// type move to a new codec while events written with the old one stay readable.
+//
+// With the default JSONB payload column every codec must still produce valid JSON;
+// see WithPayloadColumnType.
+func WithContentTypeRegistry(reg map[ges.CodecKey]ges.EventCodec) Option {
+	return func(s *EventStore) { s.byContent = reg }
+}
+
+// PayloadColumnType is the SQL type of the payload column of the events table.
+type PayloadColumnType int
+
+const (
+	// PayloadJSONB stores payloads as JSONB (the default, and what init.sql creates).
+	// Payloads can be queried and indexed in SQL, but every codec must produce valid
+	// JSON; other payloads are rejected with ErrPayloadNotJSON before a transaction
+	// is started.
+	PayloadJSONB PayloadColumnType = iota
+	// PayloadBytea stores payloads as BYTEA, byte for byte, so binary codecs such as
+	// gob, CBOR, protobuf or msgpack work. Payloads are opaque to SQL.
+	PayloadBytea
+)
+
+// WithPayloadColumnType tells the store the type of the payload column, which must
+// match the table. Existing tables can be converted with:
+//
+//	ALTER TABLE events ALTER COLUMN payload TYPE BYTEA USING convert_to(payload::text, 'UTF8');
+//
+// JSON payloads keep decoding after the conversion, as codecs receive the same
+// JSON text either way. Per-tenant tables (see WithTenantRouter) must match too, and
+// so must tables created with CreatePartitionedEvents.
+func WithPayloadColumnType(t PayloadColumnType) Option {
+	return func(s *EventStore) { s.payloadType = t }
+}
+
 // WithMetadataExtractor sets a function that builds Metadata from context.
 // When provided, Append() will merge extracted metadata with the explicit md;
 // explicit keys take precedence over extracted ones.
@@ -37,6 +116,250 @@ func WithMetadataExtractor(ex ges.MetadataExtractor) Option {
 	return func(s *EventStore) { s.extractor = ex }
 }
 
+// WithAppendInterceptor registers a hook that runs before the append transaction
+// begins. Interceptors run in registration order; the first error aborts the append.
+func WithAppendInterceptor(fn ges.AppendInterceptor) Option {
+	return func(s *EventStore) { s.interceptors = append(s.interceptors, fn) }
+}
+
+// WithTenantRouter routes event reads and writes to a per-tenant table named
+// events_<suffix>, where the suffix is derived from context by router. Contexts
+// without a tenant use the shared events table. Suffixes must match [a-z0-9_]+;
+// anything else is rejected with ErrInvalidTenantSuffix.
+//
+// Tenant tables are not created automatically; create them with the same shape as
+// the shared table, e.g. CREATE TABLE events_acme (LIKE events INCLUDING ALL).
+// Snapshots stay in the shared snapshots table, except with WithSingleTable.
+func WithTenantRouter(router TenantRouter) Option {
+	return func(s *EventStore) { s.tenantRouter = router }
+}
+
+// ErrInvalidTenantSuffix is returned when a TenantRouter yields a suffix that is
+// not a safe table name component.
+var ErrInvalidTenantSuffix = errors.New("ges-pgx: invalid tenant table suffix")
+
+// tenantSuffixPattern keeps "events_" + suffix a plain identifier within
+// PostgreSQL's 63-byte limit.
+var tenantSuffixPattern = regexp.MustCompile(`^[a-z0-9_]{1,56}$`)
+
+// WithStreamConcurrency limits the number of appends to the same stream that may be
+// in flight at once; 1 serializes them. Appends beyond the limit wait for a slot
+// (or for their context to end) before taking a pool connection, so a burst of
+// writes to a hot stream queues in-process instead of exhausting the pool and
+// failing with version conflicts. Different streams are not limited by each other.
+// Values below 1 disable the limit.
+func WithStreamConcurrency(maxInFlight int) Option {
+	return func(s *EventStore) {
+		if maxInFlight < 1 {
+			s.limiter = nil
+			return
+		}
+		s.limiter = newStreamLimiter(maxInFlight)
+	}
+}
+
+// WithMaxBatchSize makes Append reject batches of more than n events with
+// ErrBatchTooLarge before any connection is taken, so a runaway aggregate cannot
+// hold a pooled connection for an arbitrarily long insert. Values below 1 disable
+// the limit. AppendRaw, used for imports, is not limited.
+func WithMaxBatchSize(n int) Option {
+	return func(s *EventStore) { s.maxBatchSize = max(n, 0) }
+}
+
+// WithSnapshotHistory keeps every saved snapshot in the snapshot_history table in
+// addition to the latest one, so LoadSnapshotBefore can serve any past version.
+// History is never pruned by the store.
+func WithSnapshotHistory() Option {
+	return func(s *EventStore) { s.snapshotHistory = true }
+}
+
+// WithSingleTable stores snapshots in the events table instead of the snapshots and
+// snapshot_history tables, for deployments that prefer a single table to migrate
+// and back up. Rows are told apart by a record_type column: 'event' for events,
+// 'snapshot' for the latest snapshot of a stream and 'snapshot_history' for those
+// kept with WithSnapshotHistory. Snapshot states go in the payload column, and with
+// a tenant router they follow the events into the tenant table.
+//
+// The table needs the column, a primary key that includes it and a unique index on
+// the latest snapshots:
+//
+//	ALTER TABLE events ADD COLUMN record_type TEXT NOT NULL DEFAULT 'event';
+//	ALTER TABLE events DROP CONSTRAINT events_pkey, ADD PRIMARY KEY (stream_id, record_type, version);
+//	CREATE UNIQUE INDEX events_latest_snapshot ON events (stream_id) WHERE record_type = 'snapshot';
+//
+// Events are inserted with the column's default, so it must stay 'event'.
+func WithSingleTable() Option {
+	return func(s *EventStore) { s.singleTable = true }
+}
+
+// WithSnapshotPool makes SaveSnapshot, SaveSnapshotWithMeta, LoadSnapshot and
+// LoadSnapshotBefore use pool instead of the store's pool, so heavy snapshotting
+// does not compete with appends for connections. pool must reach the same
+// snapshots table and accept writes. TruncateBefore keeps checking the snapshot
+// version inside its own transaction on the main pool.
+func WithSnapshotPool(pool *pgxpool.Pool) Option {
+	return func(s *EventStore) { s.snapPool = pool }
+}
+
+// WithSnapshotCompression stores snapshot states gzip-compressed. Compressed and
+// plain states are told apart when loading, so the option can be turned on or off
+// for an existing table.
+func WithSnapshotCompression() Option {
+	return func(s *EventStore) { s.compress = true }
+}
+
+// WithMaxSnapshotSize makes SaveSnapshot reject states whose stored form, after
+// compression if enabled, exceeds n bytes with ErrSnapshotTooLarge. Nothing is
+// written, and as snapshots are a cache the aggregate simply keeps replaying its
+// events. Values below 1 disable the limit.
+func WithMaxSnapshotSize(n int) Option {
+	return func(s *EventStore) { s.maxSnapshotSize = max(n, 0) }
+}
+
+// WithClock sets the source of the times recorded for events and snapshots, so tests
+// can assert on them. Without it, times come from the database's now().
+func WithClock(now func() time.Time) Option {
+	return func(s *EventStore) { s.clock = now }
+}
+
+// WithConflictEvents makes Append fill ges.VersionConflictError.ConcurrentEvents
+// with the events appended after the expected version, read in the transaction
+// that detected the conflict. Conflicts only detected by the primary key, when a
+// writer commits between the version check and the insert, carry no events.
+func WithConflictEvents() Option {
+	return func(s *EventStore) { s.conflictEvents = true }
+}
+
+// WithTransientRetry makes Append and AppendRaw re-run their whole transaction when
+// it fails with a transient error: a serialization failure (40001), a deadlock
+// (40P01) or a lost connection, up to attempts attempts in total. backoff gives the
+// delay before each retry, as for ges.WithRetryBackoff; nil means
+// ges.ExponentialBackoff(10ms, 500ms). Version conflicts are never retried, and
+// neither are failed commits whose outcome is unknown.
+func WithTransientRetry(attempts int, backoff func(attempt int) time.Duration) Option {
+	return func(s *EventStore) {
+		if backoff == nil {
+			backoff = ges.ExponentialBackoff(10*time.Millisecond, 500*time.Millisecond)
+		}
+		s.retryAttempts = attempts
+		s.retryBackoff = backoff
+	}
+}
+
+// WithMaxVersion makes appends that would move a stream past version n fail with
+// ges.ErrVersionLimit. Values below 1 leave math.MaxInt64 as the limit.
+func WithMaxVersion(n int64) Option {
+	return func(s *EventStore) { s.maxVersion = n }
+}
+
+// WithVerifyContiguous makes Load, LoadIter and LoadRange check that the versions
+// they return run fromVersion+1, fromVersion+2, ... without gaps, failing with a
+// *ges.VersionGapError at the first missing version. It guards against rows lost to
+// botched migrations or manual deletes. Streams truncated with TruncateBefore must
+// then be read from at least the truncation point.
+func WithVerifyContiguous() Option {
+	return func(s *EventStore) { s.verifyVersions = true }
+}
+
+// WithDedupKeys makes appends honor ges.Deduplicated: an event whose dedup key is
+// already in the stream is dropped if its payload is equal (as JSONB, or byte for
+// byte with PayloadBytea) and fails the
+// append with ges.ErrDuplicateKey otherwise. The same applies to repeated keys
+// within one batch. Duplicates are dropped before the version check, so retrying a
+// batch made only of recorded events succeeds with the current version even if the
+// expected version is stale. AppendRaw is not affected.
+//
+// Keys are stored in a dedup_key column, which databases created before it existed
+// need added first:
+//
+//	ALTER TABLE events ADD COLUMN dedup_key TEXT;
+//	CREATE UNIQUE INDEX events_dedup_key ON events (stream_id, dedup_key) WHERE dedup_key IS NOT NULL;
+func WithDedupKeys() Option {
+	return func(s *EventStore) { s.dedup = true }
+}
+
+// WithBeforeCommit registers a hook that runs inside the append transaction of
+// Append and AppendRaw, after the events are inserted and before the commit, so it
+// can write to the application's own tables atomically with them (e.g. an outbox).
+// Hooks run in registration order; an error rolls the append back and is returned
+// wrapped in a *StoreError. With WithTransientRetry a hook runs again for every attempt.
+func WithBeforeCommit(fn func(ctx context.Context, tx pgx.Tx) error) Option {
+	return func(s *EventStore) { s.beforeCommit = append(s.beforeCommit, fn) }
+}
+
+// WithAfterCommit registers a hook that runs after an append transaction commits,
+// e.g. to notify in-process listeners. Hooks run in registration order and cannot
+// fail the append, which is already durable. Appends that record nothing, such as
+// batches dropped by WithDedupKeys, do not run them.
+func WithAfterCommit(fn func(ctx context.Context)) Option {
+	return func(s *EventStore) { s.afterCommit = append(s.afterCommit, fn) }
+}
+
+// WithIndexedMetadata makes appends also write the metadata values of keys into
+// dedicated md_<key> TEXT columns, next to the full metadata, and makes ReadAll
+// filter on those columns for ges.WithMetadataValue instead of on the JSONB
+// metadata. Strings are stored as is and other values in their fmt.Sprint form;
+// events without the key get NULL. It panics if a key does not match [a-z0-9_]+.
+//
+// The columns are not created automatically. init.sql has them for tenant_id and
+// user_id; add others, with an index for the reads, like:
+//
+//	ALTER TABLE events ADD COLUMN md_region TEXT;
+//	CREATE INDEX events_md_region ON events (md_region, position);
+func WithIndexedMetadata(keys ...string) Option {
+	for _, key := range keys {
+		if !metadataKeyPattern.MatchString(key) {
+			panic(fmt.Sprintf("ges-pgx: metadata key %q cannot be a column name", key))
+		}
+	}
+	return func(s *EventStore) { s.indexedMeta = append(s.indexedMeta, keys...) }
+}
+
+// WithStreamIDValidator makes appends and reads of a single stream, such as Load,
+// LoadRange or Exists, reject stream IDs for which validate returns an error,
+// returning that error before a connection is taken. Empty batches are no-ops and
+// are not checked. ges.ValidateStreamID enforces the
+// "<Category>:<ID>" convention.
+func WithStreamIDValidator(validate func(streamID string) error) Option {
+	return func(s *EventStore) { s.validateID = validate }
+}
+
+// WithTypeNamer sets how the event_type of appended events is derived, instead of
+// ges.EventType. The type registry must be keyed by the names it produces, and so
+// must type filters passed to ReadAll.
+func WithTypeNamer(namer ges.TypeNamer) Option {
+	return func(s *EventStore) { s.typeNamer = namer }
+}
+
+// WithSchemaRegistry makes appends validate the encoded payload of every event whose
+// type has a schema in schemas, rejecting the batch with an error wrapping
+// ges.ErrSchemaViolation if one does not conform (see ges.ValidatePayload). Only
+// payloads of JSON codecs are validated.
+//
+// AppendRaw is exempt: it restores events that were accepted when they were first
+// appended, e.g. for ges.Sync and ges.Restore, and those may predate the schema.
+// Call ges.ValidatePayload before AppendRaw to check raw payloads anyway.
+func WithSchemaRegistry(schemas map[string]ges.PayloadSchema) Option {
+	return func(s *EventStore) { s.schemas = schemas }
+}
+
+// WithUnknownEventFallback makes reads deliver events whose type has no codec with a
+// ges.UnknownEvent payload holding the stored bytes, instead of failing with
+// ErrUnknownEventType, for consumers and relays that must get through streams with
+// types they do not know yet. Payloads that a registered codec fails to decode are
+// still handled as set with WithOnDecodeError.
+func WithUnknownEventFallback() Option {
+	return func(s *EventStore) { s.unknownFallback = true }
+}
+
+// WithOnDecodeError decides what reads do with events that cannot be decoded. With
+// ges.DecodeSkip the event is delivered with a ges.SkippedEvent payload instead of
+// failing the read, so one corrupt row does not make its aggregate unloadable; fn is
+// the place to log it. Without this option every such event fails the read.
+func WithOnDecodeError(fn func(ges.DecodeError) ges.DecodeAction) Option {
+	return func(s *EventStore) { s.onDecodeError = fn }
+}
+
 // NewEventStore creates a Postgres-backed EventStore.
 func NewEventStore(pool *pgxpool.Pool, opts ...Option) *EventStore {
 	s := &EventStore{
@@ -50,6 +373,7 @@ func NewEventStore(pool *pgxpool.Pool, opts ...Option) *EventStore {
 }
 
 // Append persists a batch of events using optimistic concurrency control.
+// An empty batch is a no-op that does not start a transaction (see EnsureVersion).
 func (s *EventStore) Append(
 	ctx context.Context,
 	streamID string,
@@ -57,6 +381,123 @@ func (s *EventStore) Append(
 	events []ges.Event,
 	md ges.Metadata,
 ) (int64, error) {
+	envelopes := make([]ges.Envelope, len(events))
+	for i, e := range events {
+		envelopes[i] = ges.Envelope{Event: e}
+	}
+	return s.AppendEnvelopes(ctx, streamID, expectedVersion, envelopes, md)
+}
+
+// AppendAuto appends events after the current version of streamID, read in the
+// same transaction as the insert. A writer appending in between is still caught
+// by the primary key and reported as a *ges.VersionConflictError.
+func (s *EventStore) AppendAuto(
+	ctx context.Context,
+	streamID string,
+	events []ges.Event,
+	md ges.Metadata,
+) (int64, error) {
+	if len(events) == 0 {
+		table, err := s.eventsTable(ctx)
+		if err != nil {
+			return 0, err
+		}
+		var currentVersion int64
+		if err := s.pool.QueryRow(
+			ctx,
+			`SELECT COALESCE(MAX(version), 0) FROM `+table+` WHERE stream_id = $1`+s.eventRows(),
+			streamID,
+		).Scan(&currentVersion); err != nil {
+			return 0, &StoreError{Op: "get current version", StreamID: streamID, Err: err}
+		}
+		return currentVersion, nil
+	}
+
+	envelopes := make([]ges.Envelope, len(events))
+	for i, e := range events {
+		envelopes[i] = ges.Envelope{Event: e}
+	}
+	version, _, err := s.appendEnvelopes(ctx, streamID, anyVersion, envelopes, md)
+	return version, err
+}
+
+// AppendEnvelopes is like Append but also persists each envelope's headers into the
+// per-event headers column. A non-zero OccurredAt is stored as the event time.
+func (s *EventStore) AppendEnvelopes(
+	ctx context.Context,
+	streamID string,
+	expectedVersion int64,
+	envelopes []ges.Envelope,
+	md ges.Metadata,
+) (int64, error) {
+	if expectedVersion < 0 {
+		return 0, fmt.Errorf("%w: %d", ges.ErrInvalidExpectedVersion, expectedVersion)
+	}
+	if len(envelopes) == 0 {
+		return expectedVersion, nil
+	}
+	version, _, err := s.appendEnvelopes(ctx, streamID, expectedVersion, envelopes, md)
+	return version, err
+}
+
+// AppendWithPosition is like Append but also returns the position of the last
+// appended event, or 0 if none was appended. Pass it to ges.WaitForPosition with a
+// store reading from a replica to read your own writes there.
+func (s *EventStore) AppendWithPosition(
+	ctx context.Context,
+	streamID string,
+	expectedVersion int64,
+	events []ges.Event,
+	md ges.Metadata,
+) (version, position int64, err error) {
+	if expectedVersion < 0 {
+		return 0, 0, fmt.Errorf("%w: %d", ges.ErrInvalidExpectedVersion, expectedVersion)
+	}
+	if len(events) == 0 {
+		return expectedVersion, 0, nil
+	}
+	envelopes := make([]ges.Envelope, len(events))
+	for i, e := range events {
+		envelopes[i] = ges.Envelope{Event: e}
+	}
+	return s.appendEnvelopes(ctx, streamID, expectedVersion, envelopes, md)
+}
+
+// anyVersion makes appendEnvelopes append after the current version instead of
+// checking it.
+const anyVersion = -1
+
+func (s *EventStore) appendEnvelopes(
+	ctx context.Context,
+	streamID string,
+	expectedVersion int64,
+	envelopes []ges.Envelope,
+	md ges.Metadata,
+) (version, position int64, err error) {
+	if err := s.validateStreamID(streamID); err != nil {
+		return 0, 0, err
+	}
+	if s.maxBatchSize > 0 && len(envelopes) > s.maxBatchSize {
+		return 0, 0, fmt.Errorf("%w: %d events for %s, limit is %d", ErrBatchTooLarge, len(envelopes), streamID, s.maxBatchSize)
+	}
+	for i, env := range envelopes {
+		if env.Event == nil {
+			return 0, 0, fmt.Errorf("%w: event %d of %d for %s", ges.ErrNilEvent, i+1, len(envelopes), streamID)
+		}
+	}
+
+	// Encode everything up front so that unregistered types and encoding failures
+	// are reported before a connection is taken or a transaction begun.
+	encoded, err := s.encodeEnvelopes(envelopes)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	events := make([]ges.Event, len(envelopes))
+	for i, env := range envelopes {
+		events[i] = env.Event
+	}
+
 	// Merge context-derived metadata (if configured) with explicit md.
 	// Later maps take precedence → explicit md overrides extracted.
 	if s.extractor != nil {
@@ -64,9 +505,47 @@ func (s *EventStore) Append(
 		md = extracted.Merge(md)
 	}
 
+	if len(s.interceptors) > 0 {
+		md = md.Merge() // interceptors may enrich md; hand them a private copy
+		for _, intercept := range s.interceptors {
+			if err := intercept(ctx, streamID, events, md); err != nil {
+				return 0, 0, err
+			}
+		}
+	}
+
+	table, err := s.eventsTable(ctx)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if s.limiter != nil {
+		release, err := s.limiter.acquire(ctx, table+"/"+streamID)
+		if err != nil {
+			return 0, 0, &StoreError{Op: "acquire stream slot", StreamID: streamID, Err: err}
+		}
+		defer release()
+	}
+
+	version, err = s.retryTransient(ctx, func() (int64, error) {
+		var err error
+		version, position, err = s.insertEnvelopes(ctx, table, streamID, expectedVersion, encoded, md)
+		return version, err
+	})
+	return version, position, err
+}
+
+// insertEnvelopes runs the transaction of appendEnvelopes.
+func (s *EventStore) insertEnvelopes(
+	ctx context.Context,
+	table, streamID string,
+	expectedVersion int64,
+	encoded []encodedEvent,
+	md ges.Metadata,
+) (version, position int64, err error) {
 	tx, err := s.pool.Begin(ctx)
 	if err != nil {
-		return 0, fmt.Errorf("ges-pgx: could not begin transaction: %w", err)
+		return 0, 0, &StoreError{Op: "begin transaction", StreamID: streamID, Err: err}
 	}
 	defer func(tx pgx.Tx, ctx context.Context) {
 		_ = tx.Rollback(ctx)
@@ -76,191 +555,1694 @@ func (s *EventStore) Append(
 	var currentVersion int64
 	if err := tx.QueryRow(
 		ctx,
-		`SELECT COALESCE(MAX(version), 0) FROM events WHERE stream_id = $1`,
+		`SELECT COALESCE(MAX(version), 0) FROM `+table+` WHERE stream_id = $1`+s.eventRows(),
 		streamID,
 	).Scan(&currentVersion); err != nil {
-		return 0, fmt.Errorf("ges-pgx: could not get current version: %w", err)
+		return 0, 0, &StoreError{Op: "get current version", StreamID: streamID, Err: err}
+	}
+	if s.dedup {
+		kept, err := s.dropDuplicates(ctx, tx, table, streamID, encoded)
+		if err != nil {
+			return 0, 0, err
+		}
+		if len(kept) == 0 {
+			return currentVersion, 0, nil
+		}
+		encoded = kept
+	}
+	if expectedVersion == anyVersion {
+		expectedVersion = currentVersion
 	}
 	if currentVersion != expectedVersion {
-		return 0, &ges.VersionConflictError{
+		conflict := &ges.VersionConflictError{
 			StreamID:        streamID,
 			ExpectedVersion: expectedVersion,
 			ActualVersion:   currentVersion,
 		}
-	}
-
-	if len(events) == 0 {
-		if err := tx.Commit(ctx); err != nil {
-			return 0, fmt.Errorf("ges-pgx: could not commit transaction: %w", err)
+		if s.conflictEvents && currentVersion > expectedVersion {
+			events, err := s.loadConcurrent(ctx, tx, table, streamID, expectedVersion)
+			if err != nil {
+				return 0, 0, err
+			}
+			conflict.ConcurrentEvents = events
 		}
-		return expectedVersion, nil
+		return 0, 0, conflict
+	}
+	if err := s.checkVersionLimit(streamID, currentVersion, len(encoded)); err != nil {
+		return 0, 0, err
 	}
 
-	// Insert each event with the next version.
-	for _, e := range events {
-		eventType := ges.EventType(e)
-		codec := s.typeRegistry[eventType]
-		if codec == nil {
-			return 0, fmt.Errorf("ges-pgx: no codec registered for event type %q", eventType)
-		}
-
-		payload, err := codec.Encode(e)
-		if err != nil {
-			return 0, fmt.Errorf("ges-pgx: could not encode event: %w", err)
-		}
+	meta, err := json.Marshal(md)
+	if err != nil {
+		return 0, 0, &StoreError{Op: "encode metadata", StreamID: streamID, Err: err}
+	}
 
-		meta, err := json.Marshal(md)
-		if err != nil {
-			return 0, fmt.Errorf("ges-pgx: could not encode metadata: %w", err)
-		}
+	insert := s.insertSQL(table)
+	indexed := s.indexedValues(md)
 
+	// Insert each event with the next version.
+	recordedAt := s.now()
+	for _, ev := range encoded {
 		currentVersion++
 
-		if _, err := tx.Exec(
-			ctx,
-			`
-			INSERT INTO events (stream_id, version, event_type, payload, metadata)
-			VALUES ($1, $2, $3, $4, $5)
-			`,
+		args := []any{
 			streamID,
 			currentVersion,
-			eventType,
-			payload,
+			ev.typ,
+			ev.contentType,
+			ev.payload,
 			meta,
-		); err != nil {
+			ev.headers,
+			recordedAt,
+			ev.occurredAt,
+		}
+		if s.dedup {
+			args = append(args, ev.dedupKey)
+		}
+		args = append(args, indexed...)
+		if err := tx.QueryRow(ctx, insert, args...).Scan(&position); err != nil {
 			if isUniqueViolation(err) {
-				return 0, &ges.VersionConflictError{
+				return 0, 0, &ges.VersionConflictError{
 					StreamID:        streamID,
 					ExpectedVersion: expectedVersion,
 					ActualVersion:   currentVersion,
 				}
 			}
-			return 0, fmt.Errorf("ges-pgx: could not insert event: %w", err)
+			return 0, 0, &StoreError{Op: "insert event", StreamID: streamID, Err: err}
 		}
 	}
 
-	if err := tx.Commit(ctx); err != nil {
-		return 0, fmt.Errorf("ges-pgx: could not commit transaction: %w", err)
+	if err := s.commit(ctx, tx, streamID); err != nil {
+		return 0, 0, err
 	}
-	return currentVersion, nil
+	return currentVersion, position, nil
 }
 
-// Load returns all events for a given stream strictly after fromVersion,
-// ordered by version ascending. The second return value is the last version read.
-func (s *EventStore) Load(
+// insertSQL returns the statement inserting one event into table and returning its
+// position. Its parameters are the nine base columns, then the dedup key with
+// WithDedupKeys, then the values of WithIndexedMetadata (see indexedValues).
+func (s *EventStore) insertSQL(table string) string {
+	columns := []string{"stream_id", "version", "event_type", "content_type", "payload", "metadata", "headers", "at", "occurred_at"}
+	values := []string{"$1", "$2", "$3", "$4", "$5", "$6", "$7", "COALESCE($8, now())", "COALESCE($9, $8, now())"}
+	if s.dedup {
+		columns = append(columns, "dedup_key")
+		values = append(values, fmt.Sprintf("NULLIF($%d, '')", len(values)+1))
+	}
+	for _, key := range s.indexedMeta {
+		columns = append(columns, "md_"+key)
+		values = append(values, fmt.Sprintf("$%d", len(values)+1))
+	}
+	return `INSERT INTO ` + table + ` (` + strings.Join(columns, ", ") + `)
+		VALUES (` + strings.Join(values, ", ") + `)
+		RETURNING position`
+}
+
+// indexedValues returns the md_<key> column values of md for WithIndexedMetadata.
+func (s *EventStore) indexedValues(md ges.Metadata) []any {
+	out := make([]any, len(s.indexedMeta))
+	for i, key := range s.indexedMeta {
+		switch v := md[key].(type) {
+		case nil:
+		case string:
+			out[i] = v
+		default:
+			out[i] = fmt.Sprint(v)
+		}
+	}
+	return out
+}
+
+// dropDuplicates returns encoded without the events whose dedup key is already in
+// the stream, or earlier in the batch, with an equal payload.
+func (s *EventStore) dropDuplicates(
 	ctx context.Context,
-	streamID string,
-	fromVersion int64,
-) ([]ges.Event, int64, error) {
-	rows, err := s.pool.Query(
+	tx pgx.Tx,
+	table, streamID string,
+	encoded []encodedEvent,
+) ([]encodedEvent, error) {
+	equalPayload := `payload = $3::jsonb`
+	if s.payloadType == PayloadBytea {
+		equalPayload = `payload = $3::bytea`
+	}
+
+	batch := make(map[string][]byte)
+	kept := make([]encodedEvent, 0, len(encoded))
+	for _, ev := range encoded {
+		if ev.dedupKey == "" {
+			kept = append(kept, ev)
+			continue
+		}
+		if prev, ok := batch[ev.dedupKey]; ok {
+			if !bytes.Equal(prev, ev.payload) {
+				return nil, fmt.Errorf("%w: %q in %s", ges.ErrDuplicateKey, ev.dedupKey, streamID)
+			}
+			continue
+		}
+
+		var equal bool
+		err := tx.QueryRow(
+			ctx,
+			`SELECT `+equalPayload+` FROM `+table+` WHERE stream_id = $1 AND dedup_key = $2`,
+			streamID,
+			ev.dedupKey,
+			ev.payload,
+		).Scan(&equal)
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			kept = append(kept, ev)
+		case err != nil:
+			return nil, &StoreError{Op: "look up dedup key", StreamID: streamID, Err: err}
+		case !equal:
+			return nil, fmt.Errorf("%w: %q in %s", ges.ErrDuplicateKey, ev.dedupKey, streamID)
+		}
+		batch[ev.dedupKey] = ev.payload
+	}
+	return kept, nil
+}
+
+// loadConcurrent reads the events of streamID after expectedVersion within tx.
+func (s *EventStore) loadConcurrent(
+	ctx context.Context,
+	tx pgx.Tx,
+	table, streamID string,
+	expectedVersion int64,
+) ([]ges.StoredEvent, error) {
+	rows, err := tx.Query(
 		ctx,
 		`
-		SELECT version, event_type, payload
-		FROM events
-		WHERE stream_id = $1 AND version > $2
+		SELECT `+eventColumns+`
+		FROM `+table+`
+		WHERE stream_id = $1 AND version > $2`+s.eventRows()+`
 		ORDER BY version ASC
 		`,
 		streamID,
-		fromVersion,
+		expectedVersion,
 	)
 	if err != nil {
-		return nil, 0, fmt.Errorf("ges-pgx: could not query events: %w", err)
+		return nil, &StoreError{Op: "query concurrent events", StreamID: streamID, Err: err}
 	}
 	defer rows.Close()
 
-	var out []ges.Event
-	var last int64
-
+	var out []ges.StoredEvent
 	for rows.Next() {
-		var version int64
-		var eventType string
-		var payload []byte
-
-		if err := rows.Scan(&version, &eventType, &payload); err != nil {
-			return nil, 0, fmt.Errorf("ges-pgx: could not scan event: %w", err)
+		ev, err := s.scanEvent(rows)
+		if err != nil {
+			return nil, err
 		}
+		out = append(out, ev)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, &StoreError{Op: "read concurrent events", StreamID: streamID, Err: err}
+	}
+	return out, nil
+}
+
+// now returns the configured clock's time, or nil to use the database's now().
+func (s *EventStore) now() *time.Time {
+	if s.clock == nil {
+		return nil
+	}
+	t := s.clock()
+	return &t
+}
 
+// encodedEvent is an envelope encoded for insertion into the events table.
+type encodedEvent struct {
+	typ         string
+	contentType string
+	payload     []byte
+	headers     []byte
+	occurredAt  *time.Time // nil means the time the event is recorded
+	dedupKey    string     // set only with WithDedupKeys
+}
+
+// encodeEnvelopes encodes envelopes with the registered codecs. It fails with
+// ErrUnknownEventType if any event type has no codec.
+func (s *EventStore) encodeEnvelopes(envelopes []ges.Envelope) ([]encodedEvent, error) {
+	out := make([]encodedEvent, len(envelopes))
+	for i, env := range envelopes {
+		eventType := s.eventType(env.Event)
 		codec := s.typeRegistry[eventType]
 		if codec == nil {
-			return nil, 0, fmt.Errorf("unknown event type: %s", eventType)
+			return nil, fmt.Errorf("%w: %q (event %d of %d)", ErrUnknownEventType, eventType, i+1, len(envelopes))
 		}
 
-		ev, err := codec.Decode(payload)
+		payload, err := codec.Encode(env.Event)
 		if err != nil {
-			return nil, 0, fmt.Errorf("ges-pgx: could not decode event: %w", err)
+			return nil, &StoreError{Op: "encode event", Err: fmt.Errorf("%q: %w", eventType, err)}
+		}
+		if err := s.checkPayload(eventType, payload); err != nil {
+			return nil, err
+		}
+		if err := ges.ValidatePayload(s.schemas, eventType, ges.ContentTypeOf(codec), payload); err != nil {
+			return nil, err
 		}
 
-		out = append(out, ev)
-		last = version
+		hdr := env.Headers
+		if hdr == nil {
+			hdr = map[string]string{} // encode as {} rather than null
+		}
+		headers, err := json.Marshal(hdr)
+		if err != nil {
+			return nil, &StoreError{Op: "encode headers", Err: err}
+		}
+
+		out[i] = encodedEvent{
+			typ:         eventType,
+			contentType: ges.ContentTypeOf(codec),
+			payload:     payload,
+			headers:     headers,
+		}
+		if s.dedup {
+			out[i].dedupKey = ges.DedupKey(env.Event)
+		}
+		if !env.OccurredAt.IsZero() {
+			out[i].occurredAt = &env.OccurredAt
+		}
 	}
-	return out, last, nil
+	return out, nil
 }
 
-// SaveSnapshot upserts the snapshot state for a stream at a given version.
-// Snapshots are an optimization for fast rehydration and are safe to treat
-// as a cache—failure to save should not compromise domain consistency.
-func (s *EventStore) SaveSnapshot(
+// AppendRaw appends already encoded events in a single transaction. Each payload
+// must be the []byte produced by the codec of its type and ContentType; both are
+// stored unchanged.
+// Metadata, headers and times are kept as given, and extractors and interceptors
+// are not applied.
+func (s *EventStore) AppendRaw(
 	ctx context.Context,
 	streamID string,
-	version int64,
-	state any,
-) error {
-	data, err := json.Marshal(state)
+	expectedVersion int64,
+	events []ges.StoredEvent,
+) (int64, error) {
+	if expectedVersion < 0 {
+		return 0, fmt.Errorf("%w: %d", ges.ErrInvalidExpectedVersion, expectedVersion)
+	}
+	if len(events) == 0 {
+		return expectedVersion, nil
+	}
+	if err := s.validateStreamID(streamID); err != nil {
+		return 0, err
+	}
+	for _, ev := range events {
+		if payload, ok := ev.Payload.([]byte); ok {
+			if err := s.checkPayload(ev.Type, payload); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	table, err := s.eventsTable(ctx)
 	if err != nil {
-		return err
+		return 0, err
 	}
-	_, err = s.pool.Exec(
-		ctx,
-		`
-		INSERT INTO snapshots (stream_id, version, state)
-		VALUES ($1, $2, $3)
-		ON CONFLICT (stream_id) DO UPDATE
-		SET version = EXCLUDED.version,
-		    state   = EXCLUDED.state
-		`,
-		streamID,
-		version,
-		data,
-	)
-	return err
+
+	if s.limiter != nil {
+		release, err := s.limiter.acquire(ctx, table+"/"+streamID)
+		if err != nil {
+			return 0, &StoreError{Op: "acquire stream slot", StreamID: streamID, Err: err}
+		}
+		defer release()
+	}
+
+	return s.retryTransient(ctx, func() (int64, error) {
+		return s.insertRaw(ctx, table, streamID, expectedVersion, events)
+	})
 }
 
-// LoadSnapshot retrieves the latest snapshot for a stream. If not found, Found=false.
-// The State is returned as a generic structure (typically map[string]any) since the
-// library does not enforce a concrete aggregate type; applications can re-decode it.
-func (s *EventStore) LoadSnapshot(
+// snapshotBeyond reports whether streamID has a snapshot after version, locking it
+// in tx.
+func (s *EventStore) snapshotBeyond(ctx context.Context, tx pgx.Tx, streamID string, version int64) (bool, error) {
+	statements, err := s.snapshotStatements(ctx)
+	if err != nil {
+		return false, err
+	}
+	var snapshotVersion int64
+	err = tx.QueryRow(ctx, statements.lockVersion, streamID).Scan(&snapshotVersion)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, &StoreError{Op: "get snapshot version", StreamID: streamID, Err: err}
+	}
+	return snapshotVersion > version, nil
+}
+
+// insertRaw runs the transaction of AppendRaw.
+func (s *EventStore) insertRaw(
+	ctx context.Context,
+	table, streamID string,
+	expectedVersion int64,
+	events []ges.StoredEvent,
+) (int64, error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return 0, &StoreError{Op: "begin transaction", StreamID: streamID, Err: err}
+	}
+	defer func(tx pgx.Tx, ctx context.Context) {
+		_ = tx.Rollback(ctx)
+	}(tx, ctx)
+
+	var currentVersion int64
+	if err := tx.QueryRow(
+		ctx,
+		`SELECT COALESCE(MAX(version), 0) FROM `+table+` WHERE stream_id = $1`+s.eventRows(),
+		streamID,
+	).Scan(&currentVersion); err != nil {
+		return 0, &StoreError{Op: "get current version", StreamID: streamID, Err: err}
+	}
+	if currentVersion != expectedVersion {
+		truncated := false
+		if currentVersion == 0 {
+			if truncated, err = s.snapshotBeyond(ctx, tx, streamID, expectedVersion); err != nil {
+				return 0, err
+			}
+		}
+		if !truncated {
+			return 0, &ges.VersionConflictError{
+				StreamID:        streamID,
+				ExpectedVersion: expectedVersion,
+				ActualVersion:   currentVersion,
+			}
+		}
+		// A truncated stream being restored starts after its first version.
+		currentVersion = expectedVersion
+	}
+	if err := s.checkVersionLimit(streamID, currentVersion, len(events)); err != nil {
+		return 0, err
+	}
+
+	for _, ev := range events {
+		payload, ok := ev.Payload.([]byte)
+		if !ok {
+			return 0, &StoreError{Op: "check raw payload", StreamID: streamID,
+				Err: fmt.Errorf("%q is %T, not []byte", ev.Type, ev.Payload)}
+		}
+		meta, err := json.Marshal(ev.Metadata)
+		if err != nil {
+			return 0, &StoreError{Op: "encode metadata", StreamID: streamID, Err: err}
+		}
+		hdr := ev.Headers
+		if hdr == nil {
+			hdr = map[string]string{}
+		}
+		headers, err := json.Marshal(hdr)
+		if err != nil {
+			return 0, &StoreError{Op: "encode headers", StreamID: streamID, Err: err}
+		}
+		var occurredAt, recordedAt *time.Time
+		if t := cmp.Or(ev.RecordedAt, ev.At); !t.IsZero() {
+			recordedAt = &t
+		} else {
+			recordedAt = s.now()
+		}
+		if !ev.OccurredAt.IsZero() {
+			occurredAt = &ev.OccurredAt
+		}
+
+		currentVersion++
+
+		args := []any{
+			streamID,
+			currentVersion,
+			ev.Type,
+			ev.ContentType,
+			payload,
+			meta,
+			headers,
+			recordedAt,
+			occurredAt,
+		}
+		if s.dedup {
+			args = append(args, "") // raw events are restored, not deduplicated
+		}
+		args = append(args, s.indexedValues(ev.Metadata)...)
+		if _, err := tx.Exec(ctx, s.insertSQL(table), args...); err != nil {
+			if isUniqueViolation(err) {
+				return 0, &ges.VersionConflictError{
+					StreamID:        streamID,
+					ExpectedVersion: expectedVersion,
+					ActualVersion:   currentVersion,
+				}
+			}
+			return 0, &StoreError{Op: "insert event", StreamID: streamID, Err: err}
+		}
+	}
+
+	if err := s.commit(ctx, tx, streamID); err != nil {
+		return 0, err
+	}
+	return currentVersion, nil
+}
+
+// EnsureVersion returns a *ges.VersionConflictError unless the stream is currently
+// at expectedVersion. It is a single read; no transaction is started. It reads from
+// the pool even within ReadInTransaction, whose snapshot may predate the version.
+func (s *EventStore) EnsureVersion(
+	ctx context.Context,
+	streamID string,
+	expectedVersion int64,
+) error {
+	if err := s.validateStreamID(streamID); err != nil {
+		return err
+	}
+	if expectedVersion < 0 {
+		return fmt.Errorf("%w: %d", ges.ErrInvalidExpectedVersion, expectedVersion)
+	}
+	table, err := s.eventsTable(ctx)
+	if err != nil {
+		return err
+	}
+
+	var currentVersion int64
+	if err := s.pool.QueryRow(
+		ctx,
+		`SELECT COALESCE(MAX(version), 0) FROM `+table+` WHERE stream_id = $1`+s.eventRows(),
+		streamID,
+	).Scan(&currentVersion); err != nil {
+		return &StoreError{Op: "get current version", StreamID: streamID, Err: err}
+	}
+	if currentVersion != expectedVersion {
+		return &ges.VersionConflictError{
+			StreamID:        streamID,
+			ExpectedVersion: expectedVersion,
+			ActualVersion:   currentVersion,
+		}
+	}
+	return nil
+}
+
+// Load returns all events for a given stream strictly after fromVersion,
+// ordered by version ascending. The second return value is the stream's version:
+// that of the last event read, or, when no event follows fromVersion, that of the
+// stream, which costs an extra query.
+func (s *EventStore) Load(
+	ctx context.Context,
+	streamID string,
+	fromVersion int64,
+) ([]ges.Event, int64, error) {
+	if err := s.validateStreamID(streamID); err != nil {
+		return nil, 0, err
+	}
+
+	var out []ges.Event
+	var last int64
+
+	for ev, err := range s.LoadIter(ctx, streamID, fromVersion) {
+		if err != nil {
+			return nil, 0, err
+		}
+		out = append(out, ev.Payload)
+		last = ev.Version
+	}
+	if len(out) == 0 && fromVersion > 0 {
+		// Nothing after fromVersion; report the stream's version all the same.
+		version, err := s.currentVersion(ctx, streamID)
+		return nil, version, err
+	}
+	return out, last, nil
+}
+
+// currentVersion returns the version of streamID, 0 if it has no events.
+func (s *EventStore) currentVersion(ctx context.Context, streamID string) (int64, error) {
+	table, err := s.eventsTable(ctx)
+	if err != nil {
+		return 0, err
+	}
+	var version int64
+	if err := s.reader(ctx).QueryRow(
+		ctx,
+		`SELECT COALESCE(MAX(version), 0) FROM `+table+` WHERE stream_id = $1`+s.eventRows(),
+		streamID,
+	).Scan(&version); err != nil {
+		return 0, &StoreError{Op: "get current version", StreamID: streamID, Err: err}
+	}
+	return version, nil
+}
+
+// Exists reports whether streamID has any events.
+func (s *EventStore) Exists(ctx context.Context, streamID string) (bool, error) {
+	if err := s.validateStreamID(streamID); err != nil {
+		return false, err
+	}
+	table, err := s.eventsTable(ctx)
+	if err != nil {
+		return false, err
+	}
+	var exists bool
+	if err := s.reader(ctx).QueryRow(
+		ctx,
+		`SELECT EXISTS (SELECT 1 FROM `+table+` WHERE stream_id = $1`+s.eventRows()+`)`,
+		streamID,
+	).Scan(&exists); err != nil {
+		return false, &StoreError{Op: "check stream", StreamID: streamID, Err: err}
+	}
+	return exists, nil
+}
+
+// LoadIter streams the events of a stream strictly after fromVersion, ordered by
+// version ascending, decoding one row at a time. The underlying connection is held
+// until iteration finishes, so keep the loop body short.
+func (s *EventStore) LoadIter(
+	ctx context.Context,
+	streamID string,
+	fromVersion int64,
+) iter.Seq2[ges.StoredEvent, error] {
+	return func(yield func(ges.StoredEvent, error) bool) {
+		if err := s.validateStreamID(streamID); err != nil {
+			yield(ges.StoredEvent{}, err)
+			return
+		}
+		table, err := s.eventsTable(ctx)
+		if err != nil {
+			yield(ges.StoredEvent{}, err)
+			return
+		}
+
+		rows, err := s.reader(ctx).Query(
+			ctx,
+			`
+			SELECT `+eventColumns+`
+			FROM `+table+`
+			WHERE stream_id = $1 AND version > $2`+s.eventRows()+`
+			ORDER BY version ASC
+			`,
+			streamID,
+			fromVersion,
+		)
+		if err != nil {
+			yield(ges.StoredEvent{}, &StoreError{Op: "query events", StreamID: streamID, Err: err})
+			return
+		}
+		defer rows.Close()
+
+		next := fromVersion + 1
+		for rows.Next() {
+			ev, err := s.scanEvent(rows)
+			if err == nil {
+				err = s.verifyVersion(ev, next)
+			}
+			if err != nil {
+				yield(ges.StoredEvent{}, err)
+				return
+			}
+			if !yield(ev, nil) {
+				return
+			}
+			next++
+		}
+		if err := rows.Err(); err != nil {
+			yield(ges.StoredEvent{}, &StoreError{Op: "read events", StreamID: streamID, Err: err})
+		}
+	}
+}
+
+// LoadRaw returns the events of a stream strictly after fromVersion, ordered by
+// version ascending, with each payload left as the stored []byte.
+func (s *EventStore) LoadRaw(
+	ctx context.Context,
+	streamID string,
+	fromVersion int64,
+) ([]ges.StoredEvent, error) {
+	return s.LoadRawRange(ctx, streamID, fromVersion, math.MaxInt64)
+}
+
+// LoadRawRange is like LoadRaw, restricted to fromVersion < version <= toVersion.
+func (s *EventStore) LoadRawRange(
+	ctx context.Context,
+	streamID string,
+	fromVersion, toVersion int64,
+) ([]ges.StoredEvent, error) {
+	if err := s.validateStreamID(streamID); err != nil {
+		return nil, err
+	}
+	table, err := s.eventsTable(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.reader(ctx).Query(
+		ctx,
+		`
+		SELECT `+eventColumns+`
+		FROM `+table+`
+		WHERE stream_id = $1 AND version > $2 AND version <= $3`+s.eventRows()+`
+		ORDER BY version ASC
+		`,
+		streamID,
+		fromVersion,
+		toVersion,
+	)
+	if err != nil {
+		return nil, &StoreError{Op: "query events", StreamID: streamID, Err: err}
+	}
+	defer rows.Close()
+
+	var out []ges.StoredEvent
+	for rows.Next() {
+		ev, err := scanRawEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, ev)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, &StoreError{Op: "read events", StreamID: streamID, Err: err}
+	}
+	return out, nil
+}
+
+// LoadRange returns the events of a stream with fromVersion < version <= toVersion,
+// ordered by version ascending.
+func (s *EventStore) LoadRange(
+	ctx context.Context,
+	streamID string,
+	fromVersion, toVersion int64,
+) ([]ges.StoredEvent, error) {
+	if err := s.validateStreamID(streamID); err != nil {
+		return nil, err
+	}
+	table, err := s.eventsTable(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.reader(ctx).Query(
+		ctx,
+		`
+		SELECT `+eventColumns+`
+		FROM `+table+`
+		WHERE stream_id = $1 AND version > $2 AND version <= $3`+s.eventRows()+`
+		ORDER BY version ASC
+		`,
+		streamID,
+		fromVersion,
+		toVersion,
+	)
+	if err != nil {
+		return nil, &StoreError{Op: "query events", StreamID: streamID, Err: err}
+	}
+	defer rows.Close()
+
+	var out []ges.StoredEvent
+	for rows.Next() {
+		ev, err := s.scanEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.verifyVersion(ev, fromVersion+int64(len(out))+1); err != nil {
+			return nil, err
+		}
+		out = append(out, ev)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, &StoreError{Op: "read events", StreamID: streamID, Err: err}
+	}
+	return out, nil
+}
+
+// LoadLast returns the last n events of streamID in version order.
+func (s *EventStore) LoadLast(ctx context.Context, streamID string, n int) ([]ges.StoredEvent, error) {
+	if err := s.validateStreamID(streamID); err != nil {
+		return nil, err
+	}
+	if n <= 0 {
+		return nil, nil
+	}
+	table, err := s.eventsTable(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.reader(ctx).Query(
+		ctx,
+		`
+		SELECT `+eventColumns+`
+		FROM `+table+`
+		WHERE stream_id = $1`+s.eventRows()+`
+		ORDER BY version DESC
+		LIMIT $2
+		`,
+		streamID,
+		n,
+	)
+	if err != nil {
+		return nil, &StoreError{Op: "query events", StreamID: streamID, Err: err}
+	}
+	defer rows.Close()
+
+	var out []ges.StoredEvent
+	for rows.Next() {
+		ev, err := s.scanEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, ev)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, &StoreError{Op: "read events", StreamID: streamID, Err: err}
+	}
+	slices.Reverse(out)
+	return out, nil
+}
+
+// LoadPage returns up to limit events of streamID after afterVersion in the direction
+// given by desc, and whether more follow (see ges.PageLoader). It seeks on the
+// primary key and reads one extra row to tell whether more follow, so deep pages
+// cost no more than the first.
+func (s *EventStore) LoadPage(
+	ctx context.Context,
+	streamID string,
+	afterVersion int64,
+	limit int,
+	desc bool,
+) ([]ges.StoredEvent, bool, error) {
+	if err := s.validateStreamID(streamID); err != nil {
+		return nil, false, err
+	}
+	if limit <= 0 {
+		return nil, false, nil
+	}
+	table, err := s.eventsTable(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+
+	args := []any{streamID, limit + 1}
+	seek, order := ` AND version > $3`, `ASC`
+	if desc {
+		seek, order = ` AND version < $3`, `DESC`
+	}
+	if desc && afterVersion == 0 {
+		seek = "" // from the latest event
+	} else {
+		args = append(args, afterVersion)
+	}
+	rows, err := s.reader(ctx).Query(
+		ctx,
+		`
+		SELECT `+eventColumns+`
+		FROM `+table+`
+		WHERE stream_id = $1`+seek+s.eventRows()+`
+		ORDER BY version `+order+`
+		LIMIT $2
+		`,
+		args...,
+	)
+	if err != nil {
+		return nil, false, &StoreError{Op: "query events", StreamID: streamID, Err: err}
+	}
+	defer rows.Close()
+
+	var out []ges.StoredEvent
+	for rows.Next() {
+		ev, err := s.scanEvent(rows)
+		if err != nil {
+			return nil, false, err
+		}
+		out = append(out, ev)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, false, &StoreError{Op: "read events", StreamID: streamID, Err: err}
+	}
+	if len(out) > limit {
+		return out[:limit], true, nil
+	}
+	return out, false, nil
+}
+
+// LoadMany returns the events after each stream's from-version, keyed by stream ID,
+// with a single query for all streams.
+func (s *EventStore) LoadMany(
+	ctx context.Context,
+	fromVersions map[string]int64,
+) (map[string][]ges.StoredEvent, error) {
+	out := make(map[string][]ges.StoredEvent, len(fromVersions))
+	if len(fromVersions) == 0 {
+		return out, nil
+	}
+	for streamID := range fromVersions {
+		if err := s.validateStreamID(streamID); err != nil {
+			return nil, err
+		}
+	}
+
+	table, err := s.eventsTable(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	streamIDs := make([]string, 0, len(fromVersions))
+	versions := make([]int64, 0, len(fromVersions))
+	for streamID, fromVersion := range fromVersions {
+		streamIDs = append(streamIDs, streamID)
+		versions = append(versions, fromVersion)
+		out[streamID] = []ges.StoredEvent{}
+	}
+
+	// $2[i] is the from-version of $1[i]; array_position pairs each row with its stream.
+	rows, err := s.reader(ctx).Query(
+		ctx,
+		`
+		SELECT `+eventColumns+`
+		FROM `+table+`
+		WHERE stream_id = ANY($1::text[])
+		  AND version > ($2::bigint[])[array_position($1::text[], stream_id)]`+s.eventRows()+`
+		ORDER BY stream_id, version ASC
+		`,
+		streamIDs,
+		versions,
+	)
+	if err != nil {
+		return nil, &StoreError{Op: "query events", Err: err}
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		ev, err := s.scanEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+		out[ev.StreamID] = append(out[ev.StreamID], ev)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, &StoreError{Op: "read events", Err: err}
+	}
+	return out, nil
+}
+
+// ReadAll returns up to limit events across all streams whose global position is
+// strictly greater than fromPosition, ordered by position ascending.
+//
+// Positions come from a sequence, so concurrent transactions may commit out of
+// position order; a reader polling the head can briefly observe a gap.
+func (s *EventStore) ReadAll(
+	ctx context.Context,
+	fromPosition int64,
+	limit int,
+	opts ...ges.ReadOption,
+) ([]ges.StoredEvent, error) {
+	table, err := s.eventsTable(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := ges.NewReadFilter(opts...)
+	where := `position > $1` + s.eventRows()
+	args := []any{fromPosition, limit}
+	if len(filter.Types) > 0 {
+		args = append(args, filter.Types)
+		where += fmt.Sprintf(` AND event_type = ANY($%d)`, len(args))
+	}
+	if filter.Category != "" {
+		args = append(args, likePrefix(filter.Category+":"))
+		where += fmt.Sprintf(` AND stream_id LIKE $%d`, len(args))
+	}
+	if filter.StreamID != "" {
+		args = append(args, filter.StreamID)
+		where += fmt.Sprintf(` AND stream_id = $%d`, len(args))
+	}
+	for _, key := range slices.Sorted(maps.Keys(filter.Metadata)) {
+		args = append(args, filter.Metadata[key])
+		if slices.Contains(s.indexedMeta, key) {
+			where += fmt.Sprintf(` AND md_%s = $%d`, key, len(args))
+			continue
+		}
+		args = append(args, key)
+		where += fmt.Sprintf(` AND metadata ->> $%d = $%d`, len(args), len(args)-1)
+	}
+
+	rows, err := s.reader(ctx).Query(
+		ctx,
+		`
+		SELECT `+eventColumns+`
+		FROM `+table+`
+		WHERE `+where+`
+		ORDER BY position ASC
+		LIMIT $2
+		`,
+		args...,
+	)
+	if err != nil {
+		return nil, &StoreError{Op: "query events", Err: err}
+	}
+	defer rows.Close()
+
+	var out []ges.StoredEvent
+	for rows.Next() {
+		ev, err := s.scanEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, ev)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, &StoreError{Op: "read events", Err: err}
+	}
+	return out, nil
+}
+
+// HeadPosition returns the highest position in the events table, or 0 if it is empty.
+func (s *EventStore) HeadPosition(ctx context.Context) (int64, error) {
+	table, err := s.eventsTable(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	query := `SELECT COALESCE(MAX(position), 0) FROM ` + table
+	if s.singleTable {
+		query += ` WHERE record_type = 'event'`
+	}
+	var position int64
+	if err := s.reader(ctx).QueryRow(ctx, query).Scan(&position); err != nil {
+		return 0, &StoreError{Op: "get head position", Err: err}
+	}
+	return position, nil
+}
+
+// Stats counts the events, streams and snapshots of the store. The counts scan the
+// events and snapshots tables.
+func (s *EventStore) Stats(ctx context.Context) (ges.StoreStats, error) {
+	table, err := s.eventsTable(ctx)
+	if err != nil {
+		return ges.StoreStats{}, err
+	}
+	statements, err := s.snapshotStatements(ctx)
+	if err != nil {
+		return ges.StoreStats{}, err
+	}
+
+	query := `SELECT split_part(stream_id, ':', 1), count(*), count(DISTINCT stream_id) FROM ` + table
+	if s.singleTable {
+		query += ` WHERE record_type = 'event'`
+	}
+	rows, err := s.reader(ctx).Query(ctx, query+` GROUP BY 1`)
+	if err != nil {
+		return ges.StoreStats{}, &StoreError{Op: "count events", Err: err}
+	}
+	defer rows.Close()
+
+	stats := ges.StoreStats{Categories: map[string]int64{}}
+	for rows.Next() {
+		var category string
+		var events, streams int64
+		if err := rows.Scan(&category, &events, &streams); err != nil {
+			return ges.StoreStats{}, &StoreError{Op: "scan event counts", Err: err}
+		}
+		stats.Events += events
+		stats.Streams += streams
+		stats.Categories[category] = events
+	}
+	if err := rows.Err(); err != nil {
+		return ges.StoreStats{}, &StoreError{Op: "count events", Err: err}
+	}
+
+	if err := s.snapshotReader(ctx).QueryRow(ctx, statements.count).Scan(&stats.Snapshots); err != nil {
+		return ges.StoreStats{}, &StoreError{Op: "count snapshots", Err: err}
+	}
+	return stats, nil
+}
+
+// LoadCheckpoint returns the last position saved for name, or 0 if none was saved.
+func (s *EventStore) LoadCheckpoint(ctx context.Context, name string) (int64, error) {
+	var position int64
+	err := s.pool.QueryRow(
+		ctx,
+		`SELECT position FROM checkpoints WHERE name = $1`,
+		name,
+	).Scan(&position)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, nil
+		}
+		return 0, &StoreError{Op: "load checkpoint", Err: err}
+	}
+	return position, nil
+}
+
+// SaveCheckpoint records position as the last processed position for name.
+func (s *EventStore) SaveCheckpoint(ctx context.Context, name string, position int64) error {
+	if _, err := s.pool.Exec(
+		ctx,
+		`
+		INSERT INTO checkpoints (name, position)
+		VALUES ($1, $2)
+		ON CONFLICT (name) DO UPDATE
+		SET position   = EXCLUDED.position,
+		    updated_at = now()
+		`,
+		name,
+		position,
+	); err != nil {
+		return &StoreError{Op: "save checkpoint", Err: err}
+	}
+	return nil
+}
+
+// SetStreamMetadata replaces the metadata of streamID with md in the stream_headers table.
+func (s *EventStore) SetStreamMetadata(ctx context.Context, streamID string, md ges.Metadata) error {
+	if md == nil {
+		md = ges.Metadata{} // encode as {} rather than null
+	}
+	meta, err := json.Marshal(md)
+	if err != nil {
+		return &StoreError{Op: "encode stream metadata", StreamID: streamID, Err: err}
+	}
+	if _, err := s.pool.Exec(
+		ctx,
+		`
+		INSERT INTO stream_headers (stream_id, metadata)
+		VALUES ($1, $2)
+		ON CONFLICT (stream_id) DO UPDATE
+		SET metadata   = EXCLUDED.metadata,
+		    updated_at = now()
+		`,
+		streamID,
+		meta,
+	); err != nil {
+		return &StoreError{Op: "save stream metadata", StreamID: streamID, Err: err}
+	}
+	return nil
+}
+
+// GetStreamMetadata returns the metadata of streamID, or nil if none was set.
+func (s *EventStore) GetStreamMetadata(ctx context.Context, streamID string) (ges.Metadata, error) {
+	var meta []byte
+	err := s.reader(ctx).QueryRow(
+		ctx,
+		`SELECT metadata FROM stream_headers WHERE stream_id = $1`,
+		streamID,
+	).Scan(&meta)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, &StoreError{Op: "load stream metadata", StreamID: streamID, Err: err}
+	}
+
+	md := ges.Metadata{}
+	if err := json.Unmarshal(meta, &md); err != nil {
+		return nil, &StoreError{Op: "decode stream metadata", StreamID: streamID, Err: err}
+	}
+	return md, nil
+}
+
+// SaveDeadLetter records dl, replacing any entry for the same subscription and position.
+func (s *EventStore) SaveDeadLetter(ctx context.Context, dl ges.DeadLetter) error {
+	if _, err := s.pool.Exec(
+		ctx,
+		`
+		INSERT INTO dead_letters (subscription, position, stream_id, version, event_type, error, attempts, at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (subscription, position) DO UPDATE
+		SET error    = EXCLUDED.error,
+		    attempts = EXCLUDED.attempts,
+		    at       = EXCLUDED.at
+		`,
+		dl.Subscription,
+		dl.Position,
+		dl.StreamID,
+		dl.Version,
+		dl.Type,
+		dl.Error,
+		dl.Attempts,
+		dl.At,
+	); err != nil {
+		return &StoreError{Op: "save dead letter", Err: err}
+	}
+	return nil
+}
+
+// ListDeadLetters returns the dead letters of a subscription ordered by position.
+func (s *EventStore) ListDeadLetters(ctx context.Context, subscription string) ([]ges.DeadLetter, error) {
+	rows, err := s.pool.Query(
+		ctx,
+		`
+		SELECT subscription, position, stream_id, version, event_type, error, attempts, at
+		FROM dead_letters
+		WHERE subscription = $1
+		ORDER BY position ASC
+		`,
+		subscription,
+	)
+	if err != nil {
+		return nil, &StoreError{Op: "query dead letters", Err: err}
+	}
+	defer rows.Close()
+
+	var out []ges.DeadLetter
+	for rows.Next() {
+		var dl ges.DeadLetter
+		if err := rows.Scan(
+			&dl.Subscription,
+			&dl.Position,
+			&dl.StreamID,
+			&dl.Version,
+			&dl.Type,
+			&dl.Error,
+			&dl.Attempts,
+			&dl.At,
+		); err != nil {
+			return nil, &StoreError{Op: "scan dead letter", Err: err}
+		}
+		out = append(out, dl)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, &StoreError{Op: "read dead letters", Err: err}
+	}
+	return out, nil
+}
+
+// DeleteDeadLetter removes the entry for subscription and position, if any.
+func (s *EventStore) DeleteDeadLetter(ctx context.Context, subscription string, position int64) error {
+	if _, err := s.pool.Exec(
+		ctx,
+		`DELETE FROM dead_letters WHERE subscription = $1 AND position = $2`,
+		subscription,
+		position,
+	); err != nil {
+		return &StoreError{Op: "delete dead letter", Err: err}
+	}
+	return nil
+}
+
+// querier is what reads need from a pool or a transaction.
+type querier interface {
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// readTxKey is the context key of the transaction started by ReadInTransaction.
+type readTxKey struct{}
+
+// ReadInTransaction runs fn in a read-only repeatable-read transaction: the reads of
+// events, snapshots and stream metadata made with the ctx passed to fn all see the
+// same snapshot of the database, including those from the snapshot pool. Use it to
+// load several aggregates, or an aggregate and a projection, that must be mutually
+// consistent; see also ges.WithReadTransaction. Nested calls join the outer
+// transaction. Writes are unaffected and run outside it.
+//
+// The transaction holds a single connection, so fn must finish iterating the results
+// of LoadIter before reading anything else.
+func (s *EventStore) ReadInTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(readTxKey{}).(pgx.Tx); ok {
+		return fn(ctx)
+	}
+	tx, err := s.pool.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return &StoreError{Op: "begin read transaction", Err: err}
+	}
+	defer func(tx pgx.Tx, ctx context.Context) {
+		_ = tx.Rollback(ctx)
+	}(tx, ctx)
+
+	if err := fn(context.WithValue(ctx, readTxKey{}, tx)); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return &StoreError{Op: "commit read transaction", Err: err}
+	}
+	return nil
+}
+
+// reader returns the transaction of ReadInTransaction for ctx, or else the pool.
+func (s *EventStore) reader(ctx context.Context) querier {
+	if tx, ok := ctx.Value(readTxKey{}).(pgx.Tx); ok {
+		return tx
+	}
+	return s.pool
+}
+
+// eventsTable returns the quoted name of the events table for ctx.
+func (s *EventStore) eventsTable(ctx context.Context) (string, error) {
+	if s.tenantRouter == nil {
+		return "events", nil
+	}
+	suffix, ok := s.tenantRouter(ctx)
+	if !ok {
+		return "events", nil
+	}
+	if !tenantSuffixPattern.MatchString(suffix) {
+		return "", fmt.Errorf("%w: %q", ErrInvalidTenantSuffix, suffix)
+	}
+	return pgx.Identifier{"events_" + suffix}.Sanitize(), nil
+}
+
+// eventRows returns the condition restricting a query on the events table to event
+// rows. It is empty unless snapshots share the table (see WithSingleTable).
+func (s *EventStore) eventRows() string {
+	if !s.singleTable {
+		return ""
+	}
+	return ` AND record_type = 'event'`
+}
+
+// likePrefix returns a LIKE pattern matching strings that start with prefix.
+func likePrefix(prefix string) string {
+	return likeEscaper.Replace(prefix) + "%"
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// eventColumns is the column list understood by scanEvent.
+const eventColumns = `position, COALESCE(event_id::text, ''), stream_id, version, event_type, content_type, payload, metadata, headers, occurred_at, at`
+
+// scanEvent scans and decodes a row selected with eventColumns.
+func (s *EventStore) scanEvent(rows pgx.Rows) (ges.StoredEvent, error) {
+	ev, err := scanRawEvent(rows)
+	if err != nil {
+		return ges.StoredEvent{}, err
+	}
+
+	codec := ges.DecoderFor(s.typeRegistry, s.byContent, ev.Type, ev.ContentType)
+	if codec == nil && s.unknownFallback {
+		ev.Payload = ges.UnknownEvent{Type: ev.Type, Raw: ev.Payload.([]byte)}
+		return ev, nil
+	}
+	if codec == nil {
+		return s.decodeFailed(ev, ErrUnknownEventType)
+	}
+
+	payloadEv, err := codec.Decode(ev.Payload.([]byte))
+	if err != nil {
+		return s.decodeFailed(ev, err)
+	}
+	ev.Payload = payloadEv
+	return ev, nil
+}
+
+// commit runs the before-commit hooks in tx, commits the append to streamID and
+// then runs the after-commit hooks.
+func (s *EventStore) commit(ctx context.Context, tx pgx.Tx, streamID string) error {
+	for _, fn := range s.beforeCommit {
+		if err := fn(ctx, tx); err != nil {
+			return &StoreError{Op: "run before-commit hook", StreamID: streamID, Err: err}
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return &StoreError{Op: "commit transaction", StreamID: streamID, Err: commitError{err}}
+	}
+	for _, fn := range s.afterCommit {
+		fn(ctx)
+	}
+	return nil
+}
+
+// retryTransient runs fn, re-running it on transient errors as configured with
+// WithTransientRetry.
+func (s *EventStore) retryTransient(ctx context.Context, fn func() (int64, error)) (int64, error) {
+	for attempt := 1; ; attempt++ {
+		version, err := fn()
+		if err == nil || attempt >= s.retryAttempts || !isTransient(err) {
+			return version, err
+		}
+
+		timer := time.NewTimer(s.retryBackoff(attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return 0, err
+		case <-timer.C:
+		}
+	}
+}
+
+// checkPayload returns an error wrapping ErrPayloadNotJSON if payload cannot be
+// stored in a JSONB payload column.
+func (s *EventStore) checkPayload(eventType string, payload []byte) error {
+	if s.payloadType != PayloadJSONB {
+		return nil
+	}
+	if !json.Valid(payload) {
+		return fmt.Errorf("%w: %q (use WithPayloadColumnType(PayloadBytea) for binary codecs)", ErrPayloadNotJSON, eventType)
+	}
+	if hasNULEscape(payload) {
+		return fmt.Errorf("%w: %q contains \\u0000, which JSONB cannot store", ErrPayloadNotJSON, eventType)
+	}
+	return nil
+}
+
+// hasNULEscape reports whether the JSON text b contains a \u0000 escape, as opposed
+// to an escaped backslash followed by "u0000".
+func hasNULEscape(b []byte) bool {
+	for i := 0; i < len(b); i++ {
+		if b[i] != '\\' {
+			continue
+		}
+		if bytes.HasPrefix(b[i+1:], []byte("u0000")) {
+			return true
+		}
+		i++ // skip the escaped character
+	}
+	return false
+}
+
+// eventType returns the type name persisted for e.
+func (s *EventStore) eventType(e ges.Event) string {
+	if s.typeNamer != nil {
+		return s.typeNamer(e)
+	}
+	return ges.EventType(e)
+}
+
+// validateStreamID runs the validator set with WithStreamIDValidator, if any.
+func (s *EventStore) validateStreamID(streamID string) error {
+	if s.validateID == nil {
+		return nil
+	}
+	return s.validateID(streamID)
+}
+
+// checkVersionLimit returns an error wrapping ges.ErrVersionLimit if appending n
+// events to a stream at currentVersion would pass the configured maximum.
+func (s *EventStore) checkVersionLimit(streamID string, currentVersion int64, n int) error {
+	limit := s.maxVersion
+	if limit <= 0 {
+		limit = math.MaxInt64
+	}
+	if int64(n) > limit-currentVersion {
+		return fmt.Errorf("%w: %d events cannot follow version %d of %s, limit is %d",
+			ges.ErrVersionLimit, n, currentVersion, streamID, limit)
+	}
+	return nil
+}
+
+// verifyVersion returns a *ges.VersionGapError if WithVerifyContiguous is set and ev
+// is not at the expected version.
+func (s *EventStore) verifyVersion(ev ges.StoredEvent, expected int64) error {
+	if !s.verifyVersions || ev.Version == expected {
+		return nil
+	}
+	return &ges.VersionGapError{StreamID: ev.StreamID, ExpectedVersion: expected, ActualVersion: ev.Version}
+}
+
+// decodeFailed returns ev as skipped or a *ges.DecodeError, as WithOnDecodeError decides.
+func (s *EventStore) decodeFailed(ev ges.StoredEvent, cause error) (ges.StoredEvent, error) {
+	decodeErr := ges.DecodeError{
+		StreamID:  ev.StreamID,
+		Version:   ev.Version,
+		EventType: ev.Type,
+		Cause:     cause,
+	}
+	if s.onDecodeError != nil && s.onDecodeError(decodeErr) == ges.DecodeSkip {
+		ev.Payload = ges.SkippedEvent{Err: decodeErr, Raw: ev.Payload.([]byte)}
+		return ev, nil
+	}
+	return ges.StoredEvent{}, &decodeErr
+}
+
+// scanRawEvent scans a row selected with eventColumns, leaving the payload encoded.
+func scanRawEvent(rows pgx.Rows) (ges.StoredEvent, error) {
+	var ev ges.StoredEvent
+	var payload []byte
+	var meta []byte
+	var headers []byte
+
+	if err := rows.Scan(
+		&ev.Position,
+		&ev.ID,
+		&ev.StreamID,
+		&ev.Version,
+		&ev.Type,
+		&ev.ContentType,
+		&payload,
+		&meta,
+		&headers,
+		&ev.OccurredAt,
+		&ev.RecordedAt,
+	); err != nil {
+		return ges.StoredEvent{}, &StoreError{Op: "scan event", Err: err}
+	}
+	ev.At = ev.RecordedAt
+	ev.Payload = payload
+
+	if err := json.Unmarshal(meta, &ev.Metadata); err != nil {
+		return ges.StoredEvent{}, &StoreError{Op: "decode metadata", Err: err}
+	}
+	if err := json.Unmarshal(headers, &ev.Headers); err != nil {
+		return ges.StoredEvent{}, &StoreError{Op: "decode headers", Err: err}
+	}
+	if len(ev.Headers) == 0 {
+		ev.Headers = nil
+	}
+	return ev, nil
+}
+
+// TruncateBefore deletes the events of streamID with a version lower than version.
+// It fails with ges.ErrTruncateUnsafe unless the latest snapshot of the stream is at
+// or after version. Positions of the deleted events are not reused.
+func (s *EventStore) TruncateBefore(ctx context.Context, streamID string, version int64) error {
+	if version <= 1 {
+		return nil // nothing precedes version 1
+	}
+	table, err := s.eventsTable(ctx)
+	if err != nil {
+		return err
+	}
+	snapshots, err := s.snapshotStatements(ctx)
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return &StoreError{Op: "begin transaction", StreamID: streamID, Err: err}
+	}
+	defer func(tx pgx.Tx, ctx context.Context) {
+		_ = tx.Rollback(ctx)
+	}(tx, ctx)
+
+	// Lock the snapshot row so it cannot move backwards while events are deleted.
+	var snapshotVersion, currentVersion int64
+	if err := tx.QueryRow(
+		ctx,
+		`
+		SELECT COALESCE((`+snapshots.lockVersion+`), 0),
+		       COALESCE((SELECT MAX(version) FROM `+table+` WHERE stream_id = $1`+s.eventRows()+`), 0)
+		`,
+		streamID,
+	).Scan(&snapshotVersion, &currentVersion); err != nil {
+		return &StoreError{Op: "get snapshot version", StreamID: streamID, Err: err}
+	}
+	if version > snapshotVersion || version > currentVersion {
+		return fmt.Errorf("%w: %s before version %d", ges.ErrTruncateUnsafe, streamID, version)
+	}
+
+	if _, err := tx.Exec(
+		ctx,
+		`DELETE FROM `+table+` WHERE stream_id = $1 AND version < $2`+s.eventRows(),
+		streamID,
+		version,
+	); err != nil {
+		return &StoreError{Op: "delete events", StreamID: streamID, Err: err}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return &StoreError{Op: "commit transaction", StreamID: streamID, Err: err}
+	}
+	return nil
+}
+
+// SaveSnapshot upserts the snapshot state for a stream at a given version.
+// Snapshots are an optimization for fast rehydration and are safe to treat
+// as a cache—failure to save should not compromise domain consistency.
+// A snapshot older than the latest one of the stream, e.g. from a slower
+// concurrent writer, does not replace it (but is kept with WithSnapshotHistory).
+func (s *EventStore) SaveSnapshot(
 	ctx context.Context,
 	streamID string,
-) (ges.Snapshot, error) {
-	row := s.pool.QueryRow(
+	version int64,
+	state any,
+) error {
+	return s.SaveSnapshotWithMeta(ctx, streamID, version, state, nil)
+}
+
+// SaveSnapshotWithMeta is like SaveSnapshot but also records md with the snapshot.
+// Context-derived metadata is merged in the same way as for Append.
+func (s *EventStore) SaveSnapshotWithMeta(
+	ctx context.Context,
+	streamID string,
+	version int64,
+	state any,
+	md ges.Metadata,
+) error {
+	if s.extractor != nil {
+		extracted := s.extractor(ctx)
+		md = extracted.Merge(md)
+	}
+
+	data, err := encodeSnapshotState(state, s.compress)
+	if err != nil {
+		return &StoreError{Op: "encode snapshot", StreamID: streamID, Err: err}
+	}
+	if s.maxSnapshotSize > 0 && len(data) > s.maxSnapshotSize {
+		return fmt.Errorf("%w: %d bytes for %s, limit %d", ErrSnapshotTooLarge, len(data), streamID, s.maxSnapshotSize)
+	}
+	meta, err := json.Marshal(md.Merge()) // nil encodes as {} to satisfy NOT NULL
+	if err != nil {
+		return &StoreError{Op: "encode metadata", StreamID: streamID, Err: err}
+	}
+	snapshots, err := s.snapshotStatements(ctx)
+	if err != nil {
+		return err
+	}
+	at := s.now()
+	if !s.snapshotHistory {
+		if _, err := s.snapshotPool().Exec(ctx, snapshots.upsertLatest, streamID, version, data, meta, at); err != nil {
+			return &StoreError{Op: "save snapshot", StreamID: streamID, Err: err}
+		}
+		return nil
+	}
+
+	tx, err := s.snapshotPool().Begin(ctx)
+	if err != nil {
+		return &StoreError{Op: "begin transaction", StreamID: streamID, Err: err}
+	}
+	defer func(tx pgx.Tx, ctx context.Context) {
+		_ = tx.Rollback(ctx)
+	}(tx, ctx)
+
+	if _, err := tx.Exec(ctx, snapshots.upsertLatest, streamID, version, data, meta, at); err != nil {
+		return &StoreError{Op: "save snapshot", StreamID: streamID, Err: err}
+	}
+	if _, err := tx.Exec(
 		ctx,
-		`SELECT version, state, at FROM snapshots WHERE stream_id = $1`,
+		snapshots.upsertHistory,
 		streamID,
-	)
+		version,
+		data,
+		meta,
+		at,
+	); err != nil {
+		return &StoreError{Op: "save snapshot history", StreamID: streamID, Err: err}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return &StoreError{Op: "commit transaction", StreamID: streamID, Err: err}
+	}
+	return nil
+}
 
+// LoadSnapshot retrieves the latest snapshot for a stream. If not found, Found=false.
+// The State is returned as a generic structure (typically map[string]any) since the
+// library does not enforce a concrete aggregate type; applications can re-decode it,
+// e.g. with ges.DecodeState. Numbers in State are json.Number values.
+func (s *EventStore) LoadSnapshot(
+	ctx context.Context,
+	streamID string,
+) (ges.Snapshot, error) {
+	snapshots, err := s.snapshotStatements(ctx)
+	if err != nil {
+		return ges.Snapshot{}, err
+	}
+	return scanSnapshot(s.snapshotReader(ctx).QueryRow(ctx, snapshots.loadLatest, streamID))
+}
+
+// LoadSnapshotBefore returns the newest snapshot of a stream with a version of at
+// most maxVersion. If there is none, Found=false.
+//
+// Older snapshots are only kept with WithSnapshotHistory; without it, only the
+// latest snapshot is considered.
+func (s *EventStore) LoadSnapshotBefore(
+	ctx context.Context,
+	streamID string,
+	maxVersion int64,
+) (ges.Snapshot, error) {
+	if !s.snapshotHistory {
+		snap, err := s.LoadSnapshot(ctx, streamID)
+		if err != nil || !snap.Found || snap.Version <= maxVersion {
+			return snap, err
+		}
+		return ges.Snapshot{Found: false}, nil
+	}
+
+	snapshots, err := s.snapshotStatements(ctx)
+	if err != nil {
+		return ges.Snapshot{}, err
+	}
+	return scanSnapshot(s.snapshotReader(ctx).QueryRow(ctx, snapshots.loadBefore, streamID, maxVersion))
+}
+
+// snapshotPool returns the pool snapshots are read from and written to.
+func (s *EventStore) snapshotPool() *pgxpool.Pool {
+	if s.snapPool != nil {
+		return s.snapPool
+	}
+	return s.pool
+}
+
+// snapshotReader is like reader, for snapshot reads outside a read transaction.
+func (s *EventStore) snapshotReader(ctx context.Context) querier {
+	if tx, ok := ctx.Value(readTxKey{}).(pgx.Tx); ok {
+		return tx
+	}
+	return s.snapshotPool()
+}
+
+// scanSnapshot scans a row of (version, state, metadata, at). No row means Found=false.
+func scanSnapshot(row pgx.Row) (ges.Snapshot, error) {
 	var version int64
 	var raw []byte
+	var rawMeta []byte
 	var at time.Time
 
-	if err := row.Scan(&version, &raw, &at); err != nil {
+	if err := row.Scan(&version, &raw, &rawMeta, &at); err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return ges.Snapshot{Found: false}, nil
 		}
-		return ges.Snapshot{}, fmt.Errorf("ges-pgx: could not scan snapshot: %w", err)
+		return ges.Snapshot{}, &StoreError{Op: "scan snapshot", Err: err}
 	}
 
 	// Decode into a generic map by default; callers may re-decode to a concrete type.
+	// Numbers are kept as json.Number so integers beyond 2^53 survive DecodeState.
+	raw, err := decodeSnapshotState(raw)
+	if err != nil {
+		return ges.Snapshot{}, &StoreError{Op: "unmarshal snapshot", Err: err}
+	}
 	var state map[string]any
-	if err := json.Unmarshal(raw, &state); err != nil {
-		return ges.Snapshot{}, fmt.Errorf("ges-pgx: could not unmarshal snapshot: %w", err)
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	if err := dec.Decode(&state); err != nil {
+		return ges.Snapshot{}, &StoreError{Op: "unmarshal snapshot", Err: err}
+	}
+
+	var md ges.Metadata
+	if err := json.Unmarshal(rawMeta, &md); err != nil {
+		return ges.Snapshot{}, &StoreError{Op: "unmarshal snapshot metadata", Err: err}
+	}
+	if len(md) == 0 {
+		md = nil
 	}
 
 	return ges.Snapshot{
-		State:   state,
-		Version: version,
-		Found:   true,
-		At:      at,
+		State:    state,
+		Version:  version,
+		Found:    true,
+		At:       at,
+		Metadata: md,
 	}, nil
 }
 
-var _ ges.EventStore = (*EventStore)(nil)
+var (
+	_ ges.EventStore            = (*EventStore)(nil)
+	_ ges.SnapshotMetadataSaver = (*EventStore)(nil)
+	_ ges.VersionChecker        = (*EventStore)(nil)
+	_ ges.EnvelopeAppender      = (*EventStore)(nil)
+	_ ges.StreamIterator        = (*EventStore)(nil)
+	_ ges.GlobalReader          = (*EventStore)(nil)
+	_ ges.Checkpointer          = (*EventStore)(nil)
+	_ ges.DeadLetterStore       = (*EventStore)(nil)
+	_ ges.RawLoader             = (*EventStore)(nil)
+	_ ges.RawRangeLoader        = (*EventStore)(nil)
+	_ ges.RawAppender           = (*EventStore)(nil)
+	_ ges.StreamMetadataStore   = (*EventStore)(nil)
+	_ ges.HeadReader            = (*EventStore)(nil)
+	_ ges.MultiLoader           = (*EventStore)(nil)
+	_ ges.RangeLoader           = (*EventStore)(nil)
+	_ ges.SnapshotHistoryLoader = (*EventStore)(nil)
+	_ ges.Truncater             = (*EventStore)(nil)
+	_ ges.AutoAppender          = (*EventStore)(nil)
+	_ ges.LastLoader            = (*EventStore)(nil)
+	_ ges.PageLoader            = (*EventStore)(nil)
+	_ ges.ExistenceChecker      = (*EventStore)(nil)
+	_ ges.PositionAppender      = (*EventStore)(nil)
+	_ ges.StatsReporter         = (*EventStore)(nil)
+	_ ges.ReadTransactor        = (*EventStore)(nil)
+)
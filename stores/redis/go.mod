@@ -0,0 +1,7 @@
+module github.com/mickamy/go-event-sourcing/stores/redis
+
+go 1.24.0
+
+replace github.com/mickamy/go-event-sourcing => ../..
+
+require github.com/mickamy/go-event-sourcing v0.0.0
@@ -0,0 +1,172 @@
+// Package redis keeps snapshots in Redis, next to events kept in another store:
+//
+//	snapshots := redis.NewSnapshotStore(client, redis.WithTTL(24*time.Hour))
+//	repo := ges.NewRepository(store, newAccount, ges.WithSnapshotStore(snapshots))
+//
+// Snapshots are a cache, so Redis may evict them at any time; the aggregate is then
+// rebuilt from its events. Each snapshot is a hash holding its version and the
+// JSON-encoded snapshot, written by a script that never replaces a newer snapshot.
+package redis
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/mickamy/go-event-sourcing"
+)
+
+// Client runs Lua scripts on Redis, which is all SnapshotStore needs. It returns a
+// nil reply as nil and no error. With go-redis, it is a few lines:
+//
+//	type client struct{ *redis.Client }
+//
+//	func (c client) Eval(ctx context.Context, script string, keys []string, args ...any) (any, error) {
+//		v, err := c.Client.Eval(ctx, script, keys, args...).Result()
+//		if errors.Is(err, redis.Nil) {
+//			return nil, nil
+//		}
+//		return v, err
+//	}
+type Client interface {
+	Eval(ctx context.Context, script string, keys []string, args ...any) (any, error)
+}
+
+// saveScript stores the snapshot ARGV[2] at version ARGV[1] unless a newer one is
+// stored already, and sets the TTL ARGV[3] in milliseconds, or none if it is 0.
+const saveScript = `
+local current = tonumber(redis.call('HGET', KEYS[1], 'version'))
+if current and current > tonumber(ARGV[1]) then
+	return 0
+end
+redis.call('HSET', KEYS[1], 'version', ARGV[1], 'snapshot', ARGV[2])
+if tonumber(ARGV[3]) > 0 then
+	redis.call('PEXPIRE', KEYS[1], ARGV[3])
+else
+	redis.call('PERSIST', KEYS[1])
+end
+return 1
+`
+
+// loadScript returns the snapshot stored under KEYS[1], or nil.
+const loadScript = `return redis.call('HGET', KEYS[1], 'snapshot')`
+
+// record is the stored form of a snapshot.
+type record struct {
+	Version  int64           `json:"version"`
+	State    json.RawMessage `json:"state"`
+	Metadata ges.Metadata    `json:"metadata,omitempty"`
+	At       time.Time       `json:"at"`
+}
+
+// SnapshotStore is a ges.SnapshotStore backed by Redis.
+type SnapshotStore struct {
+	client Client
+	prefix string
+	ttl    time.Duration
+}
+
+// Option configures a SnapshotStore.
+type Option func(*SnapshotStore)
+
+// WithKeyPrefix sets the prefix of snapshot keys. The default is "ges:snapshot:",
+// which stores the snapshot of stream "Account:42" under "ges:snapshot:Account:42".
+func WithKeyPrefix(prefix string) Option {
+	return func(s *SnapshotStore) { s.prefix = prefix }
+}
+
+// WithTTL makes snapshots expire ttl after they were last saved. By default they
+// are kept until Redis evicts them.
+func WithTTL(ttl time.Duration) Option {
+	return func(s *SnapshotStore) { s.ttl = ttl }
+}
+
+// NewSnapshotStore creates a SnapshotStore that talks to Redis through client.
+func NewSnapshotStore(client Client, opts ...Option) *SnapshotStore {
+	s := &SnapshotStore{client: client, prefix: "ges:snapshot:"}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// SaveSnapshot stores state, encoded as JSON, as the snapshot of streamID at version.
+// A snapshot older than the stored one is dropped, so the snapshot never regresses.
+func (s *SnapshotStore) SaveSnapshot(ctx context.Context, streamID string, version int64, state any) error {
+	return s.SaveSnapshotWithMeta(ctx, streamID, version, state, nil)
+}
+
+// SaveSnapshotWithMeta is like SaveSnapshot but also stores md, which LoadSnapshot
+// returns with the snapshot.
+func (s *SnapshotStore) SaveSnapshotWithMeta(
+	ctx context.Context,
+	streamID string,
+	version int64,
+	state any,
+	md ges.Metadata,
+) error {
+	encoded, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("ges-redis: encode snapshot of %s: %w", streamID, err)
+	}
+	payload, err := json.Marshal(record{
+		Version:  version,
+		State:    encoded,
+		Metadata: md,
+		At:       time.Now().UTC(),
+	})
+	if err != nil {
+		return fmt.Errorf("ges-redis: encode snapshot of %s: %w", streamID, err)
+	}
+	if _, err := s.client.Eval(ctx, saveScript, []string{s.prefix + streamID}, version, string(payload), s.ttl.Milliseconds()); err != nil {
+		return fmt.Errorf("ges-redis: save snapshot of %s: %w", streamID, err)
+	}
+	return nil
+}
+
+// LoadSnapshot returns the snapshot of streamID, with Found=false if there is none
+// or it expired. The state comes back in its JSON form (see ges.DecodeState).
+func (s *SnapshotStore) LoadSnapshot(ctx context.Context, streamID string) (ges.Snapshot, error) {
+	reply, err := s.client.Eval(ctx, loadScript, []string{s.prefix + streamID})
+	if err != nil {
+		return ges.Snapshot{}, fmt.Errorf("ges-redis: load snapshot of %s: %w", streamID, err)
+	}
+
+	var payload []byte
+	switch v := reply.(type) {
+	case nil:
+		return ges.Snapshot{}, nil
+	case string:
+		payload = []byte(v)
+	case []byte:
+		payload = v
+	default:
+		return ges.Snapshot{}, fmt.Errorf("ges-redis: load snapshot of %s: unexpected reply %T", streamID, reply)
+	}
+
+	var rec record
+	if err := json.Unmarshal(payload, &rec); err != nil {
+		return ges.Snapshot{}, fmt.Errorf("ges-redis: decode snapshot of %s: %w", streamID, err)
+	}
+	// Numbers are kept as json.Number so integers beyond 2^53 survive DecodeState.
+	var state any
+	dec := json.NewDecoder(bytes.NewReader(rec.State))
+	dec.UseNumber()
+	if err := dec.Decode(&state); err != nil {
+		return ges.Snapshot{}, fmt.Errorf("ges-redis: decode snapshot of %s: %w", streamID, err)
+	}
+	return ges.Snapshot{
+		State:    state,
+		Version:  rec.Version,
+		Found:    true,
+		At:       rec.At,
+		Metadata: rec.Metadata,
+	}, nil
+}
+
+var (
+	_ ges.SnapshotStore         = (*SnapshotStore)(nil)
+	_ ges.SnapshotMetadataSaver = (*SnapshotStore)(nil)
+)
@@ -0,0 +1,217 @@
+package redis_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mickamy/go-event-sourcing"
+	"github.com/mickamy/go-event-sourcing/internal/storetest"
+	"github.com/mickamy/go-event-sourcing/stores/redis"
+)
+
+// fakeRedis runs the two scripts of SnapshotStore, told apart by their arguments,
+// against an in-memory map of hashes.
+type fakeRedis struct {
+	mu     sync.Mutex
+	hashes map[string]map[string]any
+	ttls   map[string]int64
+}
+
+func newFakeRedis() *fakeRedis {
+	return &fakeRedis{hashes: map[string]map[string]any{}, ttls: map[string]int64{}}
+}
+
+func (f *fakeRedis) Eval(_ context.Context, _ string, keys []string, args ...any) (any, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	key := keys[0]
+	if len(args) == 0 {
+		if h, ok := f.hashes[key]; ok {
+			return h["snapshot"], nil
+		}
+		return nil, nil
+	}
+	version := args[0].(int64)
+	if h, ok := f.hashes[key]; ok && h["version"].(int64) > version {
+		return int64(0), nil
+	}
+	f.hashes[key] = map[string]any{"version": version, "snapshot": args[1]}
+	f.ttls[key] = args[2].(int64)
+	return int64(1), nil
+}
+
+func TestSnapshotStore(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	client := newFakeRedis()
+	s := redis.NewSnapshotStore(client, redis.WithKeyPrefix("test:"), redis.WithTTL(time.Minute))
+
+	if snap, err := s.LoadSnapshot(ctx, "Account:1"); err != nil || snap.Found {
+		t.Fatalf("expected no snapshot, got %+v, %v", snap, err)
+	}
+
+	type state struct{ Balance int }
+	if err := s.SaveSnapshotWithMeta(ctx, "Account:1", 5, state{Balance: 50}, ges.Metadata{"trace_id": "t-1"}); err != nil {
+		t.Fatalf("save snapshot failed: %v", err)
+	}
+	if client.ttls["test:Account:1"] != time.Minute.Milliseconds() {
+		t.Fatalf("expected a TTL of one minute, got %dms", client.ttls["test:Account:1"])
+	}
+
+	// An older snapshot does not replace a newer one.
+	if err := s.SaveSnapshot(ctx, "Account:1", 3, state{Balance: 30}); err != nil {
+		t.Fatalf("save snapshot failed: %v", err)
+	}
+
+	snap, err := s.LoadSnapshot(ctx, "Account:1")
+	if err != nil {
+		t.Fatalf("load snapshot failed: %v", err)
+	}
+	got, err := ges.DecodeState[state](snap.State)
+	if err != nil {
+		t.Fatalf("decode state failed: %v", err)
+	}
+	if !snap.Found || snap.Version != 5 || got.Balance != 50 || snap.Metadata["trace_id"] != "t-1" || snap.At.IsZero() {
+		t.Fatalf("expected the snapshot at version 5, got %+v", snap)
+	}
+}
+
+func TestSnapshotStore_Compliance(t *testing.T) {
+	t.Parallel()
+
+	storetest.RunSnapshots(t, func(t *testing.T) ges.SnapshotStore {
+		t.Helper()
+		return redis.NewSnapshotStore(newFakeRedis())
+	})
+}
+
+// respClient is a minimal Redis client speaking RESP over one connection, enough
+// to run the scripts of SnapshotStore against a real server.
+type respClient struct {
+	mu   sync.Mutex
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+// dialRedis connects to the Redis at REDIS_ADDR, skipping the test if it is unset.
+func dialRedis(t *testing.T) *respClient {
+	t.Helper()
+
+	addr := os.Getenv("REDIS_ADDR")
+	if addr == "" {
+		t.Skip("REDIS_ADDR is not set")
+	}
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("failed to connect to redis: %v", err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	return &respClient{conn: conn, r: bufio.NewReader(conn)}
+}
+
+func (c *respClient) Eval(ctx context.Context, script string, keys []string, args ...any) (any, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	deadline, _ := ctx.Deadline()
+	if err := c.conn.SetDeadline(deadline); err != nil {
+		return nil, err
+	}
+	cmd := append([]string{"EVAL", script, strconv.Itoa(len(keys))}, keys...)
+	for _, arg := range args {
+		cmd = append(cmd, fmt.Sprint(arg))
+	}
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "*%d\r\n", len(cmd))
+	for _, s := range cmd {
+		fmt.Fprintf(&buf, "$%d\r\n%s\r\n", len(s), s)
+	}
+	if _, err := c.conn.Write(buf.Bytes()); err != nil {
+		return nil, err
+	}
+	return c.read()
+}
+
+// read reads one reply, returning a nil bulk string or array as nil.
+func (c *respClient) read() (any, error) {
+	line, err := c.r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	if line == "" {
+		return nil, errors.New("empty reply")
+	}
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, errors.New(line[1:])
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		b := make([]byte, n+2)
+		if _, err := io.ReadFull(c.r, b); err != nil {
+			return nil, err
+		}
+		return string(b[:n]), nil
+	case '*':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		items := make([]any, n)
+		for i := range items {
+			if items[i], err = c.read(); err != nil {
+				return nil, err
+			}
+		}
+		return items, nil
+	}
+	return nil, fmt.Errorf("unexpected reply %q", line)
+}
+
+func TestSnapshotStore_Redis(t *testing.T) {
+	t.Parallel()
+
+	client := dialRedis(t)
+	// Keys are unique to this run and expire, so reruns start clean.
+	prefix := fmt.Sprintf("ges-test:%d:", time.Now().UnixNano())
+	storetest.RunSnapshots(t, func(t *testing.T) ges.SnapshotStore {
+		t.Helper()
+		return redis.NewSnapshotStore(client, redis.WithKeyPrefix(prefix+t.Name()+":"), redis.WithTTL(time.Minute))
+	})
+
+	t.Run("ttl", func(t *testing.T) {
+		ctx := t.Context()
+		key := prefix + "ttl:"
+		s := redis.NewSnapshotStore(client, redis.WithKeyPrefix(key), redis.WithTTL(time.Minute))
+		if err := s.SaveSnapshot(ctx, "Account:1", 1, map[string]any{"balance": 1}); err != nil {
+			t.Fatalf("save snapshot failed: %v", err)
+		}
+		ttl, err := client.Eval(ctx, "return redis.call('PTTL', KEYS[1])", []string{key + "Account:1"})
+		if err != nil {
+			t.Fatalf("pttl failed: %v", err)
+		}
+		if ms, _ := ttl.(int64); ms <= 0 || ms > time.Minute.Milliseconds() {
+			t.Fatalf("expected a TTL of at most one minute, got %v", ttl)
+		}
+	})
+}
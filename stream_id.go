@@ -0,0 +1,75 @@
+package ges
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ErrInvalidStreamID is returned when a stream ID does not follow the
+// "<Category>:<ID>" convention.
+var ErrInvalidStreamID = fmt.Errorf("ges: invalid stream id")
+
+// StreamID is a stream identifier of the form "<Category>:<ID>", e.g. "Account:42".
+// The category is everything before the first ':' and the ID everything after it,
+// so IDs may themselves contain colons. Category reads (WithCategory) and anything
+// grouping streams by kind rely on this convention.
+//
+// Stores take plain strings; convert with string(id) or id.String().
+type StreamID string
+
+// NewStreamID builds a StreamID from its parts. category must be non-empty and
+// must not contain ':'; id must be non-empty.
+func NewStreamID(category, id string) (StreamID, error) {
+	if category == "" || strings.Contains(category, ":") {
+		return "", fmt.Errorf("%w: category %q", ErrInvalidStreamID, category)
+	}
+	if id == "" {
+		return "", fmt.Errorf("%w: empty id in category %s", ErrInvalidStreamID, category)
+	}
+	return StreamID(category + ":" + id), nil
+}
+
+// MustStreamID is like NewStreamID but panics on invalid input. Use it with
+// trusted parts, e.g. a constant category and a generated ID.
+func MustStreamID(category, id string) StreamID {
+	s, err := NewStreamID(category, id)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// ParseStreamID validates that s follows the "<Category>:<ID>" convention.
+func ParseStreamID(s string) (StreamID, error) {
+	category, id, ok := strings.Cut(s, ":")
+	if !ok {
+		return "", fmt.Errorf("%w: %q has no category", ErrInvalidStreamID, s)
+	}
+	return NewStreamID(category, id)
+}
+
+// ValidateStreamID reports whether s follows the "<Category>:<ID>" convention, with
+// an error wrapping ErrInvalidStreamID if not. It suits the stores'
+// WithStreamIDValidator option, catching IDs such as "" or "Account:" built from an
+// empty ID before they accumulate events.
+func ValidateStreamID(s string) error {
+	_, err := ParseStreamID(s)
+	return err
+}
+
+// Category returns the part before the first ':', or the whole ID if it has none.
+func (s StreamID) Category() string {
+	category, _, _ := strings.Cut(string(s), ":")
+	return category
+}
+
+// ID returns the part after the first ':', or "" if there is none.
+func (s StreamID) ID() string {
+	_, id, _ := strings.Cut(string(s), ":")
+	return id
+}
+
+// String returns s as a plain string.
+func (s StreamID) String() string {
+	return string(s)
+}
@@ -0,0 +1,35 @@
+package ges_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/mickamy/go-event-sourcing"
+)
+
+func TestStreamID(t *testing.T) {
+	t.Parallel()
+
+	s, err := ges.ParseStreamID("Order:eu:42")
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	if s.Category() != "Order" || s.ID() != "eu:42" {
+		t.Fatalf("expected Order / eu:42, got %s / %s", s.Category(), s.ID())
+	}
+	if built := ges.MustStreamID("Order", "eu:42"); built != s {
+		t.Fatalf("expected %s, got %s", s, built)
+	}
+
+	for _, bad := range []string{"no-category", ":42", "Order:"} {
+		if _, err := ges.ParseStreamID(bad); !errors.Is(err, ges.ErrInvalidStreamID) {
+			t.Fatalf("%q: expected ErrInvalidStreamID, got %v", bad, err)
+		}
+		if err := ges.ValidateStreamID(bad); !errors.Is(err, ges.ErrInvalidStreamID) {
+			t.Fatalf("%q: expected ValidateStreamID to reject it, got %v", bad, err)
+		}
+	}
+	if _, err := ges.NewStreamID("A:B", "1"); !errors.Is(err, ges.ErrInvalidStreamID) {
+		t.Fatalf("expected a category with ':' to be rejected, got %v", err)
+	}
+}
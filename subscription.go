@@ -0,0 +1,238 @@
+package ges
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"time"
+)
+
+// Checkpointer persists how far a named consumer has processed the global stream.
+type Checkpointer interface {
+	// LoadCheckpoint returns the last processed position for name, or 0 if none was saved.
+	LoadCheckpoint(ctx context.Context, name string) (int64, error)
+
+	// SaveCheckpoint records position as the last processed position for name.
+	SaveCheckpoint(ctx context.Context, name string, position int64) error
+}
+
+// SubscriptionHandler processes a single event delivered by a Subscription.
+type SubscriptionHandler func(ctx context.Context, ev StoredEvent) error
+
+const (
+	defaultPageSize        = 100
+	defaultMinPollInterval = 100 * time.Millisecond
+	defaultMaxPollInterval = 5 * time.Second
+	defaultPollJitter      = 0.2
+	defaultGapWindow       = 5 * time.Second
+)
+
+// SubscriptionOption configures a Subscription.
+type SubscriptionOption func(*Subscription)
+
+// WithCheckpointer makes the subscription resume from, and record progress to, cp.
+// Without a checkpointer a subscription starts from the beginning on every Run.
+func WithCheckpointer(cp Checkpointer) SubscriptionOption {
+	return func(s *Subscription) { s.checkpointer = cp }
+}
+
+// WithPollInterval sets the adaptive polling bounds. The subscription polls every
+// minInterval while events keep arriving, doubles the wait on every empty poll up
+// to maxInterval, and drops back to minInterval as soon as events show up again.
+func WithPollInterval(minInterval, maxInterval time.Duration) SubscriptionOption {
+	return func(s *Subscription) {
+		s.minPoll = minInterval
+		s.maxPoll = max(minInterval, maxInterval)
+	}
+}
+
+// WithPollJitter randomizes every wait by ±fraction (e.g. 0.2 for ±20%) so that many
+// subscribers started together do not poll in lockstep. Zero disables jitter.
+func WithPollJitter(fraction float64) SubscriptionOption {
+	return func(s *Subscription) { s.jitter = min(max(fraction, 0), 1) }
+}
+
+// WithPageSize sets how many events the subscription reads at a time, 100 by
+// default. A subscription resuming far behind catches up page by page, so memory
+// stays bounded by the page size whatever the backlog; the checkpoint advances
+// after every handled event, so a crash resumes mid-catch-up.
+func WithPageSize(n int) SubscriptionOption {
+	return func(s *Subscription) { s.pageSize = max(n, 1) }
+}
+
+// WithReadOptions filters the events delivered to the handler, e.g. with
+// WithEventTypes. Filtering happens in the store. If the reader also implements
+// HeadReader, the checkpoint moves past skipped events once the subscription has
+// caught up, so they are not scanned again after a restart; see WithGapWindow.
+func WithReadOptions(opts ...ReadOption) SubscriptionOption {
+	return func(s *Subscription) { s.readOpts = append(s.readOpts, opts...) }
+}
+
+// WithGapWindow sets how long ago a filtered subscription must have seen a head
+// position before it checkpoints past the skipped events up to it, 5s by default.
+// Stores such as pgx take positions from a sequence, so an event can commit after
+// one with a higher position; the window gives such late events time to show up
+// and be delivered rather than skipped.
+func WithGapWindow(d time.Duration) SubscriptionOption {
+	return func(s *Subscription) { s.gapWindow = max(d, 0) }
+}
+
+// Subscription is a catch-up subscription over the global event stream: it reads
+// every event after its checkpoint in position order, hands each one to a handler,
+// and then keeps polling for new events.
+//
+// Delivery is at-least-once. The checkpoint advances only after the handler succeeds;
+// a failing handler is retried with backoff on the same event, indefinitely unless
+// a dead-letter policy is configured with WithDeadLetter.
+type Subscription struct {
+	name         string
+	reader       GlobalReader
+	handler      SubscriptionHandler
+	checkpointer Checkpointer
+	minPoll      time.Duration
+	maxPoll      time.Duration
+	jitter       float64
+	deadLetters  DeadLetterStore
+	maxAttempts  int
+	readOpts     []ReadOption
+	pageSize     int
+	gapWindow    time.Duration
+}
+
+// NewSubscription creates a subscription identified by name, which is also the
+// checkpoint key. Call Run to start processing.
+func NewSubscription(name string, reader GlobalReader, handler SubscriptionHandler, opts ...SubscriptionOption) *Subscription {
+	s := &Subscription{
+		name:      name,
+		reader:    reader,
+		handler:   handler,
+		minPoll:   defaultMinPollInterval,
+		maxPoll:   defaultMaxPollInterval,
+		jitter:    defaultPollJitter,
+		pageSize:  defaultPageSize,
+		gapWindow: defaultGapWindow,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Run processes events until ctx is cancelled, returning ctx.Err() in that case.
+// Errors from reading events or saving the checkpoint stop the subscription.
+func (s *Subscription) Run(ctx context.Context) error {
+	var position int64
+	if s.checkpointer != nil {
+		p, err := s.checkpointer.LoadCheckpoint(ctx, s.name)
+		if err != nil {
+			return fmt.Errorf("ges: subscription %s: could not load checkpoint: %w", s.name, err)
+		}
+		position = p
+	}
+
+	interval := s.minPoll
+	var failedPosition int64 // position of the event currently failing, if any
+	var attempts int
+	head, filtered := s.reader.(HeadReader)
+	filtered = filtered && !NewReadFilter(s.readOpts...).IsZero()
+	var settling int64       // head position waiting out the gap window, if above position
+	var settlingAt time.Time // when settling was read
+	for {
+		// Read the head first: once a filtered page comes back short, every matching
+		// event up to a head read at least the gap window ago has been seen, and the
+		// skipped ones can be checkpointed.
+		var headPosition int64
+		if filtered {
+			p, err := head.HeadPosition(ctx)
+			if err != nil {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				return fmt.Errorf("ges: subscription %s: could not read head position: %w", s.name, err)
+			}
+			headPosition = p
+		}
+
+		events, err := s.reader.ReadAll(ctx, position, s.pageSize, s.readOpts...)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return fmt.Errorf("ges: subscription %s: could not read events: %w", s.name, err)
+		}
+
+		failed := false
+		for _, ev := range events {
+			if err := s.handler(ctx, ev); err != nil {
+				if ev.Position != failedPosition {
+					failedPosition, attempts = ev.Position, 0
+				}
+				attempts++
+				if s.deadLetters == nil || attempts < s.maxAttempts {
+					failed = true
+					break
+				}
+				if dlErr := s.deadLetter(ctx, ev, attempts, err); dlErr != nil {
+					return dlErr
+				}
+			}
+			if err := s.advance(ctx, &position, ev.Position); err != nil {
+				return err
+			}
+		}
+		if !failed && len(events) < s.pageSize {
+			if settling <= position && headPosition > position {
+				settling, settlingAt = headPosition, time.Now()
+			}
+			if settling > position && time.Since(settlingAt) >= s.gapWindow {
+				if err := s.advance(ctx, &position, settling); err != nil {
+					return err
+				}
+			}
+		}
+
+		switch {
+		case failed || len(events) == 0:
+			// Idle or failing: wait, then back off further.
+			if err := s.wait(ctx, interval); err != nil {
+				return err
+			}
+			interval = min(interval*2, s.maxPoll)
+		case len(events) == s.pageSize:
+			// Catching up: read the next page right away.
+			interval = s.minPoll
+		default:
+			interval = s.minPoll
+			if err := s.wait(ctx, interval); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// advance moves *position to to and records it as the checkpoint.
+func (s *Subscription) advance(ctx context.Context, position *int64, to int64) error {
+	*position = to
+	if s.checkpointer == nil {
+		return nil
+	}
+	if err := s.checkpointer.SaveCheckpoint(ctx, s.name, to); err != nil {
+		return fmt.Errorf("ges: subscription %s: could not save checkpoint: %w", s.name, err)
+	}
+	return nil
+}
+
+// wait sleeps for d adjusted by jitter, or until ctx is done.
+func (s *Subscription) wait(ctx context.Context, d time.Duration) error {
+	if s.jitter > 0 {
+		d += time.Duration((rand.Float64()*2 - 1) * s.jitter * float64(d))
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
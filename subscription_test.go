@@ -0,0 +1,139 @@
+package ges_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mickamy/go-event-sourcing"
+)
+
+// lateCommitReader reads the events committed so far, which, as with positions taken
+// from a sequence, may be committed out of position order.
+type lateCommitReader struct {
+	mu         sync.Mutex
+	committed  []ges.StoredEvent
+	reads      atomic.Int64
+	checkpoint atomic.Int64
+}
+
+func (r *lateCommitReader) commit(ev ges.StoredEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.committed = append(r.committed, ev)
+	slices.SortFunc(r.committed, func(a, b ges.StoredEvent) int { return int(a.Position - b.Position) })
+}
+
+func (r *lateCommitReader) ReadAll(_ context.Context, from int64, limit int, opts ...ges.ReadOption) ([]ges.StoredEvent, error) {
+	defer r.reads.Add(1)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	filter := ges.NewReadFilter(opts...)
+	var out []ges.StoredEvent
+	for _, ev := range r.committed {
+		if ev.Position > from && filter.Match(ev) && len(out) < limit {
+			out = append(out, ev)
+		}
+	}
+	return out, nil
+}
+
+func (r *lateCommitReader) HeadPosition(context.Context) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.committed) == 0 {
+		return 0, nil
+	}
+	return r.committed[len(r.committed)-1].Position, nil
+}
+
+func (r *lateCommitReader) LoadCheckpoint(context.Context, string) (int64, error) {
+	return r.checkpoint.Load(), nil
+}
+
+func (r *lateCommitReader) SaveCheckpoint(_ context.Context, _ string, position int64) error {
+	r.checkpoint.Store(position)
+	return nil
+}
+
+func TestSubscription_GapWindow(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	reader := &lateCommitReader{}
+	reader.commit(ges.StoredEvent{StreamID: "Account:1", Type: "Opened", Position: 1})
+	reader.commit(ges.StoredEvent{StreamID: "Account:1", Type: "Closed", Position: 3})
+
+	delivered := make(chan int64, 1)
+	sub := ges.NewSubscription("deposits", reader, func(_ context.Context, ev ges.StoredEvent) error {
+		delivered <- ev.Position
+		return nil
+	},
+		ges.WithCheckpointer(reader),
+		ges.WithReadOptions(ges.WithEventTypes("Deposited")),
+		ges.WithPollInterval(time.Millisecond, time.Millisecond),
+		ges.WithPollJitter(0),
+		ges.WithGapWindow(time.Minute),
+	)
+	done := make(chan error, 1)
+	go func() { done <- sub.Run(ctx) }()
+
+	// Position 2 commits after the subscription has seen head 3.
+	for reader.reads.Load() < 2 {
+		time.Sleep(time.Millisecond)
+	}
+	if got := reader.checkpoint.Load(); got != 0 {
+		t.Fatalf("expected the checkpoint to wait out the gap window, got %d", got)
+	}
+	reader.commit(ges.StoredEvent{StreamID: "Account:1", Type: "Deposited", Position: 2})
+
+	select {
+	case got := <-delivered:
+		if got != 2 {
+			t.Fatalf("expected position 2, got %d", got)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("the late event was never delivered")
+	}
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestSubscription_SkipsFilteredEvents(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	reader := &lateCommitReader{}
+	reader.commit(ges.StoredEvent{StreamID: "Account:1", Type: "Opened", Position: 1})
+	reader.commit(ges.StoredEvent{StreamID: "Account:1", Type: "Closed", Position: 2})
+
+	sub := ges.NewSubscription("deposits", reader, func(context.Context, ges.StoredEvent) error { return nil },
+		ges.WithCheckpointer(reader),
+		ges.WithReadOptions(ges.WithEventTypes("Deposited")),
+		ges.WithPollInterval(time.Millisecond, time.Millisecond),
+		ges.WithPollJitter(0),
+		ges.WithGapWindow(10*time.Millisecond),
+	)
+	done := make(chan error, 1)
+	go func() { done <- sub.Run(ctx) }()
+
+	deadline := time.Now().Add(time.Second)
+	for reader.checkpoint.Load() != 2 {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the checkpoint to move past the skipped events, got %d", reader.checkpoint.Load())
+		}
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	<-done
+}
@@ -0,0 +1,126 @@
+package ges
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// syncBatchSize is the number of events Sync reads per round trip.
+const syncBatchSize = 500
+
+// Sync copies the events of src after fromPosition to dst in global position order,
+// preserving stream IDs, versions, payload encodings, metadata and times, and returns
+// the position of the last event copied (fromPosition if there was none), from which
+// a later Sync resumes. It suits migrating between stores or maintaining a replica.
+//
+// src must implement GlobalReader and RawLoader, and should implement RawRangeLoader,
+// so that each page reads only the events it copies. Events already
+// present in dst are skipped, so re-running Sync from an earlier position is safe;
+// a stream in dst that lacks versions before the first one copied fails the sync.
+func Sync(ctx context.Context, src, dst EventStore, fromPosition int64) (int64, error) {
+	reader, ok := src.(GlobalReader)
+	if !ok {
+		return fromPosition, fmt.Errorf("ges: sync source %T cannot read across streams", src)
+	}
+	loader, ok := src.(RawLoader)
+	if !ok {
+		return fromPosition, ErrRawUnsupported
+	}
+	appender, ok := dst.(RawAppender)
+	if !ok {
+		return fromPosition, ErrRawUnsupported
+	}
+
+	return readRaw(ctx, reader, loader, fromPosition, func(streamID string, batch []StoredEvent) error {
+		return syncStream(ctx, appender, streamID, batch)
+	})
+}
+
+// readRaw calls fn with the raw events after fromPosition in global position order,
+// in runs of consecutive events of one stream, and returns the position of the last
+// event passed to fn (fromPosition if there was none). The raw events carry the
+// positions they were read at.
+func readRaw(ctx context.Context, reader GlobalReader, loader RawLoader, fromPosition int64, fn func(streamID string, batch []StoredEvent) error) (int64, error) {
+	position := fromPosition
+	for {
+		if err := ctx.Err(); err != nil {
+			return position, err
+		}
+		events, err := reader.ReadAll(ctx, position, syncBatchSize)
+		if err != nil {
+			return position, err
+		}
+
+		for start := 0; start < len(events); {
+			// Consecutive events of the same stream are copied together.
+			end := start + 1
+			for end < len(events) && events[end].StreamID == events[start].StreamID {
+				end++
+			}
+			run := events[start:end]
+			streamID := run[0].StreamID
+
+			batch, err := loadRawRange(ctx, loader, streamID, run[0].Version-1, run[len(run)-1].Version)
+			if err != nil {
+				return position, err
+			}
+			positions := make(map[int64]int64, len(run))
+			for _, ev := range run {
+				positions[ev.Version] = ev.Position
+			}
+			for i := range batch {
+				batch[i].Position = positions[batch[i].Version]
+			}
+			if err := fn(streamID, batch); err != nil {
+				return position, err
+			}
+			position = run[len(run)-1].Position
+			start = end
+		}
+		if len(events) < syncBatchSize {
+			return position, nil
+		}
+	}
+}
+
+// loadRawRange returns the raw events of streamID with from < Version <= to. Loaders
+// that are not RawRangeLoaders load the rest of the stream, which is then cut at to.
+func loadRawRange(ctx context.Context, loader RawLoader, streamID string, from, to int64) ([]StoredEvent, error) {
+	if ranged, ok := loader.(RawRangeLoader); ok {
+		return ranged.LoadRawRange(ctx, streamID, from, to)
+	}
+	events, err := loader.LoadRaw(ctx, streamID, from)
+	if err != nil {
+		return nil, err
+	}
+	for i, ev := range events {
+		if ev.Version > to {
+			return events[:i], nil
+		}
+	}
+	return events, nil
+}
+
+// syncStream appends consecutive events of streamID, skipping those dst already has.
+func syncStream(ctx context.Context, appender RawAppender, streamID string, events []StoredEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+	first := events[0].Version
+	_, err := appender.AppendRaw(ctx, streamID, first-1, events)
+	var conflict *VersionConflictError
+	if !errors.As(err, &conflict) {
+		if err != nil {
+			return fmt.Errorf("ges: sync %s at version %d: %w", streamID, first, err)
+		}
+		return nil
+	}
+	if conflict.ActualVersion <= first-1 {
+		return fmt.Errorf("ges: sync %s at version %d: destination is at version %d: %w",
+			streamID, first, conflict.ActualVersion, err)
+	}
+	// dst already has some or all of the events.
+	rest := events[min(conflict.ActualVersion-first+1, int64(len(events))):]
+	return syncStream(ctx, appender, streamID, rest)
+}
@@ -0,0 +1,69 @@
+package ges
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// TenantStore is an EventStore scoped to one tenant; see ForTenant.
+type TenantStore struct {
+	store    EventStore
+	tenantID string
+}
+
+// ForTenant returns store scoped to tenantID: Append records tenantID under
+// MetadataTenantID on every event, and ReadAll returns only events recorded with
+// it, as if called with WithMetadataValue(MetadataTenantID, tenantID). Stores that
+// index the key (see the pgx store's WithIndexedMetadata) serve such reads from
+// the index.
+//
+// Load and snapshots go to store unchanged: a stream belongs to whoever knows its
+// ID, so stream IDs should be unique across tenants.
+func ForTenant(store EventStore, tenantID string) *TenantStore {
+	return &TenantStore{store: store, tenantID: tenantID}
+}
+
+// TenantID returns the tenant the store is scoped to.
+func (s *TenantStore) TenantID() string { return s.tenantID }
+
+// Load implements EventStore.
+func (s *TenantStore) Load(ctx context.Context, streamID string, fromVersion int64) ([]Event, int64, error) {
+	return s.store.Load(ctx, streamID, fromVersion)
+}
+
+// Append implements EventStore, adding the tenant to md. Metadata naming another
+// tenant is rejected with ErrTenantMismatch before anything is written.
+func (s *TenantStore) Append(ctx context.Context, streamID string, expectedVersion int64, events []Event, md Metadata) (int64, error) {
+	if v, ok := md[MetadataTenantID]; ok && fmt.Sprint(v) != s.tenantID {
+		return 0, fmt.Errorf("%w: append to %s with tenant %v in a store for %s", ErrTenantMismatch, streamID, v, s.tenantID)
+	}
+	return s.store.Append(ctx, streamID, expectedVersion, events, md.Merge(Metadata{MetadataTenantID: s.tenantID}))
+}
+
+// SaveSnapshot implements EventStore.
+func (s *TenantStore) SaveSnapshot(ctx context.Context, streamID string, version int64, state any) error {
+	return s.store.SaveSnapshot(ctx, streamID, version, state)
+}
+
+// LoadSnapshot implements EventStore.
+func (s *TenantStore) LoadSnapshot(ctx context.Context, streamID string) (Snapshot, error) {
+	return s.store.LoadSnapshot(ctx, streamID)
+}
+
+// ReadAll implements GlobalReader, returning only the tenant's events. It fails
+// with an error wrapping errors.ErrUnsupported if the underlying store is not a
+// GlobalReader.
+func (s *TenantStore) ReadAll(ctx context.Context, fromPosition int64, limit int, opts ...ReadOption) ([]StoredEvent, error) {
+	reader, ok := s.store.(GlobalReader)
+	if !ok {
+		return nil, fmt.Errorf("ges: %T cannot read across streams: %w", s.store, errors.ErrUnsupported)
+	}
+	return reader.ReadAll(ctx, fromPosition, limit, append(slices.Clip(opts), WithMetadataValue(MetadataTenantID, s.tenantID))...)
+}
+
+var (
+	_ EventStore   = (*TenantStore)(nil)
+	_ GlobalReader = (*TenantStore)(nil)
+)
@@ -0,0 +1,72 @@
+package ges_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/mickamy/go-event-sourcing"
+)
+
+// tenantSpy records appended events with their metadata and serves them to ReadAll.
+type tenantSpy struct {
+	*spyStore
+	log []ges.StoredEvent
+}
+
+func (s *tenantSpy) Append(ctx context.Context, streamID string, expected int64, events []ges.Event, md ges.Metadata) (int64, error) {
+	v, err := s.spyStore.Append(ctx, streamID, expected, events, md)
+	if err != nil {
+		return 0, err
+	}
+	for i := range events {
+		s.log = append(s.log, ges.StoredEvent{StreamID: streamID, Version: expected + int64(i) + 1, Position: int64(len(s.log) + 1), Metadata: md})
+	}
+	return v, nil
+}
+
+func (s *tenantSpy) ReadAll(_ context.Context, from int64, limit int, opts ...ges.ReadOption) ([]ges.StoredEvent, error) {
+	filter := ges.NewReadFilter(opts...)
+	var out []ges.StoredEvent
+	for _, ev := range s.log {
+		if ev.Position > from && filter.Match(ev) && len(out) < limit {
+			out = append(out, ev)
+		}
+	}
+	return out, nil
+}
+
+func TestForTenant(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	spy := &tenantSpy{spyStore: newSpyStore()}
+	acme, globex := ges.ForTenant(spy, "acme"), ges.ForTenant(spy, "globex")
+
+	if _, err := acme.Append(ctx, "Counter:acme", 0, []ges.Event{Deposited{Amount: 1}}, ges.Metadata{ges.MetadataUserID: "u1"}); err != nil {
+		t.Fatalf("append failed: %v", err)
+	}
+	if _, err := globex.Append(ctx, "Counter:globex", 0, []ges.Event{Deposited{Amount: 2}}, nil); err != nil {
+		t.Fatalf("append failed: %v", err)
+	}
+	if md := spy.log[0].Metadata; md[ges.MetadataTenantID] != "acme" || md[ges.MetadataUserID] != "u1" {
+		t.Fatalf("expected the tenant to be added to the metadata, got %v", md)
+	}
+
+	events, err := acme.ReadAll(ctx, 0, 10)
+	if err != nil {
+		t.Fatalf("read all failed: %v", err)
+	}
+	if len(events) != 1 || events[0].StreamID != "Counter:acme" {
+		t.Fatalf("expected only the acme event, got %+v", events)
+	}
+
+	_, err = acme.Append(ctx, "Counter:acme", 1, []ges.Event{Deposited{Amount: 3}}, ges.Metadata{ges.MetadataTenantID: "globex"})
+	if !errors.Is(err, ges.ErrTenantMismatch) {
+		t.Fatalf("expected ErrTenantMismatch, got %v", err)
+	}
+
+	if _, err := ges.ForTenant(newSpyStore(), "acme").ReadAll(ctx, 0, 10); !errors.Is(err, errors.ErrUnsupported) {
+		t.Fatalf("expected errors.ErrUnsupported without a GlobalReader, got %v", err)
+	}
+}
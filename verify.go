@@ -0,0 +1,100 @@
+package ges
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+)
+
+// VerifySnapshot checks that the latest snapshot of streamID is trustworthy, e.g.
+// after a codec or upcaster change. It rebuilds the aggregate twice with fresh
+// aggregates from rebuild: once by replaying every event while ignoring the
+// snapshot, and once the way Repository.Load does, from the snapshot plus the
+// events after it. The states captured by their Snapshot methods are compared
+// with reflect.DeepEqual, and a difference is reported as a *SnapshotMismatchError.
+//
+// Streams without a snapshot pass. The result wraps ErrSnapshotNotVerified if the
+// snapshot cannot be checked: the aggregate does not take it (see Rehydrate), or the
+// stream was truncated (see Truncater), so it cannot be replayed from its start.
+// The aggregate must implement Snapshotter and capture a non-nil state, otherwise
+// the result wraps ErrSnapshotUnsupported.
+func VerifySnapshot(ctx context.Context, store EventStore, streamID string, rebuild func() Aggregate) error {
+	return verifySnapshot(ctx, store, store, streamID, rebuild, snapshotSchemaOf, capturedState)
+}
+
+// verifySnapshot implements VerifySnapshot with the snapshots of snapshots, the
+// snapshot schema version given by schema, and states captured by capture.
+func verifySnapshot(
+	ctx context.Context,
+	store EventStore,
+	snapshots SnapshotStore,
+	streamID string,
+	rebuild func() Aggregate,
+	schema func(Aggregate) int,
+	capture func(Aggregate) (any, error),
+) error {
+	snap, err := snapshots.LoadSnapshot(ctx, streamID)
+	if err != nil {
+		return err
+	}
+	if !snap.Found {
+		return nil
+	}
+
+	replayed, restored := rebuild(), rebuild()
+	applied, err := applySnapshot(restored, streamID, snap, schema(restored))
+	if err != nil {
+		return fmt.Errorf("ges: could not restore %s from its snapshot: %w", streamID, err)
+	}
+	if !applied {
+		return fmt.Errorf("%w: %T does not take the snapshot of %s", ErrSnapshotNotVerified, restored, streamID)
+	}
+	if _, err := rehydrate(ctx, store, snapshots, streamID, restored, false, 0); err != nil {
+		return fmt.Errorf("ges: could not restore %s from its snapshot: %w", streamID, err)
+	}
+
+	events, last, err := store.Load(ctx, streamID, 0)
+	if err != nil {
+		return fmt.Errorf("ges: could not replay %s: %w", streamID, err)
+	}
+	if int64(len(events)) < last {
+		return fmt.Errorf("%w: %s was truncated before version %d", ErrSnapshotNotVerified, streamID, last-int64(len(events))+1)
+	}
+	for _, e := range events {
+		replayed.Apply(e)
+	}
+	if err := checkReplayed(streamID, replayed, last); err != nil {
+		return fmt.Errorf("ges: could not replay %s: %w", streamID, err)
+	}
+
+	want, err := capture(replayed)
+	if err != nil {
+		return err
+	}
+	got, err := capture(restored)
+	if err != nil {
+		return err
+	}
+	if replayed.Version() != restored.Version() || !reflect.DeepEqual(want, got) {
+		return &SnapshotMismatchError{
+			StreamID:        streamID,
+			SnapshotVersion: snap.Version,
+			Replayed:        want,
+			Restored:        got,
+		}
+	}
+	return nil
+}
+
+// capturedState returns agg's state as captured by its Snapshot method.
+func capturedState(agg Aggregate) (any, error) {
+	s, ok := agg.(Snapshotter)
+	if !ok {
+		return nil, fmt.Errorf("%w: %T does not implement Snapshotter", ErrSnapshotUnsupported, agg)
+	}
+	state := s.Snapshot()
+	if state == nil {
+		return nil, fmt.Errorf("%w: %T captured no state", ErrSnapshotUnsupported, agg)
+	}
+	return state, nil
+}
@@ -0,0 +1,106 @@
+package ges_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/mickamy/go-event-sourcing"
+)
+
+func newSnapshottingCounter(streamID string) *counter {
+	var c counter
+	c.Init(streamID, func(e ges.Event) { c.total += e.(Deposited).Amount },
+		ges.WithSnapshotter(
+			func() any { return c.total },
+			func(state any) error {
+				c.total = state.(int)
+				return nil
+			},
+		),
+	)
+	return &c
+}
+
+// truncatedStore serves the events of spyStore as if those before version
+// dropped+1 had been truncated.
+type truncatedStore struct {
+	*spyStore
+	dropped int64
+}
+
+func (s truncatedStore) Load(ctx context.Context, streamID string, from int64) ([]ges.Event, int64, error) {
+	return s.spyStore.Load(ctx, streamID, max(from, s.dropped))
+}
+
+func TestVerifySnapshot(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	store := newSpyStore()
+	if _, err := store.Append(ctx, "Counter:1", 0, []ges.Event{Deposited{Amount: 1}, Deposited{Amount: 2}, Deposited{Amount: 3}}, nil); err != nil {
+		t.Fatalf("append failed: %v", err)
+	}
+	rebuild := func() ges.Aggregate { return newSnapshottingCounter("Counter:1") }
+
+	if err := ges.VerifySnapshot(ctx, store, "Counter:1", rebuild); err != nil {
+		t.Fatalf("expected a stream without a snapshot to pass, got %v", err)
+	}
+
+	if err := store.SaveSnapshot(ctx, "Counter:1", 2, 3); err != nil {
+		t.Fatalf("save snapshot failed: %v", err)
+	}
+	if err := ges.VerifySnapshot(ctx, store, "Counter:1", rebuild); err != nil {
+		t.Fatalf("expected a correct snapshot to pass, got %v", err)
+	}
+
+	if err := store.SaveSnapshot(ctx, "Counter:1", 2, 30); err != nil {
+		t.Fatalf("save snapshot failed: %v", err)
+	}
+	var mismatch *ges.SnapshotMismatchError
+	if err := ges.VerifySnapshot(ctx, store, "Counter:1", rebuild); !errors.As(err, &mismatch) {
+		t.Fatalf("expected a *SnapshotMismatchError, got %v", err)
+	}
+	if mismatch.SnapshotVersion != 2 || mismatch.Replayed != 6 || mismatch.Restored != 33 {
+		t.Fatalf("unexpected mismatch %+v", mismatch)
+	}
+
+	// counter restores snapshots but cannot capture its state, so there is nothing to compare.
+	err := ges.VerifySnapshot(ctx, store, "Counter:1", func() ges.Aggregate { return newCounter("Counter:1") })
+	if !errors.Is(err, ges.ErrSnapshotUnsupported) {
+		t.Fatalf("expected ErrSnapshotUnsupported, got %v", err)
+	}
+}
+
+func TestVerifySnapshot_NotVerified(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	store := newSpyStore()
+	store.events["Counter:1"] = []ges.Event{Deposited{Amount: 1}, Deposited{Amount: 2}, Deposited{Amount: 3}}
+	if err := store.SaveSnapshot(ctx, "Counter:1", 2, 3); err != nil {
+		t.Fatalf("save snapshot failed: %v", err)
+	}
+
+	// The aggregate ignores the snapshot, so both rebuilds would be full replays.
+	declining := func() ges.Aggregate {
+		var c counter
+		c.Init("Counter:1", func(e ges.Event) { c.total += e.(Deposited).Amount },
+			ges.WithSnapshotter(
+				func() any { return c.total },
+				func(any) error { return ges.ErrSnapshotUnsupported },
+			),
+		)
+		return &c
+	}
+	if err := ges.VerifySnapshot(ctx, store, "Counter:1", declining); !errors.Is(err, ges.ErrSnapshotNotVerified) {
+		t.Fatalf("expected ErrSnapshotNotVerified, got %v", err)
+	}
+
+	// Without version 1 the replay cannot be compared with the snapshot.
+	truncated := truncatedStore{store, 1}
+	rebuild := func() ges.Aggregate { return newSnapshottingCounter("Counter:1") }
+	if err := ges.VerifySnapshot(ctx, truncated, "Counter:1", rebuild); !errors.Is(err, ges.ErrSnapshotNotVerified) {
+		t.Fatalf("expected ErrSnapshotNotVerified, got %v", err)
+	}
+}
@@ -0,0 +1,50 @@
+package ges
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// WaitForPosition blocks until reader's HeadPosition reaches position, so a caller
+// that appended to a primary (see PositionAppender) can read its own writes from a
+// replica. It polls with a backoff from 5ms to 200ms and gives up after timeout, or
+// when ctx ends, with an error wrapping ErrPositionNotReached; a timeout of 0 waits
+// for ctx alone. Positions of 0 or less return immediately.
+//
+// Concurrent appends may commit out of position order, so while other writers are
+// active the head can pass position shortly before the event at position becomes
+// visible. Reads that must see one specific event should then check for it.
+func WaitForPosition(ctx context.Context, reader HeadReader, position int64, timeout time.Duration) error {
+	if position <= 0 {
+		return nil
+	}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	backoff := ExponentialBackoff(5*time.Millisecond, 200*time.Millisecond)
+	var head int64
+	for attempt := 1; ; attempt++ {
+		p, err := reader.HeadPosition(ctx)
+		if err != nil && ctx.Err() == nil {
+			return fmt.Errorf("ges: could not read head position: %w", err)
+		}
+		if err == nil {
+			if p >= position {
+				return nil
+			}
+			head = p
+		}
+
+		timer := time.NewTimer(backoff(attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("%w: head at %d, waiting for %d: %w", ErrPositionNotReached, head, position, context.Cause(ctx))
+		case <-timer.C:
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package ges_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mickamy/go-event-sourcing"
+)
+
+// laggingReplica reports a head that advances by one on every read.
+type laggingReplica struct{ head atomic.Int64 }
+
+func (r *laggingReplica) HeadPosition(context.Context) (int64, error) {
+	return r.head.Add(1) - 1, nil
+}
+
+func TestWaitForPosition(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	replica := &laggingReplica{}
+	if err := ges.WaitForPosition(ctx, replica, 3, time.Second); err != nil {
+		t.Fatalf("expected the replica to catch up, got %v", err)
+	}
+	if reads := replica.head.Load(); reads != 4 {
+		t.Fatalf("expected 4 polls, got %d", reads)
+	}
+
+	err := ges.WaitForPosition(ctx, replica, 1_000, 20*time.Millisecond)
+	if !errors.Is(err, ges.ErrPositionNotReached) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected ErrPositionNotReached after the timeout, got %v", err)
+	}
+}